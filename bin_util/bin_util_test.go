@@ -76,3 +76,17 @@ func TestArrayToPairConversion(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestPairArrayView(t *testing.T) {
+	bArr := []byte{0, 0xfa, 0x2a, 0xba, 0xac, 0x12, 0x91, 0x1d, 0xef}
+	for _, b := range [][]byte{bArr[1:], append([]byte{}, bArr[1:]...)} {
+		arr := bin_util.PairArrayView(b)
+		pairArray := *(*[]customPair)(unsafe.Pointer(&arr))
+		if len(pairArray) != 1 || pairArray[0].a != 0xacba2afa || pairArray[0].b != 0xef1d9112 {
+			t.Fail()
+		}
+	}
+	if len(bin_util.PairArrayView(nil)) != 0 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !(386 || amd64 || arm || arm64 || loong64 || mips64le || mipsle || ppc64le || riscv64 || wasm)
+
+package bin_util
+
+// Arrays have to be decoded on hosts that are not little endian.
+const nativeLittleEndian = false
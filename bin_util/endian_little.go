@@ -0,0 +1,7 @@
+//go:build 386 || amd64 || arm || arm64 || loong64 || mips64le || mipsle || ppc64le || riscv64 || wasm
+
+package bin_util
+
+// The storage format matches the memory layout of the host so arrays can
+// be used without decoding them.
+const nativeLittleEndian = true
@@ -0,0 +1,21 @@
+package bin_util
+
+import "unsafe"
+
+// PairArrayView returns the pairs stored in bytes without copying them
+// if the host is little endian and bytes is suitably aligned, otherwise
+// it falls back to ByteArrayToPairArray. The pairs can share memory with
+// bytes so neither of them should be modified.
+func PairArrayView(bytes []byte) []pair {
+	if len(bytes)%8 != 0 {
+		panic("Invalid byte size")
+	}
+	if !nativeLittleEndian || len(bytes) == 0 {
+		return ByteArrayToPairArray(bytes)
+	}
+	data := unsafe.SliceData(bytes)
+	if uintptr(unsafe.Pointer(data))%unsafe.Alignof(pair{}) != 0 {
+		return ByteArrayToPairArray(bytes)
+	}
+	return unsafe.Slice((*pair)(unsafe.Pointer(data)), len(bytes)/8)
+}
@@ -0,0 +1,243 @@
+// Package builder writes the files of a graph from a list of edges, in
+// any of the layouts of csrfile, so that small graphs can be built without
+// the LDBC converter.
+package builder
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+// Options control how the nodes are split into files and how the files
+// are encoded. One of NodesPerFile and FileBytes has to be set, a file is
+// ended as soon as it reaches either of them.
+type Options struct {
+	// NodesPerFile is the maximum number of nodes in a file.
+	NodesPerFile int
+	// FileBytes is the size that a file should not grow beyond. It is
+	// computed from the uncompressed edges, files with a single node can
+	// be larger.
+	FileBytes int
+	Encode    csrfile.EncodeOptions
+}
+
+func (o *Options) validate() error {
+	if o.NodesPerFile <= 0 && o.FileBytes <= 0 {
+		return errors.New("Either the number of nodes or the number of bytes per file is required")
+	}
+	return nil
+}
+
+// File is an encoded file that stores the nodes from Start to End, both
+// inclusive.
+type File struct {
+	Name       string
+	Start, End uint64
+	Data       []byte
+}
+
+// FileName is the name of the file that stores the nodes from start to
+// end.
+func FileName(start, end uint64) string {
+	return fmt.Sprintf("nodes-%d-%d", start, end)
+}
+
+// MaxNodes is the largest number of nodes from the smallest till the
+// largest node of a Builder. A node is allocated for every ID in between,
+// so an edge list with IDs that are further apart is rejected instead of
+// exhausting the memory.
+var MaxNodes uint64 = 1 << 30
+
+// Builder collects the edges of a graph. Every edge is stored as an
+// outgoing edge of its source and an incoming edge of its destination.
+type Builder struct {
+	edges    []edge
+	min, max uint64
+}
+
+type edge struct {
+	src   uint64
+	label uint32
+	dst   uint64
+}
+
+func New() *Builder {
+	return &Builder{min: math.MaxUint64}
+}
+
+func (b *Builder) Add(src uint64, label uint32, dst uint64) {
+	b.edges = append(b.edges, edge{src, label, dst})
+	b.min = min(b.min, src, dst)
+	b.max = max(b.max, src, dst)
+}
+
+func (b *Builder) NumEdges() int {
+	return len(b.edges)
+}
+
+// Nodes returns the nodes from the smallest till the largest node of any
+// edge, nodes without edges included. The edges of every node are sorted
+// by label and destination. An error is returned if there are more than
+// MaxNodes nodes.
+func (b *Builder) Nodes() (uint64, []csrfile.Node, error) {
+	if len(b.edges) == 0 {
+		return 0, nil, nil
+	}
+	//The span is compared before adding one so that it can not wrap.
+	if b.max-b.min >= MaxNodes {
+		return 0, nil, fmt.Errorf("Nodes %d to %d are more than the %d nodes that can be built", b.min, b.max, MaxNodes)
+	}
+	nodes := make([]csrfile.Node, b.max-b.min+1)
+	for _, e := range b.edges {
+		out, in := &nodes[e.src-b.min], &nodes[e.dst-b.min]
+		out.Out = append(out.Out, csrfile.Edge{Label: e.label, Dest: e.dst})
+		in.In = append(in.In, csrfile.Edge{Label: e.label, Dest: e.src})
+	}
+	for i := range nodes {
+		slices.SortFunc(nodes[i].Out, compareEdges)
+		slices.SortFunc(nodes[i].In, compareEdges)
+	}
+	return b.min, nodes, nil
+}
+
+func compareEdges(a, b csrfile.Edge) int {
+	if c := cmp.Compare(a.Label, b.Label); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Dest, b.Dest)
+}
+
+// Files splits the nodes of the graph into files.
+func (b *Builder) Files(opts Options) ([]File, error) {
+	start, nodes, err := b.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("Graph has no edges")
+	}
+	return Split(start, nodes, opts)
+}
+
+// Split encodes the nodes starting at start into files of the size of the
+// options.
+func Split(start uint64, nodes []csrfile.Node, opts Options) ([]File, error) {
+	if len(nodes) == 0 {
+		return nil, errors.New("There are no nodes to split")
+	}
+	s, err := NewSplitter(start, opts)
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	for _, n := range nodes {
+		f, err := s.Add(n)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			files = append(files, *f)
+		}
+	}
+	f, err := s.Flush()
+	if err != nil {
+		return nil, err
+	}
+	return append(files, *f), nil
+}
+
+// Splitter splits nodes into files as they are added, so that a graph can
+// be rewritten without holding all of its nodes in memory.
+type Splitter struct {
+	opts    Options
+	idWidth uint64
+	//First node of the file that is being filled.
+	start   uint64
+	pending []csrfile.Node
+	size    uint64
+}
+
+// NewSplitter returns a splitter whose first node is start.
+func NewSplitter(start uint64, opts Options) (*Splitter, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	idWidth := uint64(opts.Encode.IDWidth)
+	if idWidth == 0 {
+		idWidth = 4
+	}
+	return &Splitter{opts: opts, idWidth: idWidth, start: start}, nil
+}
+
+// Add adds the next node and returns the file that was completed before
+// it, if any.
+func (s *Splitter) Add(n csrfile.Node) (*File, error) {
+	node := s.start + uint64(len(s.pending))
+	if s.idWidth == 4 {
+		if node > math.MaxUint32 {
+			return nil, fmt.Errorf("Node %d does not fit in 4 byte node IDs", node)
+		}
+		for _, edges := range [][]csrfile.Edge{n.Out, n.In} {
+			for _, e := range edges {
+				if e.Dest > math.MaxUint32 {
+					return nil, fmt.Errorf("Neighbour %d of node %d does not fit in 4 byte node IDs", e.Dest, node)
+				}
+			}
+		}
+	}
+	nodeSize := 2*s.idWidth + (4+s.idWidth)*uint64(len(n.Out)+len(n.In))
+	full := s.opts.NodesPerFile > 0 && len(s.pending) == s.opts.NodesPerFile
+	if s.opts.FileBytes > 0 && len(s.pending) > 0 && s.size+nodeSize > uint64(s.opts.FileBytes) {
+		full = true
+	}
+	var res *File
+	if full {
+		var err error
+		if res, err = s.Flush(); err != nil {
+			return nil, err
+		}
+	}
+	s.pending = append(s.pending, n)
+	s.size += nodeSize
+	return res, nil
+}
+
+// Flush returns the file of the nodes that were added after the last
+// file, nil if there are none.
+func (s *Splitter) Flush() (*File, error) {
+	if len(s.pending) == 0 {
+		return nil, nil
+	}
+	f, err := encodeFile(s.start, s.pending, s.opts)
+	if err != nil {
+		return nil, err
+	}
+	s.start += uint64(len(s.pending))
+	s.pending, s.size = nil, 0
+	return &f, nil
+}
+
+func encodeFile(start uint64, nodes []csrfile.Node, opts Options) (File, error) {
+	end := start + uint64(len(nodes)) - 1
+	data, err := csrfile.Encode(start, nodes, opts.Encode)
+	if err != nil {
+		return File{}, fmt.Errorf("Unable to encode %s: %w", FileName(start, end), err)
+	}
+	return File{Name: FileName(start, end), Start: start, End: end, Data: data}, nil
+}
+
+// Write puts all the files under prefix.
+func Write(writer storage.Writer, prefix string, files []File) error {
+	for _, f := range files {
+		if err := writer.Put(prefix+f.Name, f.Data); err != nil {
+			return fmt.Errorf("Unable to write %s: %w", prefix+f.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package builder
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/graphaccess"
+	"github.com/adityachandla/graph_access_service/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+const edgeList = `src,label,dst
+0,1,2
+0,2,1
+3,1,0
+# comment
+
+2	1	3
+0,1,1
+`
+
+func TestReadEdgeList(t *testing.T) {
+	b := New()
+	assert.Nil(t, b.ReadEdgeList(strings.NewReader(edgeList)))
+	assert.Equal(t, 5, b.NumEdges())
+	start, nodes, err := b.Nodes()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), start)
+	assert.Len(t, nodes, 4)
+	assert.Equal(t, []csrfile.Edge{{Label: 1, Dest: 1}, {Label: 1, Dest: 2}, {Label: 2, Dest: 1}}, nodes[0].Out)
+	assert.Equal(t, []csrfile.Edge{{Label: 1, Dest: 0}, {Label: 2, Dest: 0}}, nodes[1].In)
+
+	//IDs that are too far apart are rejected, even if their span wraps.
+	for _, list := range []string{"0,1,1000000000000\n", "0,1,18446744073709551615\n"} {
+		b = New()
+		assert.Nil(t, b.ReadEdgeList(strings.NewReader(list)))
+		_, _, err = b.Nodes()
+		assert.NotNil(t, err)
+		_, err = b.Files(Options{NodesPerFile: 1})
+		assert.NotNil(t, err)
+	}
+
+	assert.NotNil(t, New().ReadEdgeList(strings.NewReader("0,1,2\n0,1\n")))
+	assert.NotNil(t, New().ReadEdgeList(strings.NewReader("0,1,2\n0,x,2\n")))
+}
+
+func TestSplit(t *testing.T) {
+	b := New()
+	assert.Nil(t, b.ReadEdgeList(strings.NewReader(edgeList)))
+	files, err := b.Files(Options{NodesPerFile: 3})
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+	assert.Equal(t, "nodes-0-2", files[0].Name)
+	assert.Equal(t, uint64(3), files[1].Start)
+
+	//The first node takes 8 bytes of offsets and 4 edges.
+	files, err = b.Files(Options{FileBytes: 40})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), files[0].End)
+	for _, f := range files {
+		h, _, err := csrfile.Decode(f.Data)
+		assert.Nil(t, err)
+		assert.Equal(t, f.Start, h.Start)
+	}
+
+	_, err = b.Files(Options{})
+	assert.NotNil(t, err)
+	_, err = New().Files(Options{NodesPerFile: 1})
+	assert.NotNil(t, err)
+}
+
+func TestBuiltGraphIsReadable(t *testing.T) {
+	b := New()
+	assert.Nil(t, b.ReadEdgeList(strings.NewReader(edgeList)))
+	for _, flags := range []csrfile.Flags{0, csrfile.ByteOffsets} {
+		dir := t.TempDir()
+		files, err := b.Files(Options{NodesPerFile: 2, Encode: csrfile.EncodeOptions{Flags: flags}})
+		assert.Nil(t, err)
+		fetcher := storage.InitializeFsService(dir)
+		assert.Nil(t, Write(fetcher.(storage.Writer), "", files))
+
+		var accessor graphaccess.GraphAccess
+		if flags == 0 {
+			accessor, err = graphaccess.NewSimpleCsr(fetcher)
+		} else {
+			accessor, err = graphaccess.NewOffsetCsr(fetcher, 0)
+		}
+		assert.Nil(t, err)
+		res, err := accessor.GetNeighbours(context.Background(),
+			graphaccess.Request{Node: 0, Label: 1, Direction: graphaccess.BOTH})
+		assert.Nil(t, err)
+		assert.Equal(t, []uint32{1, 2, 3}, res)
+		res, err = accessor.GetNeighbours(context.Background(),
+			graphaccess.Request{Node: 3, Label: 1, Direction: graphaccess.INCOMING})
+		assert.Nil(t, err)
+		assert.Equal(t, []uint32{2}, res)
+	}
+}
+
+func TestSplitter(t *testing.T) {
+	b := New()
+	assert.Nil(t, b.ReadEdgeList(strings.NewReader(edgeList)))
+	start, nodes, err := b.Nodes()
+	assert.Nil(t, err)
+	files, err := Split(start, nodes, Options{NodesPerFile: 3})
+	assert.Nil(t, err)
+
+	s, err := NewSplitter(start, Options{NodesPerFile: 3})
+	assert.Nil(t, err)
+	var added []File
+	for _, n := range nodes {
+		f, err := s.Add(n)
+		assert.Nil(t, err)
+		if f != nil {
+			added = append(added, *f)
+		}
+	}
+	assert.Len(t, added, 1)
+	last, err := s.Flush()
+	assert.Nil(t, err)
+	assert.Equal(t, files, append(added, *last))
+	last, err = s.Flush()
+	assert.Nil(t, err)
+	assert.Nil(t, last)
+
+	s, err = NewSplitter(0, Options{NodesPerFile: 3})
+	assert.Nil(t, err)
+	_, err = s.Add(csrfile.Node{Out: []csrfile.Edge{{Label: 1, Dest: 1 << 32}}})
+	assert.NotNil(t, err)
+}
+
+func TestRepartition(t *testing.T) {
+	b := New()
+	assert.Nil(t, b.ReadEdgeList(strings.NewReader(edgeList)))
+	files, err := b.Files(Options{NodesPerFile: 3, Encode: csrfile.EncodeOptions{Flags: csrfile.Varint | csrfile.ByteOffsets}})
+	assert.Nil(t, err)
+	src := storage.InitializeFsService(t.TempDir())
+	assert.Nil(t, Write(src.(storage.Writer), "", files))
+
+	dest := storage.InitializeFsService(t.TempDir())
+	written, err := Repartition(src, dest.(storage.Writer), Options{NodesPerFile: 1, Encode: csrfile.EncodeOptions{Flags: csrfile.ByteOffsets}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"nodes-0-0", "nodes-1-1", "nodes-2-2", "nodes-3-3"}, written)
+	accessor, err := graphaccess.NewOffsetCsr(dest, 0)
+	assert.Nil(t, err)
+	res, err := accessor.GetNeighbours(context.Background(),
+		graphaccess.Request{Node: 0, Label: 1, Direction: graphaccess.BOTH})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{1, 2, 3}, res)
+
+	//Graphs with missing nodes can not be repartitioned.
+	gap := storage.InitializeFsService(t.TempDir())
+	assert.Nil(t, Write(gap.(storage.Writer), "", files[:1]))
+	data, err := csrfile.Encode(5, []csrfile.Node{{}}, csrfile.EncodeOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, gap.(storage.Writer).Put(FileName(5, 5), data))
+	_, err = Repartition(gap, dest.(storage.Writer), Options{NodesPerFile: 1})
+	assert.NotNil(t, err)
+}
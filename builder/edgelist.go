@@ -0,0 +1,49 @@
+package builder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReadEdgeList adds the edges of a CSV or TSV edge list to the builder.
+// Every line has the source, the label and the destination of an edge,
+// separated by commas or tabs. Empty lines and lines starting with # are
+// skipped, as is a header on the first line.
+func (b *Builder) ReadEdgeList(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == '\t'
+		})
+		if len(fields) != 3 {
+			return fmt.Errorf("Line %d has %d fields instead of 3", lineNum, len(fields))
+		}
+		src, srcErr := strconv.ParseUint(strings.TrimSpace(fields[0]), 10, 64)
+		label, labelErr := strconv.ParseUint(strings.TrimSpace(fields[1]), 10, 32)
+		dst, dstErr := strconv.ParseUint(strings.TrimSpace(fields[2]), 10, 64)
+		if err := firstError(srcErr, labelErr, dstErr); err != nil {
+			if lineNum == 1 {
+				continue
+			}
+			return fmt.Errorf("Invalid edge on line %d: %w", lineNum, err)
+		}
+		b.Add(src, uint32(label), dst)
+	}
+	return scanner.Err()
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
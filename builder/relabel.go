@@ -0,0 +1,166 @@
+package builder
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/graphaccess"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+// Ordering returns the new ID minus start of every node.
+type Ordering func(start uint64, nodes []csrfile.Node) []uint32
+
+// Orderings are the orderings that a graph can be relabeled with, by name.
+var Orderings = map[string]Ordering{
+	"bfs":    BFSOrder,
+	"degree": DegreeOrder,
+}
+
+// BFSOrder numbers the nodes in the order of a breadth first search that
+// follows the edges in both directions, so that neighbours get nearby
+// IDs. A new search starts from the first node that was not reached, in
+// the order of the original IDs.
+func BFSOrder(start uint64, nodes []csrfile.Node) []uint32 {
+	visited := make([]bool, len(nodes))
+	order := make([]uint32, 0, len(nodes))
+	for root := range nodes {
+		if visited[root] {
+			continue
+		}
+		visited[root] = true
+		order = append(order, uint32(root))
+		for next := len(order) - 1; next < len(order); next++ {
+			n := &nodes[order[next]]
+			for _, edges := range [][]csrfile.Edge{n.Out, n.In} {
+				for _, e := range edges {
+					if e.Dest < start || e.Dest-start >= uint64(len(nodes)) || visited[e.Dest-start] {
+						continue
+					}
+					visited[e.Dest-start] = true
+					order = append(order, uint32(e.Dest-start))
+				}
+			}
+		}
+	}
+	return invert(order)
+}
+
+// DegreeOrder numbers the nodes by their number of edges, the nodes with
+// the most edges first, so that the nodes that are read the most share
+// their files.
+func DegreeOrder(_ uint64, nodes []csrfile.Node) []uint32 {
+	order := make([]uint32, len(nodes))
+	for i := range order {
+		order[i] = uint32(i)
+	}
+	degree := func(i uint32) int {
+		return len(nodes[i].Out) + len(nodes[i].In)
+	}
+	slices.SortStableFunc(order, func(a, b uint32) int {
+		return cmp.Compare(degree(b), degree(a))
+	})
+	return invert(order)
+}
+
+// invert turns the list of nodes in their new order into the new index of
+// every node.
+func invert(order []uint32) []uint32 {
+	newIndex := make([]uint32, len(order))
+	for i, node := range order {
+		newIndex[node] = uint32(i)
+	}
+	return newIndex
+}
+
+// Relabel returns the nodes in the order of their new IDs with their
+// neighbours relabeled, neighbours outside of the nodes keep their IDs.
+func Relabel(start uint64, nodes []csrfile.Node, newIndex []uint32) []csrfile.Node {
+	relabel := func(edges []csrfile.Edge) []csrfile.Edge {
+		res := make([]csrfile.Edge, len(edges))
+		for i, e := range edges {
+			if e.Dest >= start && e.Dest-start < uint64(len(nodes)) {
+				e.Dest = start + uint64(newIndex[e.Dest-start])
+			}
+			res[i] = e
+		}
+		slices.SortFunc(res, compareEdges)
+		return res
+	}
+	res := make([]csrfile.Node, len(nodes))
+	for i, n := range nodes {
+		res[newIndex[i]] = csrfile.Node{Out: relabel(n.Out), In: relabel(n.In)}
+	}
+	return res
+}
+
+// ReadGraph reads all the nodes of the graph in src, its files have to
+// cover a single range of nodes.
+func ReadGraph(src storage.Fetcher) (uint64, []csrfile.Node, error) {
+	entries, err := graphEntries(src)
+	if err != nil {
+		return 0, nil, err
+	}
+	var nodes []csrfile.Node
+	for _, e := range entries {
+		fileNodes, err := readNodes(src, e)
+		if err != nil {
+			return 0, nil, err
+		}
+		nodes = append(nodes, fileNodes...)
+	}
+	return entries[0].Header.Start, nodes, nil
+}
+
+// RelabelGraph rewrites the graph in src with the IDs of the ordering into
+// files of the size of the options. The permutation, which maps the
+// original IDs to the new ones, is written as well so that the server can
+// translate them. If the graph in src was relabeled already the written
+// permutation maps the IDs it was built with. The whole graph is held in
+// memory.
+func RelabelGraph(src storage.Fetcher, writer storage.Writer, ordering Ordering, opts Options) (
+	*graphaccess.Permutation, []string, error) {
+	start, nodes, err := ReadGraph(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	newIndex := ordering(start, nodes)
+	perm, err := graphaccess.NewPermutation(start, newIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	previous, err := graphaccess.LoadPermutation(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	if previous != nil {
+		if previous.Start != start || previous.NumNodes() != len(nodes) {
+			return nil, nil, fmt.Errorf("Permutation of the nodes from %d does not match the %d nodes from %d",
+				previous.Start, len(nodes), start)
+		}
+		composed := make([]uint32, len(nodes))
+		for i := range composed {
+			composed[i] = newIndex[previous.ToNew(start+uint64(i))-start]
+		}
+		if perm, err = graphaccess.NewPermutation(start, composed); err != nil {
+			return nil, nil, err
+		}
+	}
+	files, err := Split(start, Relabel(start, nodes, newIndex), opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = Write(writer, "", files); err != nil {
+		return nil, nil, err
+	}
+	if err = writer.Put(graphaccess.PermutationObject, perm.Encode()); err != nil {
+		return nil, nil, fmt.Errorf("Unable to write permutation: %w", err)
+	}
+	written := make([]string, len(files))
+	for i, f := range files {
+		written[i] = f.Name
+	}
+	return perm, written, nil
+}
@@ -0,0 +1,75 @@
+package builder
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/graphaccess"
+	"github.com/adityachandla/graph_access_service/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+const chain = "0,1,3\n3,1,1\n1,1,2\n"
+
+func TestOrderings(t *testing.T) {
+	b := New()
+	assert.Nil(t, b.ReadEdgeList(strings.NewReader(chain)))
+	start, nodes, err := b.Nodes()
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{0, 2, 3, 1}, BFSOrder(start, nodes))
+	assert.Equal(t, []uint32{2, 0, 3, 1}, DegreeOrder(start, nodes))
+
+	relabeled := Relabel(start, nodes, BFSOrder(start, nodes))
+	assert.Equal(t, []csrfile.Edge{{Label: 1, Dest: 1}}, relabeled[0].Out)
+	assert.Equal(t, []csrfile.Edge{{Label: 1, Dest: 2}}, relabeled[1].Out)
+	assert.Equal(t, []csrfile.Edge{{Label: 1, Dest: 1}}, relabeled[2].In)
+}
+
+// permutedAccessor reads the graph in the fetcher with the original IDs.
+func permutedAccessor(t *testing.T, fetcher storage.Fetcher) graphaccess.GraphAccess {
+	accessor, err := graphaccess.NewOffsetCsr(fetcher, 0)
+	assert.Nil(t, err)
+	perm, err := graphaccess.LoadPermutation(fetcher)
+	assert.Nil(t, err)
+	assert.NotNil(t, perm)
+	return graphaccess.NewPermutedCsr(accessor, perm)
+}
+
+func TestRelabelGraph(t *testing.T) {
+	b := New()
+	assert.Nil(t, b.ReadEdgeList(strings.NewReader(chain)))
+	opts := Options{NodesPerFile: 2, Encode: csrfile.EncodeOptions{Flags: csrfile.ByteOffsets}}
+	files, err := b.Files(opts)
+	assert.Nil(t, err)
+	src := storage.InitializeFsService(t.TempDir())
+	assert.Nil(t, Write(src.(storage.Writer), "", files))
+
+	bfs := storage.InitializeFsService(t.TempDir())
+	perm, written, err := RelabelGraph(src, bfs.(storage.Writer), BFSOrder, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"nodes-0-1", "nodes-2-3"}, written)
+	assert.Equal(t, uint64(1), perm.ToNew(3))
+
+	//Relabeling a relabeled graph keeps the original IDs.
+	degree := storage.InitializeFsService(t.TempDir())
+	_, _, err = RelabelGraph(bfs, degree.(storage.Writer), DegreeOrder, opts)
+	assert.Nil(t, err)
+	repartitioned := storage.InitializeFsService(t.TempDir())
+	opts.NodesPerFile = 1
+	_, err = Repartition(degree, repartitioned.(storage.Writer), opts)
+	assert.Nil(t, err)
+
+	for _, fetcher := range []storage.Fetcher{bfs, degree, repartitioned} {
+		accessor := permutedAccessor(t, fetcher)
+		res, err := accessor.GetNeighbours(context.Background(),
+			graphaccess.Request{Node: 3, Label: 1, Direction: graphaccess.BOTH})
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []uint32{0, 1}, res)
+		res, err = accessor.GetNeighbours(context.Background(),
+			graphaccess.Request{Node: 2, Label: 1, Direction: graphaccess.INCOMING})
+		assert.Nil(t, err)
+		assert.Equal(t, []uint32{1}, res)
+	}
+}
@@ -0,0 +1,113 @@
+package builder
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/graphaccess"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+// graphEntries returns the files of the graph in src in the order of
+// their nodes, they have to cover a single range of nodes.
+func graphEntries(src storage.Fetcher) ([]graphaccess.ManifestEntry, error) {
+	manifest, err := graphaccess.ProbeManifest(src, false)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to probe files: %w", err)
+	}
+	entries := manifest.Entries
+	if len(entries) == 0 {
+		return nil, errors.New("Graph has no files")
+	}
+	slices.SortFunc(entries, func(a, b graphaccess.ManifestEntry) int {
+		return cmp.Compare(a.Header.Start, b.Header.Start)
+	})
+	next := entries[0].Header.Start
+	for _, e := range entries {
+		if e.Header.Start != next {
+			return nil, fmt.Errorf("%s starts at node %d instead of %d", e.ObjectName, e.Header.Start, next)
+		}
+		next = e.Header.End + 1
+	}
+	return entries, nil
+}
+
+func readNodes(src storage.Fetcher, e graphaccess.ManifestEntry) ([]csrfile.Node, error) {
+	data, err := src.Fetch(e.ObjectName, storage.BRangeStart(0))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch %s: %w", e.ObjectName, err)
+	}
+	_, nodes, err := csrfile.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read %s: %w", e.ObjectName, err)
+	}
+	return nodes, nil
+}
+
+// Repartition rewrites the files of the graph in src into files of the
+// size of the options and returns the names of the written files. The
+// source files are read one at a time in the order of their nodes and
+// have to cover a single range of nodes, only the nodes of a single
+// source file and of the file being written are held in memory. The
+// permutation of a relabeled graph is copied along.
+func Repartition(src storage.Fetcher, writer storage.Writer, opts Options) ([]string, error) {
+	entries, err := graphEntries(src)
+	if err != nil {
+		return nil, err
+	}
+	splitter, err := NewSplitter(entries[0].Header.Start, opts)
+	if err != nil {
+		return nil, err
+	}
+	var written []string
+	write := func(f *File) error {
+		if f == nil {
+			return nil
+		}
+		if err := writer.Put(f.Name, f.Data); err != nil {
+			return fmt.Errorf("Unable to write %s: %w", f.Name, err)
+		}
+		written = append(written, f.Name)
+		return nil
+	}
+	for _, e := range entries {
+		nodes, err := readNodes(src, e)
+		if err != nil {
+			return written, err
+		}
+		for _, n := range nodes {
+			f, err := splitter.Add(n)
+			if err != nil {
+				return written, err
+			}
+			if err = write(f); err != nil {
+				return written, err
+			}
+		}
+	}
+	f, err := splitter.Flush()
+	if err != nil {
+		return written, err
+	}
+	if err = write(f); err != nil {
+		return written, err
+	}
+	return written, copyPermutation(src, writer)
+}
+
+func copyPermutation(src storage.Fetcher, writer storage.Writer) error {
+	data, err := src.Fetch(graphaccess.PermutationObject, storage.BRangeStart(0))
+	if storage.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to fetch permutation: %w", err)
+	}
+	if err = writer.Put(graphaccess.PermutationObject, data); err != nil {
+		return fmt.Errorf("Unable to write permutation: %w", err)
+	}
+	return nil
+}
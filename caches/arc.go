@@ -0,0 +1,196 @@
+package caches
+
+import (
+	"sync"
+
+	"github.com/adityachandla/graph_access_service/lists"
+)
+
+// arcList tells which of the lists of an ARC an entry is in.
+type arcList uint8
+
+const (
+	//Values that were accessed once.
+	arcRecent arcList = iota
+	//Values that were accessed more than once.
+	arcFrequent
+	//Keys that were evicted from arcRecent.
+	arcRecentGhost
+	//Keys that were evicted from arcFrequent.
+	arcFrequentGhost
+)
+
+type arcEntry[V any] struct {
+	value  V
+	weight int64
+	list   arcList
+}
+
+// ARC is an adaptive replacement cache. It splits the cache between the
+// values that were accessed once and the ones that were accessed more
+// often, and moves the split towards the list whose recently evicted keys
+// are requested again.
+type ARC[K comparable, V any] struct {
+	mapping map[K]*lists.ListNode[K, arcEntry[V]]
+	lists   [4]*lists.LinkedList[K, arcEntry[V]]
+	//weights are the total weights of the lists, the ghost lists count
+	//the weights that their keys had when they were evicted.
+	weights [4]int64
+	maxSize int64
+	//target is the weight that the recent list should hold.
+	target int64
+	weight func(V) int64
+	lock   sync.Mutex
+}
+
+func NewARC[K comparable, V any](maxSize int) *ARC[K, V] {
+	if maxSize < 1 {
+		panic("ARC maxSize should be >= 1")
+	}
+	return NewWeightedARC[K, V](int64(maxSize), func(V) int64 { return 1 })
+}
+
+// NewWeightedARC creates an ARC that evicts values once the total weight
+// of the values is more than maxWeight. Values that are heavier than
+// maxWeight are not cached.
+func NewWeightedARC[K comparable, V any](maxWeight int64, weight func(V) int64) *ARC[K, V] {
+	if maxWeight < 1 {
+		panic("ARC maxWeight should be >= 1")
+	}
+	arc := &ARC[K, V]{
+		mapping: make(map[K]*lists.ListNode[K, arcEntry[V]]),
+		maxSize: maxWeight,
+		weight:  weight,
+	}
+	for i := range arc.lists {
+		arc.lists[i] = lists.NewLinkedList[K, arcEntry[V]]()
+	}
+	return arc
+}
+
+func (arc *ARC[K, V]) len(list arcList) int {
+	return arc.lists[list].Len()
+}
+
+func (arc *ARC[K, V]) cachedWeight() int64 {
+	return arc.weights[arcRecent] + arc.weights[arcFrequent]
+}
+
+// add adds the entry to the front of list.
+func (arc *ARC[K, V]) add(key K, entry arcEntry[V]) {
+	arc.weights[entry.list] += entry.weight
+	arc.mapping[key] = arc.lists[entry.list].AddToFront(key, entry)
+}
+
+// move removes the entry from its list and adds it to the front of list.
+func (arc *ARC[K, V]) move(node *lists.ListNode[K, arcEntry[V]], list arcList, value V, weight int64) {
+	arc.lists[node.Value.list].Remove(node)
+	arc.weights[node.Value.list] -= node.Value.weight
+	arc.add(node.Key, arcEntry[V]{value: value, weight: weight, list: list})
+}
+
+func (arc *ARC[K, V]) Get(key K) (val V, ok bool) {
+	arc.lock.Lock()
+	defer arc.lock.Unlock()
+	node, found := arc.mapping[key]
+	if !found || node.Value.list >= arcRecentGhost {
+		return
+	}
+	val = node.Value.value
+	arc.move(node, arcFrequent, val, node.Value.weight)
+	return val, true
+}
+
+func (arc *ARC[K, V]) Present(key K) bool {
+	arc.lock.Lock()
+	defer arc.lock.Unlock()
+	node, found := arc.mapping[key]
+	return found && node.Value.list < arcRecentGhost
+}
+
+func (arc *ARC[K, V]) Len() int {
+	arc.lock.Lock()
+	defer arc.lock.Unlock()
+	return arc.len(arcRecent) + arc.len(arcFrequent)
+}
+
+// Weight is the total weight of the cached values.
+func (arc *ARC[K, V]) Weight() int64 {
+	arc.lock.Lock()
+	defer arc.lock.Unlock()
+	return arc.cachedWeight()
+}
+
+func (arc *ARC[K, V]) Put(key K, value V) {
+	arc.lock.Lock()
+	defer arc.lock.Unlock()
+	weight := arc.weight(value)
+	if weight > arc.maxSize {
+		return
+	}
+	node, found := arc.mapping[key]
+	if found && node.Value.list < arcRecentGhost {
+		arc.move(node, arcFrequent, value, weight)
+		for arc.cachedWeight() > arc.maxSize {
+			arc.replace(false)
+		}
+		return
+	}
+	if found {
+		//A ghost hit grows the list that the key was evicted from.
+		if node.Value.list == arcRecentGhost {
+			delta := max(arc.weights[arcFrequentGhost]/max(arc.weights[arcRecentGhost], 1), 1) * max(weight, 1)
+			arc.target = min(arc.maxSize, arc.target+delta)
+		} else {
+			delta := max(arc.weights[arcRecentGhost]/max(arc.weights[arcFrequentGhost], 1), 1) * max(weight, 1)
+			arc.target = max(0, arc.target-delta)
+		}
+		for arc.cachedWeight()+weight > arc.maxSize {
+			arc.replace(node.Value.list == arcFrequentGhost)
+		}
+		arc.move(node, arcFrequent, value, weight)
+		return
+	}
+	for arc.cachedWeight()+weight > arc.maxSize {
+		arc.replace(false)
+	}
+	//The ghost lists remember as much weight as their lists are short of
+	//their target.
+	for arc.weights[arcRecentGhost] > arc.maxSize-arc.target {
+		arc.dropLast(arcRecentGhost)
+	}
+	for arc.weights[arcFrequentGhost] > arc.target {
+		arc.dropLast(arcFrequentGhost)
+	}
+	arc.add(key, arcEntry[V]{value: value, weight: weight, list: arcRecent})
+}
+
+// replace evicts the value at the back of the recent or the frequent list
+// and keeps its key in the ghost list of that list.
+func (arc *ARC[K, V]) replace(frequentGhostHit bool) {
+	recent := arc.weights[arcRecent]
+	if arc.len(arcRecent) > 0 && (arc.len(arcFrequent) == 0 || recent > arc.target || (frequentGhostHit && recent == arc.target)) {
+		arc.evictToGhost(arcRecent, arcRecentGhost)
+	} else {
+		arc.evictToGhost(arcFrequent, arcFrequentGhost)
+	}
+}
+
+func (arc *ARC[K, V]) evictToGhost(from, ghost arcList) {
+	node, err := arc.lists[from].PopBack()
+	if err != nil {
+		panic(err)
+	}
+	arc.weights[from] -= node.Value.weight
+	var zero V
+	arc.add(node.Key, arcEntry[V]{value: zero, weight: node.Value.weight, list: ghost})
+}
+
+func (arc *ARC[K, V]) dropLast(list arcList) {
+	node, err := arc.lists[list].PopBack()
+	if err != nil {
+		panic(err)
+	}
+	arc.weights[list] -= node.Value.weight
+	delete(arc.mapping, node.Key)
+}
@@ -0,0 +1,62 @@
+package caches
+
+import "fmt"
+
+// Cache holds values up to a total weight and evicts them by its policy.
+// The values of the caches created by New weigh 1 each. All the caches
+// are safe for concurrent use.
+type Cache[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	// Put adds the value, replacing the value of the key if it is cached.
+	// Values that are heavier than the cache are not cached.
+	Put(key K, value V)
+	// Present checks for the key without counting it as an access.
+	Present(key K) bool
+	// Len is the number of cached values.
+	Len() int
+	// Weight is the total weight of the cached values.
+	Weight() int64
+}
+
+// Policy is the eviction policy of a cache created by New.
+type Policy string
+
+const (
+	PolicyLRU  Policy = "lru"
+	PolicyLRFU Policy = "lrfu"
+	PolicyARC  Policy = "arc"
+	// PolicyTinyLFU is W-TinyLFU.
+	PolicyTinyLFU Policy = "tinylfu"
+)
+
+// DefaultLrfuLambda is the lambda of the LRFU caches created by New.
+const DefaultLrfuLambda = 0.2
+
+// New creates a cache of the policy that holds up to size values.
+func New[K comparable, V any](policy Policy, size int) (Cache[K, V], error) {
+	if size < 1 {
+		return nil, fmt.Errorf("Cache size should be >= 1, it is %d", size)
+	}
+	return NewWeighted[K, V](policy, int64(size), func(V) int64 { return 1 })
+}
+
+// NewWeighted creates a cache of the policy that holds values up to a
+// total weight of maxWeight. A cache gets a byte budget with a weight
+// function that returns the bytes held by a value, the function has to
+// return the same weight every time it is called with a value.
+func NewWeighted[K comparable, V any](policy Policy, maxWeight int64, weight func(V) int64) (Cache[K, V], error) {
+	if maxWeight < 1 {
+		return nil, fmt.Errorf("Cache weight should be >= 1, it is %d", maxWeight)
+	}
+	switch policy {
+	case PolicyLRU:
+		return NewWeightedLRU[K, V](maxWeight, weight), nil
+	case PolicyLRFU:
+		return NewWeightedLrfuCache[K, V](maxWeight, DefaultLrfuLambda, weight), nil
+	case PolicyARC:
+		return NewWeightedARC[K, V](maxWeight, weight), nil
+	case PolicyTinyLFU:
+		return NewWeightedWTinyLFU[K, V](maxWeight, weight), nil
+	}
+	return nil, fmt.Errorf("Unknown cache policy %s", policy)
+}
@@ -0,0 +1,160 @@
+package caches_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adityachandla/graph_access_service/caches"
+)
+
+var policies = []caches.Policy{caches.PolicyLRU, caches.PolicyLRFU, caches.PolicyARC, caches.PolicyTinyLFU}
+
+func TestPolicies(t *testing.T) {
+	for _, policy := range policies {
+		cache, err := caches.New[int, int](policy, 10)
+		assert.Nil(t, err)
+		cache.Put(1, 100)
+		cache.Put(1, 101)
+		val, found := cache.Get(1)
+		assert.True(t, found, policy)
+		assert.Equal(t, 101, val, policy)
+		assert.True(t, cache.Present(1), policy)
+		assert.False(t, cache.Present(2), policy)
+		for i := 2; i < 100; i++ {
+			cache.Put(i, i)
+			cache.Get(i)
+			assert.LessOrEqual(t, cache.Len(), 10, policy)
+		}
+		assert.Equal(t, 10, cache.Len(), policy)
+	}
+	_, err := caches.New[int, int]("fifo", 10)
+	assert.NotNil(t, err)
+	_, err = caches.New[int, int](caches.PolicyLRU, 0)
+	assert.NotNil(t, err)
+}
+
+// scan reads the hot keys a few times and then every key of a scan once,
+// the hot keys should still be cached after it.
+func scan(cache caches.Cache[int, int], hot []int) {
+	for round := 0; round < 3; round++ {
+		for _, k := range hot {
+			if _, found := cache.Get(k); !found {
+				cache.Put(k, k)
+			}
+		}
+	}
+	for k := 1000; k < 1100; k++ {
+		if _, found := cache.Get(k); !found {
+			cache.Put(k, k)
+		}
+	}
+}
+
+func TestScanResistance(t *testing.T) {
+	hot := []int{1, 2, 3, 4, 5}
+	for _, policy := range []caches.Policy{caches.PolicyARC, caches.PolicyTinyLFU} {
+		cache, err := caches.New[int, int](policy, 10)
+		assert.Nil(t, err)
+		scan(cache, hot)
+		for _, k := range hot {
+			assert.True(t, cache.Present(k), policy)
+		}
+	}
+	//An LRU of the same size keeps none of them.
+	lru := caches.NewLRU[int, int](10)
+	scan(lru, hot)
+	for _, k := range hot {
+		assert.False(t, lru.Present(k))
+	}
+}
+
+func TestARCAdapts(t *testing.T) {
+	arc := caches.NewARC[int, int](4)
+	for k := 0; k < 4; k++ {
+		arc.Put(k, k)
+	}
+	//0 and 1 are evicted to the ghost list of the recent values, putting
+	//them again admits them as frequent values.
+	arc.Put(4, 4)
+	arc.Put(5, 5)
+	assert.False(t, arc.Present(0))
+	arc.Put(0, 0)
+	assert.True(t, arc.Present(0))
+	assert.Equal(t, 4, arc.Len())
+	arc.Put(6, 6)
+	arc.Put(7, 7)
+	assert.True(t, arc.Present(0))
+}
+
+func TestTinyLFUAdmission(t *testing.T) {
+	cache := caches.NewWTinyLFU[int, int](10)
+	for k := 0; k < 10; k++ {
+		cache.Get(k)
+		cache.Put(k, k)
+	}
+	//A key that was only accessed once does not replace one that was
+	//accessed as often.
+	cache.Get(100)
+	cache.Put(100, 100)
+	cache.Get(101)
+	cache.Put(101, 101)
+	assert.False(t, cache.Present(100))
+	assert.True(t, cache.Present(101))
+	//A key that was accessed more often does.
+	for i := 0; i < 3; i++ {
+		cache.Get(102)
+	}
+	cache.Put(102, 102)
+	cache.Put(103, 103)
+	assert.True(t, cache.Present(102))
+	assert.Equal(t, 10, cache.Len())
+}
+
+func TestWeightedPolicies(t *testing.T) {
+	bytes := func(v []uint32) int64 { return 4 * int64(len(v)) }
+	for _, policy := range policies {
+		cache, err := caches.NewWeighted[int, []uint32](policy, 400, bytes)
+		assert.Nil(t, err)
+		//Heavier than the whole cache.
+		cache.Put(0, make([]uint32, 101))
+		assert.False(t, cache.Present(0), policy)
+		for i := 1; i < 100; i++ {
+			cache.Put(i, make([]uint32, i%20))
+			cache.Get(i)
+			assert.LessOrEqual(t, cache.Weight(), int64(400), policy)
+		}
+		assert.Greater(t, cache.Weight(), int64(0), policy)
+		//Replacing a value updates the weight.
+		cache.Put(1000, make([]uint32, 10))
+		cache.Get(1000)
+		cache.Put(1000, make([]uint32, 10))
+		if cache.Present(1000) {
+			before := cache.Weight()
+			cache.Put(1000, make([]uint32, 5))
+			assert.Equal(t, before-20, cache.Weight(), policy)
+		}
+	}
+	_, err := caches.NewWeighted[int, []uint32](caches.PolicyARC, 0, bytes)
+	assert.NotNil(t, err)
+}
+
+func TestWeightedHub(t *testing.T) {
+	bytes := func(v []uint32) int64 { return 4 * int64(len(v)) }
+	for _, policy := range policies {
+		cache, err := caches.NewWeighted[int, []uint32](policy, 400, bytes)
+		assert.Nil(t, err)
+		for i := 0; i < 10; i++ {
+			cache.Put(i, make([]uint32, 1))
+		}
+		//A hub evicts values by weight, not by number.
+		for i := 0; i < 3; i++ {
+			cache.Get(100)
+		}
+		cache.Put(100, make([]uint32, 99))
+		cache.Put(101, make([]uint32, 1))
+		assert.True(t, cache.Present(100), policy)
+		assert.LessOrEqual(t, cache.Weight(), int64(400), policy)
+		assert.Less(t, cache.Len(), 11, policy)
+	}
+}
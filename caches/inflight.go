@@ -0,0 +1,140 @@
+package caches
+
+import (
+	"context"
+	"sync"
+)
+
+// InFlight lets concurrent callers that ask for the same key share a
+// single call. The call is not tied to the context of any one caller,
+// it is cancelled only once every caller waiting on it has given up.
+type InFlight[K comparable, V any] struct {
+	calls map[K]*call[V]
+	lock  sync.Mutex
+}
+
+type call[V any] struct {
+	val     V
+	err     error
+	done    chan struct{}
+	group   *callGroup
+	waiters int
+}
+
+// callGroup holds the context of the calls that were started together, it
+// is cancelled once no caller waits for any of them.
+type callGroup struct {
+	cancel context.CancelFunc
+	live   int
+}
+
+func NewInFlight[K comparable, V any]() *InFlight[K, V] {
+	return &InFlight[K, V]{calls: make(map[K]*call[V])}
+}
+
+// Do returns the result of fn for the key. If a call for the key is
+// already running, Do waits for it instead of calling fn again and
+// shared is set.
+func (f *InFlight[K, V]) Do(ctx context.Context, key K,
+	fn func(context.Context) (V, error)) (val V, shared bool, err error) {
+	vals, sharedCalls, errs := f.DoBatch(ctx, []K{key}, func(ctx context.Context, _ []K) ([]V, []error) {
+		val, err := fn(ctx)
+		return []V{val}, []error{err}
+	})
+	return vals[0], sharedCalls[0], errs[0]
+}
+
+// DoBatch is Do for many keys at once. fn is called a single time with
+// the keys that are not in flight yet, it returns a value and an error for
+// every key in the order of the keys. The keys that are already in flight
+// wait for their calls and are marked as shared.
+func (f *InFlight[K, V]) DoBatch(ctx context.Context, keys []K,
+	fn func(context.Context, []K) ([]V, []error)) (vals []V, shared []bool, errs []error) {
+	vals = make([]V, len(keys))
+	shared = make([]bool, len(keys))
+	errs = make([]error, len(keys))
+	calls := make([]*call[V], len(keys))
+	callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	group := &callGroup{cancel: cancel}
+	var startedKeys []K
+	var started []*call[V]
+	f.lock.Lock()
+	for i, key := range keys {
+		c, found := f.calls[key]
+		if found {
+			c.waiters++
+		} else {
+			c = &call[V]{done: make(chan struct{}), group: group, waiters: 1}
+			f.calls[key] = c
+			group.live++
+			startedKeys = append(startedKeys, key)
+			started = append(started, c)
+		}
+		calls[i], shared[i] = c, found
+	}
+	f.lock.Unlock()
+	if len(started) > 0 {
+		go f.run(callCtx, startedKeys, started, fn)
+	} else {
+		cancel()
+	}
+
+	for i, c := range calls {
+		select {
+		case <-c.done:
+			vals[i], errs[i] = c.val, c.err
+		case <-ctx.Done():
+			f.lock.Lock()
+			for j := i; j < len(calls); j++ {
+				f.leave(keys[j], calls[j])
+				select {
+				case <-calls[j].done:
+					vals[j], errs[j] = calls[j].val, calls[j].err
+				default:
+					errs[j] = ctx.Err()
+				}
+			}
+			f.lock.Unlock()
+			return vals, shared, errs
+		}
+	}
+	return vals, shared, errs
+}
+
+func (f *InFlight[K, V]) run(ctx context.Context, keys []K, calls []*call[V],
+	fn func(context.Context, []K) ([]V, []error)) {
+	vals, errs := fn(ctx, keys)
+	f.lock.Lock()
+	for i, c := range calls {
+		c.val, c.err = vals[i], errs[i]
+		f.forget(keys[i], c)
+	}
+	f.lock.Unlock()
+	calls[0].group.cancel()
+	for _, c := range calls {
+		close(c.done)
+	}
+}
+
+// leave removes a waiter of the call. A call without waiters is forgotten
+// and its group is cancelled once none of the calls in it has waiters.
+// Should be called with the lock held.
+func (f *InFlight[K, V]) leave(key K, c *call[V]) {
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	c.group.live--
+	if c.group.live == 0 {
+		c.group.cancel()
+	}
+	f.forget(key, c)
+}
+
+// forget removes the call so that later callers start a new one.
+// Should be called with the lock held.
+func (f *InFlight[K, V]) forget(key K, c *call[V]) {
+	if f.calls[key] == c {
+		delete(f.calls, key)
+	}
+}
@@ -0,0 +1,100 @@
+package caches_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adityachandla/graph_access_service/caches"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedCall(t *testing.T) {
+	inFlight := caches.NewInFlight[int, int]()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func(context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+	var wg sync.WaitGroup
+	var shared atomic.Int32
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, wasShared, err := inFlight.Do(context.Background(), 1, fn)
+			assert.Nil(t, err)
+			assert.Equal(t, 42, val)
+			if wasShared {
+				shared.Add(1)
+			}
+		}()
+	}
+	//Wait for all callers to join before letting the call finish.
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, int32(4), shared.Load())
+}
+
+func TestCallCancelledWithLastWaiter(t *testing.T) {
+	inFlight := caches.NewInFlight[int, int]()
+	cancelled := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		cancel()
+	}()
+	_, _, err := inFlight.Do(ctx, 1, func(callCtx context.Context) (int, error) {
+		<-callCtx.Done()
+		close(cancelled)
+		return 0, callCtx.Err()
+	})
+	assert.Equal(t, context.Canceled, err)
+	<-cancelled
+}
+
+func TestBatchJoinsCalls(t *testing.T) {
+	inFlight := caches.NewInFlight[int, int]()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		val, _, err := inFlight.Do(context.Background(), 1, func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 10, nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 10, val)
+	}()
+	<-started
+	var batchKeys []int
+	fn := func(_ context.Context, keys []int) ([]int, []error) {
+		batchKeys = keys
+		vals := make([]int, len(keys))
+		for i, k := range keys {
+			vals[i] = k * 10
+		}
+		return vals, make([]error, len(keys))
+	}
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		close(release)
+	}()
+	vals, shared, errs := inFlight.DoBatch(context.Background(), []int{2, 1, 3, 2}, fn)
+	<-done
+	assert.Equal(t, []int{2, 3}, batchKeys)
+	assert.Equal(t, []int{20, 10, 30, 20}, vals)
+	assert.Equal(t, []bool{false, true, false, true}, shared)
+	assert.Equal(t, []error{nil, nil, nil, nil}, errs)
+}
@@ -10,19 +10,33 @@ const BASE = 0.5
 type Lrfu[K comparable, V any] struct {
 	mapping map[K]*heapNode[K, V]
 	// This heap contains the min value at the top.
-	heap         []*heapNode[K, V]
-	lock         sync.Mutex
-	maxSize      int
+	heap    []*heapNode[K, V]
+	lock    sync.Mutex
+	maxSize int64
+	//size is the total weight of the cached values.
+	size         int64
+	weight       func(V) int64
 	combinedBase float64
 	time         uint32
 }
 
 func NewLrfuCache[K comparable, V any](size int, lambda float64) *Lrfu[K, V] {
+	return NewWeightedLrfuCache[K, V](int64(size), lambda, func(V) int64 { return 1 })
+}
+
+// NewWeightedLrfuCache creates an Lrfu that evicts the values with the
+// lowest scores once the total weight of the values is more than
+// maxWeight. Values that are heavier than maxWeight are not cached.
+func NewWeightedLrfuCache[K comparable, V any](maxWeight int64, lambda float64, weight func(V) int64) *Lrfu[K, V] {
+	if maxWeight < 1 {
+		panic("Lrfu maxWeight should be >= 1")
+	}
 	return &Lrfu[K, V]{
 		mapping:      make(map[K]*heapNode[K, V]),
 		heap:         make([]*heapNode[K, V], 0),
 		lock:         sync.Mutex{},
-		maxSize:      size,
+		maxSize:      maxWeight,
+		weight:       weight,
 		combinedBase: math.Pow(BASE, lambda),
 		time:         0,
 	}
@@ -31,26 +45,47 @@ func NewLrfuCache[K comparable, V any](size int, lambda float64) *Lrfu[K, V] {
 func (lrfu *Lrfu[K, V]) Put(key K, value V) {
 	lrfu.lock.Lock()
 	defer lrfu.lock.Unlock()
+	weight := lrfu.weight(value)
+	if weight > lrfu.maxSize {
+		return
+	}
 	lrfu.time++
 
+	//Putting a cached key replaces its value and counts as an access.
+	if nodePtr, ok := lrfu.mapping[key]; ok {
+		lrfu.size += weight - lrfu.weight(nodePtr.value)
+		nodePtr.value = value
+		lrfu.access(nodePtr)
+		for lrfu.size > lrfu.maxSize {
+			lrfu.evictMin()
+		}
+		return
+	}
+	for lrfu.size+weight > lrfu.maxSize {
+		lrfu.evictMin()
+	}
 	newNode := heapNode[K, V]{
 		key:           key,
 		value:         value,
+		nodeIdx:       len(lrfu.heap),
 		timeAccessed:  lrfu.time,
 		combinedScore: lrfu.combinedScore(0),
 	}
 	lrfu.mapping[key] = &newNode
-	if lrfu.maxSize == len(lrfu.heap) {
-		keyToEvict := lrfu.heap[0].key
-		delete(lrfu.mapping, keyToEvict)
-		newNode.nodeIdx = 0
-		lrfu.heap[0] = &newNode
-		lrfu.floatDown(0)
-	} else {
-		lrfu.heap = append(lrfu.heap, &newNode)
-		newNode.nodeIdx = len(lrfu.heap) - 1
-		lrfu.floatUp(len(lrfu.heap) - 1)
-	}
+	lrfu.heap = append(lrfu.heap, &newNode)
+	lrfu.floatUp(newNode.nodeIdx)
+	lrfu.size += weight
+}
+
+// evictMin removes the value with the lowest score.
+func (lrfu *Lrfu[K, V]) evictMin() {
+	toEvict := lrfu.heap[0]
+	delete(lrfu.mapping, toEvict.key)
+	lrfu.size -= lrfu.weight(toEvict.value)
+	last := len(lrfu.heap) - 1
+	lrfu.swapInHeap(0, last)
+	lrfu.heap = lrfu.heap[:last]
+	lrfu.floatDown(0)
 }
 
 func (lrfu *Lrfu[K, V]) Present(key K) bool {
@@ -68,16 +103,33 @@ func (lrfu *Lrfu[K, V]) Get(key K) (V, bool) {
 	//After access, the value may have increased, so we need to
 	//float it down.
 	if nodePtr, ok := lrfu.mapping[key]; ok {
-		nodePtr.combinedScore = lrfu.combinedScore(0) +
-			(lrfu.combinedScore(lrfu.time-nodePtr.timeAccessed) * nodePtr.combinedScore)
-		nodePtr.timeAccessed = lrfu.time
-		lrfu.floatDown(nodePtr.nodeIdx)
+		lrfu.access(nodePtr)
 		return nodePtr.value, true
 	}
 	var v V
 	return v, false
 }
 
+func (lrfu *Lrfu[K, V]) Len() int {
+	lrfu.lock.Lock()
+	defer lrfu.lock.Unlock()
+	return len(lrfu.heap)
+}
+
+// Weight is the total weight of the cached values.
+func (lrfu *Lrfu[K, V]) Weight() int64 {
+	lrfu.lock.Lock()
+	defer lrfu.lock.Unlock()
+	return lrfu.size
+}
+
+func (lrfu *Lrfu[K, V]) access(nodePtr *heapNode[K, V]) {
+	nodePtr.combinedScore = lrfu.combinedScore(0) +
+		(lrfu.combinedScore(lrfu.time-nodePtr.timeAccessed) * nodePtr.combinedScore)
+	nodePtr.timeAccessed = lrfu.time
+	lrfu.floatDown(nodePtr.nodeIdx)
+}
+
 func (lrfu *Lrfu[K, V]) floatUp(index int) {
 	for index > 0 {
 		parent := (index - 1) / 2
@@ -8,22 +8,43 @@ import (
 type LRU[K comparable, V any] struct {
 	mapping      map[K]*lists.ListNode[K, V]
 	recencyQueue *lists.LinkedList[K, V]
-	maxSize      int
-	lock         *sync.Mutex
+	maxSize      int64
+	//size is the total weight of the cached values.
+	size   int64
+	weight func(V) int64
+	lock   *sync.Mutex
 }
 
 func NewLRU[K comparable, V any](maxSize int) *LRU[K, V] {
 	if maxSize < 1 {
 		panic("LRU maxSize should be >= 1")
 	}
+	return NewWeightedLRU[K, V](int64(maxSize), func(V) int64 { return 1 })
+}
+
+// NewWeightedLRU creates an LRU that evicts the least recently used
+// values once the total weight of the values is more than maxWeight.
+// Values that are heavier than maxWeight are not cached.
+func NewWeightedLRU[K comparable, V any](maxWeight int64, weight func(V) int64) *LRU[K, V] {
+	if maxWeight < 1 {
+		panic("LRU maxWeight should be >= 1")
+	}
 	return &LRU[K, V]{
 		mapping:      make(map[K]*lists.ListNode[K, V]),
 		recencyQueue: lists.NewLinkedList[K, V](),
-		maxSize:      maxSize,
+		maxSize:      maxWeight,
+		weight:       weight,
 		lock:         &sync.Mutex{},
 	}
 }
 
+// Weight is the total weight of the cached values.
+func (lru *LRU[K, V]) Weight() int64 {
+	lru.lock.Lock()
+	defer lru.lock.Unlock()
+	return lru.size
+}
+
 func (lru *LRU[K, V]) Get(key K) (val V, ok bool) {
 	lru.lock.Lock()
 	defer lru.lock.Unlock()
@@ -33,14 +54,40 @@ func (lru *LRU[K, V]) Get(key K) (val V, ok bool) {
 	return
 }
 
+func (lru *LRU[K, V]) Present(key K) bool {
+	lru.lock.Lock()
+	defer lru.lock.Unlock()
+	_, ok := lru.mapping[key]
+	return ok
+}
+
+func (lru *LRU[K, V]) Len() int {
+	lru.lock.Lock()
+	defer lru.lock.Unlock()
+	return len(lru.mapping)
+}
+
 func (lru *LRU[K, V]) Put(key K, value V) {
+	lru.lock.Lock()
+	defer lru.lock.Unlock()
+	if lru.weight(value) > lru.maxSize {
+		return
+	}
 	lru.addToCache(key, value)
-	if lru.recencyQueue.Len() > lru.maxSize {
+	for lru.size > lru.maxSize {
 		lru.evictLast()
 	}
 }
 
 func (lru *LRU[K, V]) addToCache(key K, val V) {
+	lru.size += lru.weight(val)
+	//Concurrent misses on the same key can both put it.
+	if ref, ok := lru.mapping[key]; ok {
+		lru.size -= lru.weight(ref.Value)
+		ref.Value = val
+		lru.recencyQueue.MoveToFront(ref)
+		return
+	}
 	valRef := lru.recencyQueue.AddToFront(key, val)
 	lru.mapping[key] = valRef
 }
@@ -59,4 +106,5 @@ func (lru *LRU[K, V]) evictLast() {
 		panic(err)
 	}
 	delete(lru.mapping, toDeleteRef.Key)
+	lru.size -= lru.weight(toDeleteRef.Value)
 }
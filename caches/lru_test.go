@@ -53,3 +53,21 @@ func TestReuseMiddle(t *testing.T) {
 	_, found = lru.Get(23) //Should be fetched from cache
 	assert.True(t, found)
 }
+
+func TestWeightedEviction(t *testing.T) {
+	lru := caches.NewWeightedLRU[int, []byte](10, func(v []byte) int64 { return int64(len(v)) })
+	lru.Put(1, make([]byte, 4))
+	lru.Put(2, make([]byte, 4))
+	lru.Put(3, make([]byte, 4))
+	assert.Equal(t, int64(8), lru.Weight())
+	_, found := lru.Get(1)
+	assert.False(t, found)
+	//Values heavier than the budget are not cached.
+	lru.Put(4, make([]byte, 11))
+	_, found = lru.Get(4)
+	assert.False(t, found)
+	lru.Put(2, make([]byte, 6))
+	assert.Equal(t, int64(10), lru.Weight())
+	_, found = lru.Get(3)
+	assert.True(t, found)
+}
@@ -10,7 +10,9 @@ type PrefetchCache[K comparable, V any] struct {
 	//TODO replace the linked list with circular list.
 	list        *lists.LinkedList[K, V]
 	numElements int
-	maxSize     int
+	maxWeight   int64
+	totalWeight int64
+	weight      func(V) int64
 	lock        sync.Mutex
 }
 
@@ -18,11 +20,22 @@ func NewPrefetchCache[K comparable, V any](size int) *PrefetchCache[K, V] {
 	if size <= 0 {
 		panic("Size of cache needs to be greater than 0.")
 	}
+	return NewWeightedPrefetchCache[K, V](int64(size), func(V) int64 { return 1 })
+}
+
+// NewWeightedPrefetchCache creates a prefetch cache that holds values up
+// to a total weight of maxWeight, the oldest values are evicted first.
+// Values that are heavier than maxWeight are not cached.
+func NewWeightedPrefetchCache[K comparable, V any](maxWeight int64, weight func(V) int64) *PrefetchCache[K, V] {
+	if maxWeight <= 0 {
+		panic("Weight of cache needs to be greater than 0.")
+	}
 	return &PrefetchCache[K, V]{
 		elementMap:  make(map[K]*lists.ListNode[K, V]),
 		list:        lists.NewLinkedList[K, V](),
 		numElements: 0,
-		maxSize:     size,
+		maxWeight:   maxWeight,
+		weight:      weight,
 	}
 }
 
@@ -30,28 +43,57 @@ func (pc *PrefetchCache[K, V]) Get(key K) (val V, found bool) {
 	pc.lock.Lock()
 	defer pc.lock.Unlock()
 	if node, ok := pc.elementMap[key]; ok {
-		val = node.Value
-		delete(pc.elementMap, key)
-		pc.list.Remove(node)
-		pc.numElements--
-		return val, true
+		pc.remove(node)
+		return node.Value, true
 	}
 	return val, false
 }
 
+func (pc *PrefetchCache[K, V]) remove(node *lists.ListNode[K, V]) {
+	delete(pc.elementMap, node.Key)
+	pc.list.Remove(node)
+	pc.numElements--
+	pc.totalWeight -= pc.weight(node.Value)
+}
+
+// Present checks for the key without removing it from the cache.
+func (pc *PrefetchCache[K, V]) Present(key K) bool {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+	_, ok := pc.elementMap[key]
+	return ok
+}
+
 func (pc *PrefetchCache[K, V]) Put(key K, value V) {
 	pc.lock.Lock()
 	defer pc.lock.Unlock()
-	node := pc.list.AddToBack(key, value)
-	pc.elementMap[key] = node
-	if pc.numElements == pc.maxSize {
-		toRemove, _ := pc.list.PopFront()
-		delete(pc.elementMap, toRemove.Key)
-	} else {
-		pc.numElements++
+	if node, ok := pc.elementMap[key]; ok {
+		pc.remove(node)
+	}
+	weight := pc.weight(value)
+	if weight > pc.maxWeight {
+		return
 	}
+	for pc.totalWeight+weight > pc.maxWeight {
+		oldest, _ := pc.list.PopFront()
+		delete(pc.elementMap, oldest.Key)
+		pc.numElements--
+		pc.totalWeight -= pc.weight(oldest.Value)
+	}
+	pc.elementMap[key] = pc.list.AddToBack(key, value)
+	pc.numElements++
+	pc.totalWeight += weight
 }
 
 func (pc *PrefetchCache[K, V]) Len() int {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
 	return pc.numElements
 }
+
+// Weight is the total weight of the cached values.
+func (pc *PrefetchCache[K, V]) Weight() int64 {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+	return pc.totalWeight
+}
@@ -23,3 +23,28 @@ func TestEviction(t *testing.T) {
 	assert.False(t, found)
 	assert.Equal(t, 2, pc.Len())
 }
+
+func TestPresentDoesNotRemove(t *testing.T) {
+	pc := NewPrefetchCache[int, int](2)
+	pc.Put(1, 101)
+	assert.True(t, pc.Present(1))
+	assert.False(t, pc.Present(2))
+	assert.Equal(t, 1, pc.Len())
+}
+
+func TestWeightedEviction(t *testing.T) {
+	pc := NewWeightedPrefetchCache[int, []int](5, func(v []int) int64 { return int64(len(v)) })
+	pc.Put(1, []int{1, 2})
+	pc.Put(2, []int{1, 2})
+	pc.Put(3, []int{1, 2})
+	assert.False(t, pc.Present(1))
+	assert.Equal(t, int64(4), pc.Weight())
+	pc.Put(2, []int{1})
+	assert.Equal(t, int64(3), pc.Weight())
+	pc.Put(4, []int{1, 2, 3, 4, 5, 6})
+	assert.False(t, pc.Present(4))
+	_, found := pc.Get(3)
+	assert.True(t, found)
+	assert.Equal(t, int64(1), pc.Weight())
+	assert.Equal(t, 1, pc.Len())
+}
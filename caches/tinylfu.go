@@ -0,0 +1,254 @@
+package caches
+
+import (
+	"sync"
+
+	"github.com/adityachandla/graph_access_service/lists"
+)
+
+const (
+	//Counters stop at the largest value of the 4 bit counters of TinyLFU.
+	maxFrequency = 15
+	//Frequencies are halved after this many accesses per cached value.
+	//Every access adds at most one key to the exact frequency table, so
+	//it holds up to frequencyResetFactor keys per cached value, each with
+	//a byte of count. A Go map does not shrink when keys are deleted, so
+	//the table keeps the memory of its largest size.
+	frequencyResetFactor = 10
+)
+
+// frequencies counts how often keys were accessed. Every count is halved
+// once there were frequencyResetFactor times as many accesses as the cache
+// holds values, so that the counts follow changes in the workload and
+// only the keys of recent accesses are kept. The counts are kept exactly
+// instead of in a count-min sketch because keys can not be hashed
+// generically.
+type frequencies[K comparable] struct {
+	counts   map[K]uint8
+	accesses int
+}
+
+// increment counts an access of the key to a cache that holds cached
+// values.
+func (f *frequencies[K]) increment(key K, cached int) {
+	if f.counts[key] < maxFrequency {
+		f.counts[key]++
+	}
+	f.accesses++
+	if f.accesses < frequencyResetFactor*max(cached, 1) {
+		return
+	}
+	for k, count := range f.counts {
+		if count <= 1 {
+			delete(f.counts, k)
+		} else {
+			f.counts[k] = count / 2
+		}
+	}
+	f.accesses /= 2
+}
+
+// tinyLFUSegment tells which of the lists of a W-TinyLFU an entry is in.
+type tinyLFUSegment uint8
+
+const (
+	//New values, evicted by recency.
+	segmentWindow tinyLFUSegment = iota
+	//Values admitted from the window.
+	segmentProbation
+	//Values that were accessed while on probation.
+	segmentProtected
+)
+
+type tinyLFUEntry[V any] struct {
+	value   V
+	weight  int64
+	segment tinyLFUSegment
+}
+
+// WTinyLFU is a W-TinyLFU cache. New values enter a small LRU window, the
+// values evicted from the window are only admitted to the main cache if
+// they were accessed more often than the values that the main cache would
+// evict for them. The main cache is a segmented LRU in which values that
+// are accessed again move from the probation to the protected segment.
+// Accesses are counted on Get.
+type WTinyLFU[K comparable, V any] struct {
+	mapping  map[K]*lists.ListNode[K, tinyLFUEntry[V]]
+	segments [3]*lists.LinkedList[K, tinyLFUEntry[V]]
+	//weights are the total weights of the segments.
+	weights      [3]int64
+	windowSize   int64
+	mainSize     int64
+	protectedMax int64
+	weight       func(V) int64
+	freq         frequencies[K]
+	lock         sync.Mutex
+}
+
+// NewWTinyLFU creates a cache with a window of 1% and a protected segment
+// of 80% of the main cache.
+func NewWTinyLFU[K comparable, V any](maxSize int) *WTinyLFU[K, V] {
+	if maxSize < 1 {
+		panic("WTinyLFU maxSize should be >= 1")
+	}
+	return NewWeightedWTinyLFU[K, V](int64(maxSize), func(V) int64 { return 1 })
+}
+
+// NewWeightedWTinyLFU creates a W-TinyLFU whose segments are sized by the
+// total weight of their values instead of their number. Values that are
+// heavier than maxWeight are not cached.
+func NewWeightedWTinyLFU[K comparable, V any](maxWeight int64, weight func(V) int64) *WTinyLFU[K, V] {
+	if maxWeight < 1 {
+		panic("WTinyLFU maxWeight should be >= 1")
+	}
+	windowSize := max(1, maxWeight/100)
+	mainSize := maxWeight - windowSize
+	c := &WTinyLFU[K, V]{
+		mapping:      make(map[K]*lists.ListNode[K, tinyLFUEntry[V]]),
+		windowSize:   windowSize,
+		mainSize:     mainSize,
+		protectedMax: mainSize * 8 / 10,
+		weight:       weight,
+		freq:         frequencies[K]{counts: make(map[K]uint8)},
+	}
+	for i := range c.segments {
+		c.segments[i] = lists.NewLinkedList[K, tinyLFUEntry[V]]()
+	}
+	return c
+}
+
+func (c *WTinyLFU[K, V]) Get(key K) (val V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.freq.increment(key, len(c.mapping))
+	node, found := c.mapping[key]
+	if !found {
+		return
+	}
+	val = node.Value.value
+	c.access(node, val, node.Value.weight)
+	return val, true
+}
+
+func (c *WTinyLFU[K, V]) Present(key K) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, found := c.mapping[key]
+	return found
+}
+
+func (c *WTinyLFU[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.mapping)
+}
+
+// Weight is the total weight of the cached values.
+func (c *WTinyLFU[K, V]) Weight() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.weights[segmentWindow] + c.weights[segmentProbation] + c.weights[segmentProtected]
+}
+
+func (c *WTinyLFU[K, V]) Put(key K, value V) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	weight := c.weight(value)
+	if weight > c.windowSize+c.mainSize {
+		return
+	}
+	if node, found := c.mapping[key]; found {
+		c.access(node, value, weight)
+	} else {
+		c.add(key, tinyLFUEntry[V]{value: value, weight: weight, segment: segmentWindow})
+	}
+	c.shrink()
+}
+
+// shrink evicts values until the window and the main cache are within
+// their weights. Values evicted from the window are considered for the
+// main cache.
+func (c *WTinyLFU[K, V]) shrink() {
+	for c.weights[segmentWindow] > c.windowSize {
+		c.admit(c.popBack(segmentWindow))
+	}
+	for c.weights[segmentProbation]+c.weights[segmentProtected] > c.mainSize {
+		c.popBack(c.victimSegment())
+	}
+}
+
+// admit adds a value evicted from the window to the main cache if there
+// is room for it, or if it was accessed more often than every value that
+// the main cache would evict for it.
+func (c *WTinyLFU[K, V]) admit(candidate *lists.ListNode[K, tinyLFUEntry[V]]) {
+	if candidate.Value.weight > c.mainSize {
+		return
+	}
+	room := c.mainSize - c.weights[segmentProbation] - c.weights[segmentProtected]
+	var victims []*lists.ListNode[K, tinyLFUEntry[V]]
+	for room < candidate.Value.weight {
+		victim := c.popBack(c.victimSegment())
+		victims = append(victims, victim)
+		if c.freq.counts[candidate.Key] <= c.freq.counts[victim.Key] {
+			//The victims are put back in the order they were in.
+			for i := len(victims) - 1; i >= 0; i-- {
+				c.addToBack(victims[i].Key, victims[i].Value)
+			}
+			return
+		}
+		room += victim.Value.weight
+	}
+	entry := candidate.Value
+	entry.segment = segmentProbation
+	c.add(candidate.Key, entry)
+}
+
+// victimSegment is the segment that the main cache evicts from.
+func (c *WTinyLFU[K, V]) victimSegment() tinyLFUSegment {
+	if c.segments[segmentProbation].Len() == 0 {
+		return segmentProtected
+	}
+	return segmentProbation
+}
+
+func (c *WTinyLFU[K, V]) add(key K, entry tinyLFUEntry[V]) {
+	c.weights[entry.segment] += entry.weight
+	c.mapping[key] = c.segments[entry.segment].AddToFront(key, entry)
+}
+
+func (c *WTinyLFU[K, V]) addToBack(key K, entry tinyLFUEntry[V]) {
+	c.weights[entry.segment] += entry.weight
+	c.mapping[key] = c.segments[entry.segment].AddToBack(key, entry)
+}
+
+func (c *WTinyLFU[K, V]) popBack(segment tinyLFUSegment) *lists.ListNode[K, tinyLFUEntry[V]] {
+	node, err := c.segments[segment].PopBack()
+	if err != nil {
+		panic(err)
+	}
+	c.weights[segment] -= node.Value.weight
+	delete(c.mapping, node.Key)
+	return node
+}
+
+// access moves the value to the front of its segment, values on probation
+// are promoted to the protected segment, which demotes its least recently
+// used values if it gets too heavy.
+func (c *WTinyLFU[K, V]) access(node *lists.ListNode[K, tinyLFUEntry[V]], value V, weight int64) {
+	segment := node.Value.segment
+	c.weights[segment] += weight - node.Value.weight
+	node.Value.value = value
+	node.Value.weight = weight
+	if segment != segmentProbation {
+		c.segments[segment].MoveToFront(node)
+	} else {
+		c.segments[segmentProbation].Remove(node)
+		c.weights[segmentProbation] -= weight
+		c.add(node.Key, tinyLFUEntry[V]{value: value, weight: weight, segment: segmentProtected})
+	}
+	for c.weights[segmentProtected] > c.protectedMax {
+		demoted := c.popBack(segmentProtected)
+		demoted.Value.segment = segmentProbation
+		c.add(demoted.Key, demoted.Value)
+	}
+}
@@ -0,0 +1,60 @@
+// Command build writes the files of a graph from a CSV or TSV edge list,
+// see builder.ReadEdgeList, for example to build test fixtures and small
+// graphs without the LDBC converter.
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/adityachandla/graph_access_service/builder"
+	"github.com/adityachandla/graph_access_service/cmd/internal/graphflags"
+)
+
+var (
+	input  = flag.String("input", "-", "Path to the edge list, - reads it from stdin")
+	output = graphflags.RegisterOutput(flag.CommandLine, 1<<16)
+)
+
+func main() {
+	flag.Parse()
+	opts, err := output.Options()
+	if err != nil {
+		log.Fatal(err)
+	}
+	dest, writer, err := output.Destination(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			log.Fatalf("Unable to open the edge list: %s", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	b := builder.New()
+	if err = b.ReadEdgeList(r); err != nil {
+		log.Fatalf("Unable to read the edge list: %s", err)
+	}
+	files, err := b.Files(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = builder.Write(writer, "", files); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote %d edges in %d files\n", b.NumEdges(), len(files))
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.Name
+	}
+	if err = output.WriteManifest(dest, writer, names); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,101 @@
+// Command convert rewrites every file of a graph in another layout, for
+// example to compare the bytes fetched by the varint or zstd accessor
+// with the decode time it adds.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/graphaccess"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+var (
+	fsType       = flag.String("fstype", "s3", "Filesystem type of the source s3/local")
+	bucket       = flag.String("bucket", "s3graphtest1", "Path to the source s3 bucket")
+	region       = flag.String("region", "eu-west-1", "AWS Region of the source")
+	destFsType   = flag.String("destfstype", "local", "Filesystem type of the destination s3/local")
+	destBucket   = flag.String("destbucket", "", "Path to the destination s3 bucket")
+	destRegion   = flag.String("destregion", "eu-west-1", "AWS Region of the destination")
+	format       = flag.String("format", "varint", "Layout of the converted files indices/offsets/varint/zstd")
+	withManifest = flag.Bool("manifest", true, "Write the manifest of the converted files")
+	directory    = flag.Bool("directory", false, "Write label directories, only for the offsets format")
+	directoryMin = flag.Int("directorymin", 1024, "Number of edges a node needs to get a label directory")
+	blockSize    = flag.Int("blocksize", csrfile.DefaultBlockSize, "Uncompressed size of the blocks of the zstd format, a multiple of 8")
+	idWidth      = flag.Int("idwidth", 4, "Size of the node IDs of the converted files in bytes, 8 is only supported by the offsets format")
+)
+
+func main() {
+	flag.Parse()
+	flags, err := csrfile.LayoutFlags(csrfile.Layout(*format))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *blockSize <= 0 || *blockSize%8 != 0 || *blockSize > csrfile.MaxBlockSize {
+		log.Fatalf("Invalid block size %d", *blockSize)
+	}
+	if *idWidth != 4 && (*idWidth != 8 || flags != csrfile.ByteOffsets || *directory) {
+		log.Fatalf("Node IDs of %d bytes can not be written in the %s format", *idWidth, *format)
+	}
+	opts := csrfile.EncodeOptions{Flags: flags, DirectoryMinEdges: *directoryMin, BlockSize: *blockSize,
+		IDWidth: uint8(*idWidth)}
+	if *directory {
+		if flags != csrfile.ByteOffsets {
+			log.Fatalf("Label directories can not be written in the %s format", *format)
+		}
+		opts.Flags |= csrfile.LabelDirectory
+	}
+	if *destBucket == "" {
+		log.Fatal("The destination bucket is required")
+	}
+	src, err := storage.InitializeService(*fsType, *bucket, *region)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dest, err := storage.InitializeService(*destFsType, *destBucket, *destRegion)
+	if err != nil {
+		log.Fatal(err)
+	}
+	writer, ok := dest.(storage.Writer)
+	if !ok {
+		log.Fatalf("Unable to write to filesystem type %s", *destFsType)
+	}
+	files, err := graphaccess.ProbeManifest(src, false)
+	if err != nil {
+		log.Fatalf("Unable to probe files: %s", err)
+	}
+	var srcBytes, destBytes int
+	for _, e := range files.Entries {
+		data, err := src.Fetch(e.ObjectName, storage.BRangeStart(0))
+		if err != nil {
+			log.Fatalf("Unable to fetch %s: %s", e.ObjectName, err)
+		}
+		h, nodes, err := csrfile.Decode(data)
+		if err != nil {
+			log.Fatalf("Unable to read %s: %s", e.ObjectName, err)
+		}
+		converted, err := csrfile.Encode(h.Start, nodes, opts)
+		if err != nil {
+			log.Fatalf("Unable to encode %s: %s", e.ObjectName, err)
+		}
+		if err = writer.Put(e.ObjectName, converted); err != nil {
+			log.Fatalf("Unable to write %s: %s", e.ObjectName, err)
+		}
+		log.Printf("Converted %s from %d to %d bytes\n", e.ObjectName, len(data), len(converted))
+		srcBytes += len(data)
+		destBytes += len(converted)
+	}
+	log.Printf("Converted %d files from %d to %d bytes\n", len(files.Entries), srcBytes, destBytes)
+	if !*withManifest {
+		return
+	}
+	manifest, err := graphaccess.ProbeManifest(dest, true)
+	if err != nil {
+		log.Fatalf("Unable to probe converted files: %s", err)
+	}
+	if err = writer.Put(graphaccess.ManifestObject, manifest.Encode()); err != nil {
+		log.Fatalf("Unable to write manifest: %s", err)
+	}
+}
@@ -0,0 +1,46 @@
+// Command fsck checks the files of a graph, see validator.Check, and
+// prints the report as JSON. It exits with status 1 if there are any
+// issues.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/adityachandla/graph_access_service/storage"
+	"github.com/adityachandla/graph_access_service/validator"
+)
+
+var (
+	fsType      = flag.String("fstype", "s3", "Filesystem type s3/local")
+	bucket      = flag.String("bucket", "s3graphtest1", "Path to the s3 bucket")
+	region      = flag.String("region", "eu-west-1", "AWS Region")
+	prefix      = flag.String("prefix", "", "Only check the objects under the prefix, such as a partition of a label partitioned bucket")
+	maxIssues   = flag.Int("maxissues", 1000, "Number of issues that are listed in the report, 0 lists all")
+	parallelism = flag.Int("parallelism", 0, "Number of files that are read at once, 0 for the default")
+)
+
+func main() {
+	flag.Parse()
+	fetcher, err := storage.InitializeService(*fsType, *bucket, *region)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *prefix != "" {
+		fetcher = storage.NewPrefixFetcher(fetcher, *prefix)
+	}
+	report, err := validator.Check(fetcher, validator.Options{MaxIssues: *maxIssues, Parallelism: *parallelism})
+	if err != nil {
+		log.Fatalf("Unable to list files: %s", err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(report); err != nil {
+		log.Fatal(err)
+	}
+	if !report.OK {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,125 @@
+// Package graphflags defines the flags that the commands which write the
+// files of a graph share, and checks them in one place so that the
+// commands accept the same combinations.
+package graphflags
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/adityachandla/graph_access_service/builder"
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/graphaccess"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+// Source is the filesystem that a graph is read from.
+type Source struct {
+	fsType, bucket, region *string
+}
+
+func RegisterSource(fs *flag.FlagSet) *Source {
+	return &Source{
+		fsType: fs.String("fstype", "s3", "Filesystem type of the source s3/local"),
+		bucket: fs.String("bucket", "s3graphtest1", "Path to the source s3 bucket"),
+		region: fs.String("region", "eu-west-1", "AWS Region of the source"),
+	}
+}
+
+func (s *Source) Fetcher() (storage.Fetcher, error) {
+	return storage.InitializeService(*s.fsType, *s.bucket, *s.region)
+}
+
+// Output is the filesystem that the files of a graph are written to and
+// the options they are written with.
+type Output struct {
+	destFsType, destBucket, destRegion *string
+	nodesPerFile, fileBytes            *int
+	format                             *string
+	directory                          *bool
+	directoryMin, blockSize, idWidth   *int
+	manifest                           *bool
+}
+
+// RegisterOutput defines the flags of the destination and of the written
+// files, nodesPerFile is the default of the -nodes flag.
+func RegisterOutput(fs *flag.FlagSet, nodesPerFile int) *Output {
+	return &Output{
+		destFsType:   fs.String("destfstype", "local", "Filesystem type of the destination s3/local"),
+		destBucket:   fs.String("destbucket", "", "Path to the destination s3 bucket"),
+		destRegion:   fs.String("destregion", "eu-west-1", "AWS Region of the destination"),
+		nodesPerFile: fs.Int("nodes", nodesPerFile, "Maximum number of nodes per file, 0 for no limit"),
+		fileBytes:    fs.Int("filebytes", 0, "Size of the uncompressed edges that a file should not grow beyond, 0 for no limit"),
+		format:       fs.String("format", "offsets", "Layout of the written files indices/offsets/varint/zstd"),
+		directory:    fs.Bool("directory", false, "Write label directories, only for the offsets format"),
+		directoryMin: fs.Int("directorymin", 1024, "Number of edges a node needs to get a label directory"),
+		blockSize:    fs.Int("blocksize", csrfile.DefaultBlockSize, "Uncompressed size of the blocks of the zstd format, a multiple of 8"),
+		idWidth:      fs.Int("idwidth", 4, "Size of the node IDs of the written files in bytes, 8 is only supported by the offsets format"),
+		manifest:     fs.Bool("manifest", true, "Write the manifest of the written files"),
+	}
+}
+
+// Options checks the flags of the written files and returns the options
+// to build them with.
+func (o *Output) Options() (builder.Options, error) {
+	flags, err := csrfile.LayoutFlags(csrfile.Layout(*o.format))
+	if err != nil {
+		return builder.Options{}, err
+	}
+	if *o.blockSize <= 0 || *o.blockSize%8 != 0 || *o.blockSize > csrfile.MaxBlockSize {
+		return builder.Options{}, fmt.Errorf("Invalid block size %d", *o.blockSize)
+	}
+	if *o.directory && flags != csrfile.ByteOffsets {
+		return builder.Options{}, fmt.Errorf("Label directories can not be written in the %s format", *o.format)
+	}
+	if *o.idWidth != 4 && (*o.idWidth != 8 || flags != csrfile.ByteOffsets || *o.directory) {
+		return builder.Options{}, fmt.Errorf("Node IDs of %d bytes can not be written in the %s format", *o.idWidth, *o.format)
+	}
+	if *o.directory {
+		flags |= csrfile.LabelDirectory
+	}
+	return builder.Options{
+		NodesPerFile: *o.nodesPerFile,
+		FileBytes:    *o.fileBytes,
+		Encode: csrfile.EncodeOptions{Flags: flags, DirectoryMinEdges: *o.directoryMin, BlockSize: *o.blockSize,
+			IDWidth: uint8(*o.idWidth)},
+	}, nil
+}
+
+// Destination initializes the filesystem that the files are written to.
+// The source is nil for commands that don't read a graph, otherwise the
+// destination has to be a different one.
+func (o *Output) Destination(src *Source) (storage.Fetcher, storage.Writer, error) {
+	if *o.destBucket == "" {
+		return nil, nil, errors.New("The destination bucket is required")
+	}
+	if src != nil && *o.destFsType == *src.fsType && *o.destBucket == *src.bucket {
+		return nil, nil, errors.New("The destination has to be different from the source")
+	}
+	dest, err := storage.InitializeService(*o.destFsType, *o.destBucket, *o.destRegion)
+	if err != nil {
+		return nil, nil, err
+	}
+	writer, ok := dest.(storage.Writer)
+	if !ok {
+		return nil, nil, fmt.Errorf("Unable to write to filesystem type %s", *o.destFsType)
+	}
+	return dest, writer, nil
+}
+
+// WriteManifest writes the manifest of the written files, unless it was
+// turned off with the -manifest flag.
+func (o *Output) WriteManifest(dest storage.Fetcher, writer storage.Writer, written []string) error {
+	if !*o.manifest {
+		return nil
+	}
+	manifest, err := graphaccess.ProbeObjects(dest, written, true)
+	if err != nil {
+		return fmt.Errorf("Unable to probe written files: %w", err)
+	}
+	if err = writer.Put(graphaccess.ManifestObject, manifest.Encode()); err != nil {
+		return fmt.Errorf("Unable to write manifest: %w", err)
+	}
+	return nil
+}
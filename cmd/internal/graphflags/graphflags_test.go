@@ -0,0 +1,56 @@
+package graphflags
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func parse(t *testing.T, args ...string) (*Source, *Output) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	src := RegisterSource(fs)
+	out := RegisterOutput(fs, 1<<16)
+	assert.Nil(t, fs.Parse(args))
+	return src, out
+}
+
+func TestOptions(t *testing.T) {
+	_, out := parse(t, "-directory", "-nodes", "10")
+	opts, err := out.Options()
+	assert.Nil(t, err)
+	assert.Equal(t, 10, opts.NodesPerFile)
+	assert.Equal(t, csrfile.ByteOffsets|csrfile.LabelDirectory, opts.Encode.Flags)
+	assert.Equal(t, uint8(4), opts.Encode.IDWidth)
+}
+
+func TestInvalidOptions(t *testing.T) {
+	invalid := [][]string{
+		{"-format", "columns"},
+		{"-format", "zstd", "-blocksize", "12"},
+		{"-format", "varint", "-directory"},
+		{"-format", "zstd", "-idwidth", "8"},
+		{"-idwidth", "8", "-directory"},
+		{"-idwidth", "2"},
+	}
+	for _, args := range invalid {
+		_, out := parse(t, args...)
+		_, err := out.Options()
+		assert.NotNil(t, err, args)
+	}
+}
+
+func TestDestination(t *testing.T) {
+	src, out := parse(t)
+	_, _, err := out.Destination(src)
+	assert.NotNil(t, err)
+
+	dir := t.TempDir()
+	src, out = parse(t, "-fstype", "local", "-bucket", dir, "-destbucket", dir)
+	_, _, err = out.Destination(src)
+	assert.NotNil(t, err)
+	_, writer, err := out.Destination(nil)
+	assert.Nil(t, err)
+	assert.NotNil(t, writer)
+}
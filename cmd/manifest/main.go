@@ -0,0 +1,39 @@
+// Command manifest probes every file of a graph and writes the manifest
+// that the accessors read at startup.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/adityachandla/graph_access_service/graphaccess"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+var (
+	fsType      = flag.String("fstype", "s3", "Filesystem type s3/local")
+	bucket      = flag.String("bucket", "s3graphtest1", "Path to the s3 bucket")
+	region      = flag.String("region", "eu-west-1", "AWS Region")
+	withOffsets = flag.Bool("offsets", true, "Include the offset tables of the files in the manifest")
+	prefix      = flag.String("prefix", "", "Only probe the objects under the prefix, such as a partition of a label partitioned bucket")
+)
+
+func main() {
+	flag.Parse()
+	fetcher, err := storage.InitializeService(*fsType, *bucket, *region)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *prefix != "" {
+		fetcher = storage.NewPrefixFetcher(fetcher, *prefix)
+	}
+	manifest, err := graphaccess.ProbeManifest(fetcher, *withOffsets)
+	if err != nil {
+		log.Fatalf("Unable to probe files: %s", err)
+	}
+	data := manifest.Encode()
+	if err = fetcher.(storage.Writer).Put(graphaccess.ManifestObject, data); err != nil {
+		log.Fatalf("Unable to write manifest: %s", err)
+	}
+	log.Printf("Wrote manifest of %d files (%d bytes)\n", len(manifest.Entries), len(data))
+}
@@ -0,0 +1,175 @@
+// Command partition reorganizes a bucket into a label partitioned bucket,
+// in which the edges of every group of labels are stored in their own
+// objects. The labels that are not in any group are stored together in
+// the default partition.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/graphaccess"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+var (
+	fsType       = flag.String("fstype", "s3", "Filesystem type of the source s3/local")
+	bucket       = flag.String("bucket", "s3graphtest1", "Path to the source s3 bucket")
+	region       = flag.String("region", "eu-west-1", "AWS Region of the source")
+	destFsType   = flag.String("destfstype", "local", "Filesystem type of the destination s3/local")
+	destBucket   = flag.String("destbucket", "", "Path to the destination s3 bucket")
+	destRegion   = flag.String("destregion", "eu-west-1", "AWS Region of the destination")
+	groups       = flag.String("groups", "", "Groups of labels that are stored together, such as 1,2;3 for a partition with labels 1 and 2 and one with label 3")
+	format       = flag.String("format", "offsets", "Layout of the partitioned files indices/offsets/varint/zstd")
+	withManifest = flag.Bool("manifest", true, "Write the manifest of every partition")
+)
+
+// parseGroups parses the groups flag, every label can only be in one
+// group.
+func parseGroups(value string) ([][]uint32, error) {
+	var res [][]uint32
+	seen := make(map[uint32]bool)
+	for _, group := range strings.Split(value, ";") {
+		var labels []uint32
+		for _, field := range strings.Split(group, ",") {
+			label, err := strconv.ParseUint(strings.TrimSpace(field), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid label %q: %w", field, err)
+			}
+			if seen[uint32(label)] {
+				return nil, fmt.Errorf("Label %d is in more than one group", label)
+			}
+			seen[uint32(label)] = true
+			labels = append(labels, uint32(label))
+		}
+		res = append(res, labels)
+	}
+	return res, nil
+}
+
+func partitionPrefix(labels []uint32) string {
+	names := make([]string, len(labels))
+	for i, label := range labels {
+		names[i] = strconv.FormatUint(uint64(label), 10)
+	}
+	return "labels-" + strings.Join(names, "-") + "/"
+}
+
+func main() {
+	flag.Parse()
+	flags, err := csrfile.LayoutFlags(csrfile.Layout(*format))
+	if err != nil {
+		log.Fatal(err)
+	}
+	labelGroups, err := parseGroups(*groups)
+	if err != nil {
+		log.Fatalf("Invalid groups: %s", err)
+	}
+	if *destBucket == "" {
+		log.Fatal("The destination bucket is required")
+	}
+	src, err := storage.InitializeService(*fsType, *bucket, *region)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dest, err := storage.InitializeService(*destFsType, *destBucket, *destRegion)
+	if err != nil {
+		log.Fatal(err)
+	}
+	writer, ok := dest.(storage.Writer)
+	if !ok {
+		log.Fatalf("Unable to write to filesystem type %s", *destFsType)
+	}
+
+	index := graphaccess.PartitionIndex{}
+	partitionOf := make(map[uint32]int)
+	for i, labels := range labelGroups {
+		index.Partitions = append(index.Partitions, graphaccess.Partition{Prefix: partitionPrefix(labels), Labels: labels})
+		for _, label := range labels {
+			partitionOf[label] = i
+		}
+	}
+	others := len(index.Partitions)
+	index.Partitions = append(index.Partitions, graphaccess.Partition{Prefix: "labels-other/", Default: true})
+	find := func(label uint32) int {
+		if p, found := partitionOf[label]; found {
+			return p
+		}
+		return others
+	}
+
+	files, err := graphaccess.ProbeManifest(src, false)
+	if err != nil {
+		log.Fatalf("Unable to probe files: %s", err)
+	}
+	for _, e := range files.Entries {
+		data, err := src.Fetch(e.ObjectName, storage.BRangeStart(0))
+		if err != nil {
+			log.Fatalf("Unable to fetch %s: %s", e.ObjectName, err)
+		}
+		h, nodes, err := csrfile.Decode(data)
+		if err != nil {
+			log.Fatalf("Unable to read %s: %s", e.ObjectName, err)
+		}
+		//Every partition has all the nodes so that its node ranges are
+		//the same as those of the source.
+		split := make([][]csrfile.Node, len(index.Partitions))
+		for p := range split {
+			split[p] = make([]csrfile.Node, len(nodes))
+		}
+		for n, node := range nodes {
+			for _, edge := range node.Out {
+				p := find(edge.Label)
+				split[p][n].Out = append(split[p][n].Out, edge)
+			}
+			for _, edge := range node.In {
+				p := find(edge.Label)
+				split[p][n].In = append(split[p][n].In, edge)
+			}
+		}
+		for p, partition := range index.Partitions {
+			encoded, err := csrfile.Encode(h.Start, split[p], csrfile.EncodeOptions{Flags: flags, IDWidth: h.IDWidth})
+			if err != nil {
+				log.Fatalf("Unable to encode %s: %s", partition.Prefix+e.ObjectName, err)
+			}
+			if err = writer.Put(partition.Prefix+e.ObjectName, encoded); err != nil {
+				log.Fatalf("Unable to write %s: %s", partition.Prefix+e.ObjectName, err)
+			}
+		}
+		log.Printf("Partitioned %s\n", e.ObjectName)
+	}
+	if *withManifest {
+		for _, partition := range index.Partitions {
+			manifest, err := graphaccess.ProbeManifest(storage.NewPrefixFetcher(dest, partition.Prefix), true)
+			if err != nil {
+				log.Fatalf("Unable to probe partition %s: %s", partition.Prefix, err)
+			}
+			if err = writer.Put(partition.Prefix+graphaccess.ManifestObject, manifest.Encode()); err != nil {
+				log.Fatalf("Unable to write manifest of partition %s: %s", partition.Prefix, err)
+			}
+		}
+	}
+	indexBytes, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		log.Fatalf("Unable to encode partition index: %s", err)
+	}
+	if err = writer.Put(graphaccess.PartitionsObject, indexBytes); err != nil {
+		log.Fatalf("Unable to write partition index: %s", err)
+	}
+	//The permutation of a relabeled graph is shared by all the partitions.
+	perm, err := src.Fetch(graphaccess.PermutationObject, storage.BRangeStart(0))
+	if err != nil && !storage.IsNotExist(err) {
+		log.Fatalf("Unable to fetch permutation: %s", err)
+	}
+	if err == nil {
+		if err = writer.Put(graphaccess.PermutationObject, perm); err != nil {
+			log.Fatalf("Unable to write permutation: %s", err)
+		}
+	}
+	log.Printf("Wrote %d partitions of %d files\n", len(index.Partitions), len(files.Entries))
+}
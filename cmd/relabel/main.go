@@ -0,0 +1,47 @@
+// Command relabel rewrites a graph with new node IDs so that neighbours
+// get nearby IDs, see builder.RelabelGraph. The server translates the IDs
+// of the requests with the permutation that is written along, so clients
+// keep using the original IDs.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/adityachandla/graph_access_service/builder"
+	"github.com/adityachandla/graph_access_service/cmd/internal/graphflags"
+)
+
+var (
+	source = graphflags.RegisterSource(flag.CommandLine)
+	output = graphflags.RegisterOutput(flag.CommandLine, 1<<16)
+	order  = flag.String("order", "bfs", "Order of the new node IDs bfs/degree")
+)
+
+func main() {
+	flag.Parse()
+	ordering, ok := builder.Orderings[*order]
+	if !ok {
+		log.Fatalf("Unknown order %s", *order)
+	}
+	opts, err := output.Options()
+	if err != nil {
+		log.Fatal(err)
+	}
+	dest, writer, err := output.Destination(source)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := source.Fetcher()
+	if err != nil {
+		log.Fatal(err)
+	}
+	perm, written, err := builder.RelabelGraph(src, writer, ordering, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Relabeled %d nodes into %d files\n", perm.NumNodes(), len(written))
+	if err = output.WriteManifest(dest, writer, written); err != nil {
+		log.Fatal(err)
+	}
+}
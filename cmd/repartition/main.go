@@ -0,0 +1,41 @@
+// Command repartition rewrites the files of a graph with another number of
+// nodes or bytes per file, see builder.Repartition, for example to measure
+// how the size of the files affects the accessors that fetch whole files.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/adityachandla/graph_access_service/builder"
+	"github.com/adityachandla/graph_access_service/cmd/internal/graphflags"
+)
+
+var (
+	source = graphflags.RegisterSource(flag.CommandLine)
+	output = graphflags.RegisterOutput(flag.CommandLine, 0)
+)
+
+func main() {
+	flag.Parse()
+	opts, err := output.Options()
+	if err != nil {
+		log.Fatal(err)
+	}
+	dest, writer, err := output.Destination(source)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := source.Fetcher()
+	if err != nil {
+		log.Fatal(err)
+	}
+	written, err := builder.Repartition(src, writer, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote %d files\n", len(written))
+	if err = output.WriteManifest(dest, writer, written); err != nil {
+		log.Fatal(err)
+	}
+}
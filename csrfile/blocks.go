@@ -0,0 +1,174 @@
+package csrfile
+
+import (
+	"encoding/binary"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// BlockIndexPreambleSize is the size of the block size and the number
+	// of blocks at the start of the block index, which are needed to know
+	// the size of the rest of it.
+	BlockIndexPreambleSize = 8
+	// DefaultBlockSize is the uncompressed size of the blocks written when
+	// no block size is given.
+	DefaultBlockSize = 64 << 10
+	// MaxBlockSize is the largest uncompressed block size that is read.
+	MaxBlockSize = 16 << 20
+)
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	//A block never decompresses to more than MaxBlockSize bytes, larger
+	//frames are corrupt.
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxBlockSize))
+)
+
+// BlockIndex maps the uncompressed edges of a Zstd file to the blocks
+// that store them. Block i holds the uncompressed bytes from i*BlockSize,
+// all blocks but the last are BlockSize bytes long.
+type BlockIndex struct {
+	BlockSize uint32
+	// Offsets has the position of every block in the file followed by the
+	// end of the last block.
+	Offsets []uint32
+	//edgesSize is the number of bytes of the uncompressed edges.
+	edgesSize uint64
+}
+
+// BlockIndexSize returns the size of the block index from its preamble,
+// which is stored at the start of the edges. The index ends with the
+// checksum of the rest of it.
+func (h *Header) BlockIndexSize(preamble []byte) (uint64, error) {
+	if len(preamble) < BlockIndexPreambleSize {
+		return 0, corrupt("block index is only %d bytes long", len(preamble))
+	}
+	blockSize := uint64(binary.LittleEndian.Uint32(preamble))
+	numBlocks := uint64(binary.LittleEndian.Uint32(preamble[4:]))
+	if blockSize == 0 || blockSize > MaxBlockSize || blockSize%h.EdgeSize() != 0 {
+		return 0, corrupt("block size %d is invalid", blockSize)
+	}
+	edgesSize := h.NumEdges * h.EdgeSize()
+	if expected := (edgesSize + blockSize - 1) / blockSize; numBlocks != expected {
+		return 0, corrupt("file has %d blocks instead of %d", numBlocks, expected)
+	}
+	return BlockIndexPreambleSize + 4*(numBlocks+1) + 4, nil
+}
+
+// ParseBlockIndex parses and checks the block index of the file. After
+// that every block can be fetched from its offset till the offset of the
+// next one.
+func (h *Header) ParseBlockIndex(data []byte) (*BlockIndex, error) {
+	size, err := h.BlockIndexSize(data)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != size {
+		return nil, corrupt("block index is %d bytes instead of %d", len(data), size)
+	}
+	if checksum(data[:len(data)-4]) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return nil, corrupt("block index checksum mismatch")
+	}
+	index := &BlockIndex{
+		BlockSize: binary.LittleEndian.Uint32(data),
+		Offsets:   make([]uint32, 0, (len(data)-BlockIndexPreambleSize-4)/4),
+		edgesSize: h.NumEdges * h.EdgeSize(),
+	}
+	prev := h.EdgesStart() + size
+	for i := BlockIndexPreambleSize; i < len(data)-4; i += 4 {
+		offset := uint64(binary.LittleEndian.Uint32(data[i:]))
+		//Every block takes at least one byte.
+		if (i == BlockIndexPreambleSize && offset != prev) || (i > BlockIndexPreambleSize && offset <= prev) {
+			return nil, corrupt("offset %d of block %d is invalid", offset, len(index.Offsets))
+		}
+		index.Offsets = append(index.Offsets, uint32(offset))
+		prev = offset
+	}
+	return index, nil
+}
+
+func (b *BlockIndex) NumBlocks() int {
+	return len(b.Offsets) - 1
+}
+
+// EdgesSize is the number of bytes of the uncompressed edges.
+func (b *BlockIndex) EdgesSize() uint64 {
+	return b.edgesSize
+}
+
+// Blocks returns the first and the last block that hold the uncompressed
+// bytes from start till end, exclusive. The range must not be empty.
+func (b *BlockIndex) Blocks(start, end uint64) (int, int) {
+	return int(start / uint64(b.BlockSize)), int((end - 1) / uint64(b.BlockSize))
+}
+
+// BlockRange is the position of the block in the file, from the returned
+// start till the returned end, exclusive.
+func (b *BlockIndex) BlockRange(block int) (uint32, uint32) {
+	return b.Offsets[block], b.Offsets[block+1]
+}
+
+// Decompress returns the uncompressed bytes of the block.
+func (b *BlockIndex) Decompress(block int, compressed []byte) ([]byte, error) {
+	size := min(uint64(b.BlockSize), b.edgesSize-uint64(block)*uint64(b.BlockSize))
+	data, err := zstdDecoder.DecodeAll(compressed, make([]byte, 0, size))
+	if err != nil {
+		return nil, corrupt("block %d can not be decompressed: %s", block, err)
+	}
+	if uint64(len(data)) != size {
+		return nil, corrupt("block %d is %d bytes instead of %d", block, len(data), size)
+	}
+	return data, nil
+}
+
+// appendBlocks appends the block index and the blocks of the edges, which
+// start at the position start of the file.
+func appendBlocks(dst, edges []byte, blockSize int, start uint64) []byte {
+	numBlocks := (len(edges) + blockSize - 1) / blockSize
+	var blocks []byte
+	offsets := make([]uint32, 0, numBlocks+1)
+	for i := 0; i < len(edges); i += blockSize {
+		offsets = append(offsets, uint32(len(blocks)))
+		blocks = zstdEncoder.EncodeAll(edges[i:min(i+blockSize, len(edges))], blocks)
+	}
+	offsets = append(offsets, uint32(len(blocks)))
+	blocksStart := uint32(start) + BlockIndexPreambleSize + 4*uint32(numBlocks+1) + 4
+	index := binary.LittleEndian.AppendUint32(nil, uint32(blockSize))
+	index = binary.LittleEndian.AppendUint32(index, uint32(numBlocks))
+	for _, offset := range offsets {
+		index = binary.LittleEndian.AppendUint32(index, blocksStart+offset)
+	}
+	index = binary.LittleEndian.AppendUint32(index, checksum(index))
+	dst = append(dst, index...)
+	return append(dst, blocks...)
+}
+
+// decompressEdges checks the block index at the start of data, which has
+// the index and all the blocks, and returns the uncompressed edges.
+func (h *Header) decompressEdges(data []byte) ([]byte, error) {
+	size, err := h.BlockIndexSize(data)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) < size {
+		return nil, corrupt("block index is cut off")
+	}
+	index, err := h.ParseBlockIndex(data[:size])
+	if err != nil {
+		return nil, err
+	}
+	if end := uint64(index.Offsets[index.NumBlocks()]); end != h.EdgesStart()+uint64(len(data)) {
+		return nil, corrupt("blocks end at %d instead of at the end of the file", end)
+	}
+	edges := make([]byte, 0, index.edgesSize)
+	for i := 0; i < index.NumBlocks(); i++ {
+		start, end := index.BlockRange(i)
+		block, err := index.Decompress(i, data[uint64(start)-h.EdgesStart():uint64(end)-h.EdgesStart()])
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, block...)
+	}
+	return edges, nil
+}
@@ -0,0 +1,78 @@
+package csrfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockIndex(t *testing.T) {
+	file := mustEncode(t, 10, testNodes(), EncodeOptions{Flags: Zstd, BlockSize: 16})
+	h, err := ParseHeader(file)
+	assert.Nil(t, err)
+	assert.Equal(t, LayoutZstd, h.Layout())
+	assert.Nil(t, h.ValidateOffsets(file[h.OffsetTableStart():h.EdgesStart()]))
+	size, err := h.BlockIndexSize(file[h.EdgesStart():])
+	assert.Nil(t, err)
+	index, err := h.ParseBlockIndex(file[h.EdgesStart() : h.EdgesStart()+size])
+	assert.Nil(t, err)
+	//6 edges of 8 bytes in blocks of 16 bytes.
+	assert.Equal(t, 3, index.NumBlocks())
+	assert.Equal(t, uint64(48), index.EdgesSize())
+	first, last := index.Blocks(8, 40)
+	assert.Equal(t, 0, first)
+	assert.Equal(t, 2, last)
+	first, last = index.Blocks(16, 32)
+	assert.Equal(t, 1, first)
+	assert.Equal(t, 1, last)
+
+	var edges []byte
+	for i := 0; i < index.NumBlocks(); i++ {
+		start, end := index.BlockRange(i)
+		block, err := index.Decompress(i, file[start:end])
+		assert.Nil(t, err)
+		edges = append(edges, block...)
+	}
+	assert.Equal(t, uint64(len(file)), uint64(index.Offsets[3]))
+	plain := mustEncode(t, 10, testNodes(), EncodeOptions{})
+	assert.True(t, bytes.Equal(plain[HeaderSize+6*4:], edges))
+}
+
+func TestCorruptBlocks(t *testing.T) {
+	file := mustEncode(t, 10, testNodes(), EncodeOptions{Flags: Zstd, BlockSize: 16})
+	h, err := ParseHeader(file)
+	assert.Nil(t, err)
+	size, err := h.BlockIndexSize(file[h.EdgesStart():])
+	assert.Nil(t, err)
+	index, err := h.ParseBlockIndex(file[h.EdgesStart() : h.EdgesStart()+size])
+	assert.Nil(t, err)
+
+	//Blocks that do not decompress to their size.
+	start, end := index.BlockRange(0)
+	_, err = index.Decompress(0, file[start:end-1])
+	assert.True(t, errors.Is(err, ErrCorrupt))
+	_, err = index.Decompress(1, zstdEncoder.EncodeAll(make([]byte, 8), nil))
+	assert.True(t, errors.Is(err, ErrCorrupt))
+
+	//Keep the checksum of the edges valid so that the blocks are checked.
+	edges := append([]byte{}, file[h.EdgesStart():]...)
+	edges[len(edges)-1] ^= 0xFF
+	_, _, err = Decode(Assemble(h, file[h.OffsetTableStart():h.EdgesStart()], edges))
+	assert.True(t, errors.Is(err, ErrCorrupt))
+
+	indexBytes := append([]byte{}, file[h.EdgesStart():h.EdgesStart()+size]...)
+	binary.LittleEndian.PutUint32(indexBytes, 12)
+	_, err = h.ParseBlockIndex(indexBytes)
+	assert.True(t, errors.Is(err, ErrCorrupt))
+	binary.LittleEndian.PutUint32(indexBytes, 16)
+	indexBytes[BlockIndexPreambleSize] ^= 1
+	_, err = h.ParseBlockIndex(indexBytes)
+	assert.True(t, errors.Is(err, ErrCorrupt))
+
+	withOffsets := Header{Version: CurrentVersion, IDWidth: 4, HeaderSize: HeaderSize, Flags: Zstd | ByteOffsets}
+	_, err = ParseHeader(withOffsets.Encode())
+	assert.True(t, errors.Is(err, ErrCorrupt))
+}
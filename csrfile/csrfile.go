@@ -0,0 +1,440 @@
+// Package csrfile describes the layout of the files that store a range
+// of nodes of a graph.
+//
+// A file starts with a header followed by the offset table, which has a
+// pair of outgoing and incoming offsets for every node, and the edges,
+// which are (label, destination) pairs. The outgoing edges of a node are
+// stored before its incoming edges. Depending on the layout the offsets
+// are either absolute byte offsets of the edges in the file, which is
+// what OffsetCsr reads, or indices into the edge array, which is what
+// Csr reads. All integers are little endian. Files with the Varint flag
+// store the edges of every node compressed instead, see
+// AppendVarintSection, and their offsets are byte offsets.
+//
+// Files with the LabelDirectory flag have a label directory for every
+// node after the edges, see DirectoryEntry, so that only the edges with a
+// single label can be read. The directory table has the byte offset of
+// the directory of every node and the offset of the end of the last one,
+// followed by the CRC of the table. The directories follow the table and
+// are followed by the CRC of all of them.
+//
+// Files with the Zstd flag store the edges uncompressed in fixed size
+// blocks that are compressed with zstd, and their offsets are indices. The
+// block index comes right after the offset table. It has the uncompressed
+// block size and the number of blocks, each of them 4 bytes, the byte
+// offset of every block and of the end of the last one and the CRC of the
+// index, see BlockIndex. The blocks follow the index.
+//
+// Node IDs and offsets are 4 bytes long, or 8 bytes long in files with a
+// node ID width of 8, which can only have the byte offsets layout without
+// label directories. Their edges are 12 bytes long. Labels are always 4
+// bytes long.
+//
+// The header is HeaderSize bytes long:
+//
+//	magic "GCSR"          4 bytes
+//	version               2 bytes
+//	node ID width         1 byte
+//	flags                 1 byte
+//	header size           4 bytes
+//	start node            8 bytes
+//	end node              8 bytes, inclusive
+//	number of edges       8 bytes
+//	CRC of offset table   4 bytes
+//	CRC of edges          4 bytes
+//	CRC of header         4 bytes, of all the bytes before it
+//
+// Files written before the header was introduced start with the start and
+// end node as 4 byte integers followed by the offset table. They are read
+// as version 0 and their layout is inferred from the first offset.
+package csrfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+const (
+	Magic = "GCSR"
+	// CurrentVersion is the version of the files that are written.
+	CurrentVersion = 1
+	// LegacyVersion is the version of files without a header.
+	LegacyVersion = 0
+
+	HeaderSize       = 48
+	LegacyHeaderSize = 8
+	// PrefixSize is the number of bytes needed to tell a versioned file
+	// from a legacy one, see IsVersioned.
+	PrefixSize = LegacyHeaderSize
+)
+
+type Flags uint8
+
+const (
+	// ByteOffsets is set when the offset table holds absolute byte
+	// offsets of the edges, otherwise it holds indices into the edges.
+	ByteOffsets Flags = 1 << iota
+	// Varint is set when the edges of every node are compressed, see
+	// AppendVarintSection. The offsets of such files are byte offsets.
+	Varint
+	// LabelDirectory is set when the file has label directories, the
+	// offsets of such files are byte offsets and the edges are not
+	// compressed.
+	LabelDirectory
+	// Zstd is set when the edges are stored in zstd compressed blocks, see
+	// BlockIndex. The offsets of such files are indices and no other flag
+	// can be set.
+	Zstd
+)
+
+const knownFlags = ByteOffsets | Varint | LabelDirectory | Zstd
+
+// Layout tells which accessor can read a file.
+type Layout string
+
+const (
+	// LayoutIndices is read by Csr.
+	LayoutIndices Layout = "indices"
+	// LayoutByteOffsets is read by OffsetCsr and PrefetchCsr.
+	LayoutByteOffsets Layout = "offsets"
+	// LayoutVarint is read by VarintCsr.
+	LayoutVarint Layout = "varint"
+	// LayoutZstd is read by ZstdCsr.
+	LayoutZstd Layout = "zstd"
+)
+
+func (h *Header) Layout() Layout {
+	if h.Flags&Zstd != 0 {
+		return LayoutZstd
+	}
+	if h.Flags&Varint != 0 {
+		return LayoutVarint
+	}
+	if h.Flags&ByteOffsets != 0 {
+		return LayoutByteOffsets
+	}
+	return LayoutIndices
+}
+
+// LayoutFlags returns the flags of the files with the layout.
+func LayoutFlags(layout Layout) (Flags, error) {
+	switch layout {
+	case LayoutIndices:
+		return 0, nil
+	case LayoutByteOffsets:
+		return ByteOffsets, nil
+	case LayoutVarint:
+		return Varint, nil
+	case LayoutZstd:
+		return Zstd, nil
+	}
+	return 0, fmt.Errorf("Invalid layout %s", layout)
+}
+
+// IsCompressed is true for the layouts whose edges are not stored as
+// fixed size pairs.
+func (h *Header) IsCompressed() bool {
+	return h.Layout() == LayoutVarint || h.Layout() == LayoutZstd
+}
+
+// ErrCorrupt is wrapped by all the errors about files that can not be
+// read.
+var ErrCorrupt = errors.New("Corrupt CSR file")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+func checksum(data []byte) uint32 {
+	return crc32.Checksum(data, castagnoli)
+}
+
+func corrupt(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrCorrupt, fmt.Sprintf(format, args...))
+}
+
+type Header struct {
+	Version uint16
+	// IDWidth is the size of node IDs and offsets in bytes.
+	IDWidth    uint8
+	Flags      Flags
+	HeaderSize uint32
+	// Nodes from Start to End, both inclusive, are stored in the file.
+	Start, End uint64
+	// NumEdges is the number of outgoing and incoming edges in the file,
+	// it is not known for legacy files.
+	NumEdges             uint64
+	OffsetsCrc, EdgesCrc uint32
+}
+
+func (h *Header) IsLegacy() bool {
+	return h.Version == LegacyVersion
+}
+
+func (h *Header) NumNodes() uint64 {
+	return h.End - h.Start + 1
+}
+
+func (h *Header) OffsetTableSize() uint64 {
+	return 2 * uint64(h.IDWidth) * h.NumNodes()
+}
+
+// OffsetTableStart is the position of the offset table in the file.
+func (h *Header) OffsetTableStart() uint64 {
+	return uint64(h.HeaderSize)
+}
+
+// EdgesStart is the position of the first edge in the file.
+func (h *Header) EdgesStart() uint64 {
+	return h.OffsetTableStart() + h.OffsetTableSize()
+}
+
+// EdgeSize is the size of a single (label, destination) pair.
+func (h *Header) EdgeSize() uint64 {
+	return 4 + uint64(h.IDWidth)
+}
+
+func (h *Header) HasDirectory() bool {
+	return h.Flags&LabelDirectory != 0
+}
+
+// DirectoryTableStart is the position of the directory table, it starts
+// right after the edges.
+func (h *Header) DirectoryTableStart() uint64 {
+	return h.EdgesStart() + h.NumEdges*h.EdgeSize()
+}
+
+// DirectoryTableSize includes the end of the last directory and the
+// checksum of the table.
+func (h *Header) DirectoryTableSize() uint64 {
+	return 4*(h.NumNodes()+1) + 4
+}
+
+// ReadID reads a node ID or an offset of the file from the start of data.
+func (h *Header) ReadID(data []byte) uint64 {
+	if h.IDWidth == 8 {
+		return binary.LittleEndian.Uint64(data)
+	}
+	return uint64(binary.LittleEndian.Uint32(data))
+}
+
+// AppendID appends a node ID or an offset of the file to dst.
+func (h *Header) AppendID(dst []byte, id uint64) []byte {
+	if h.IDWidth == 8 {
+		return binary.LittleEndian.AppendUint64(dst, id)
+	}
+	return binary.LittleEndian.AppendUint32(dst, uint32(id))
+}
+
+// IsVersioned tells if the file that starts with prefix has a header, at
+// least PrefixSize bytes are needed.
+func IsVersioned(prefix []byte) bool {
+	return len(prefix) >= len(Magic) && string(prefix[:len(Magic)]) == Magic
+}
+
+// ParseHeader parses the header at the start of data. Versioned files
+// need HeaderSize bytes and legacy files LegacyHeaderSize bytes. The
+// layout of legacy files is not known till InferLayout is called.
+func ParseHeader(data []byte) (Header, error) {
+	if len(data) < PrefixSize {
+		return Header{}, corrupt("file is only %d bytes long", len(data))
+	}
+	if !IsVersioned(data) {
+		h := Header{
+			Version:    LegacyVersion,
+			IDWidth:    4,
+			HeaderSize: LegacyHeaderSize,
+			Start:      uint64(binary.LittleEndian.Uint32(data[0:4])),
+			End:        uint64(binary.LittleEndian.Uint32(data[4:8])),
+		}
+		if h.End < h.Start {
+			return Header{}, corrupt("end node %d is before start node %d", h.End, h.Start)
+		}
+		return h, nil
+	}
+	if len(data) < HeaderSize {
+		return Header{}, corrupt("header is only %d bytes long", len(data))
+	}
+	h := Header{
+		Version:    binary.LittleEndian.Uint16(data[4:6]),
+		IDWidth:    data[6],
+		Flags:      Flags(data[7]),
+		HeaderSize: binary.LittleEndian.Uint32(data[8:12]),
+		Start:      binary.LittleEndian.Uint64(data[12:20]),
+		End:        binary.LittleEndian.Uint64(data[20:28]),
+		NumEdges:   binary.LittleEndian.Uint64(data[28:36]),
+		OffsetsCrc: binary.LittleEndian.Uint32(data[36:40]),
+		EdgesCrc:   binary.LittleEndian.Uint32(data[40:44]),
+	}
+	if crc := binary.LittleEndian.Uint32(data[44:48]); crc != checksum(data[:44]) {
+		return Header{}, corrupt("header checksum mismatch")
+	}
+	if h.Version == LegacyVersion || h.Version > CurrentVersion {
+		return Header{}, fmt.Errorf("Unsupported CSR file version %d, the latest supported version is %d",
+			h.Version, CurrentVersion)
+	}
+	if h.IDWidth != 4 && h.IDWidth != 8 {
+		return Header{}, fmt.Errorf("Unsupported node ID width of %d bytes", h.IDWidth)
+	}
+	if h.Flags&^knownFlags != 0 {
+		return Header{}, fmt.Errorf("Unsupported CSR file flags %b", h.Flags&^knownFlags)
+	}
+	if h.IDWidth == 8 && h.Flags != ByteOffsets {
+		return Header{}, fmt.Errorf("Unsupported CSR file flags %b for 8 byte node IDs", h.Flags)
+	}
+	if h.Flags&Varint != 0 && h.Flags&ByteOffsets == 0 {
+		return Header{}, corrupt("compressed file without byte offsets")
+	}
+	if h.Flags&LabelDirectory != 0 && h.Layout() != LayoutByteOffsets {
+		return Header{}, corrupt("label directory in a file with the %s layout", h.Layout())
+	}
+	if h.Flags&Zstd != 0 && h.Flags != Zstd {
+		return Header{}, corrupt("zstd compressed file with the flags %b", h.Flags)
+	}
+	if h.HeaderSize != HeaderSize {
+		return Header{}, corrupt("header size is %d instead of %d", h.HeaderSize, HeaderSize)
+	}
+	if h.End < h.Start {
+		return Header{}, corrupt("end node %d is before start node %d", h.End, h.Start)
+	}
+	return h, nil
+}
+
+// Encode returns the header as it is stored at the start of the file.
+// Legacy headers are encoded as a versioned header with version 0, which
+// is only meaningful outside of a CSR file.
+func (h *Header) Encode() []byte {
+	res := make([]byte, 0, HeaderSize)
+	res = append(res, Magic...)
+	res = binary.LittleEndian.AppendUint16(res, h.Version)
+	res = append(res, h.IDWidth, byte(h.Flags))
+	res = binary.LittleEndian.AppendUint32(res, h.HeaderSize)
+	res = binary.LittleEndian.AppendUint64(res, h.Start)
+	res = binary.LittleEndian.AppendUint64(res, h.End)
+	res = binary.LittleEndian.AppendUint64(res, h.NumEdges)
+	res = binary.LittleEndian.AppendUint32(res, h.OffsetsCrc)
+	res = binary.LittleEndian.AppendUint32(res, h.EdgesCrc)
+	return binary.LittleEndian.AppendUint32(res, checksum(res))
+}
+
+// DecodeHeader is the inverse of Encode, unlike ParseHeader it also
+// accepts legacy headers.
+func DecodeHeader(data []byte) (Header, error) {
+	if len(data) < HeaderSize || !IsVersioned(data) {
+		return Header{}, corrupt("encoded header is missing")
+	}
+	if binary.LittleEndian.Uint16(data[4:6]) != LegacyVersion {
+		return ParseHeader(data)
+	}
+	if crc := binary.LittleEndian.Uint32(data[44:48]); crc != checksum(data[:44]) {
+		return Header{}, corrupt("header checksum mismatch")
+	}
+	return Header{
+		Version:    LegacyVersion,
+		IDWidth:    data[6],
+		Flags:      Flags(data[7]),
+		HeaderSize: binary.LittleEndian.Uint32(data[8:12]),
+		Start:      binary.LittleEndian.Uint64(data[12:20]),
+		End:        binary.LittleEndian.Uint64(data[20:28]),
+	}, nil
+}
+
+// InferLayout sets the layout of a legacy file from its offset table.
+// The first node's edges start right after the offset table when the
+// offsets are byte offsets and at index 0 otherwise.
+func (h *Header) InferLayout(offsets []byte) error {
+	if !h.IsLegacy() {
+		return nil
+	}
+	if len(offsets) < 4 {
+		return corrupt("offset table is missing")
+	}
+	switch first := uint64(binary.LittleEndian.Uint32(offsets)); first {
+	case 0:
+		h.Flags &^= ByteOffsets
+	case h.EdgesStart():
+		h.Flags |= ByteOffsets
+	default:
+		return corrupt("first offset %d is neither 0 nor %d", first, h.EdgesStart())
+	}
+	return nil
+}
+
+// ValidateOffsets checks the offset table of the file. After that every
+// offset can be used to slice the edges without going out of range. The
+// number of edges of legacy files is not known, for legacy files that
+// store indices ValidateEdges has to be called first and the byte
+// offsets of the others are not bounded. Neither are the offsets of
+// varint files.
+func (h *Header) ValidateOffsets(offsets []byte) error {
+	if uint64(len(offsets)) != h.OffsetTableSize() {
+		return corrupt("offset table is %d bytes instead of %d", len(offsets), h.OffsetTableSize())
+	}
+	if !h.IsLegacy() && checksum(offsets) != h.OffsetsCrc {
+		return corrupt("offset table checksum mismatch")
+	}
+	first, unit := uint64(0), uint64(1)
+	if h.Flags&ByteOffsets != 0 {
+		first, unit = h.EdgesStart(), h.EdgeSize()
+	}
+	if h.Layout() == LayoutVarint {
+		unit = 1
+	}
+	prev := first
+	for i := 0; i < len(offsets); i += int(h.IDWidth) {
+		offset := h.ReadID(offsets[i:])
+		if (i == 0 && offset != first) || offset < prev || (offset-first)%unit != 0 {
+			return corrupt("offset %d of node %d is invalid", offset, h.Start+uint64(i)/(2*uint64(h.IDWidth)))
+		}
+		prev = offset
+	}
+	bounded := h.Layout() != LayoutVarint && (!h.IsLegacy() || h.Flags&ByteOffsets == 0)
+	if bounded && (prev-first)/unit > h.NumEdges {
+		return corrupt("offsets point past the %d edges of the file", h.NumEdges)
+	}
+	return nil
+}
+
+// ValidateEdges checks the size and the checksum of the edges. The
+// number of edges of legacy files is only known after this. Only the
+// checksum of compressed edges is checked.
+func (h *Header) ValidateEdges(edges []byte) error {
+	if h.IsCompressed() {
+		if checksum(edges) != h.EdgesCrc {
+			return corrupt("edges checksum mismatch")
+		}
+		return nil
+	}
+	if uint64(len(edges))%h.EdgeSize() != 0 {
+		return corrupt("edges are %d bytes, which is not a multiple of %d", len(edges), h.EdgeSize())
+	}
+	if h.IsLegacy() {
+		h.NumEdges = uint64(len(edges)) / h.EdgeSize()
+		return nil
+	}
+	if uint64(len(edges)) != h.NumEdges*h.EdgeSize() {
+		return corrupt("file has %d bytes of edges instead of %d", len(edges), h.NumEdges*h.EdgeSize())
+	}
+	if checksum(edges) != h.EdgesCrc {
+		return corrupt("edges checksum mismatch")
+	}
+	return nil
+}
+
+// Assemble returns a versioned file with the offsets and the edges. The
+// checksums of the header are filled in, as is the number of edges for
+// files that are not compressed. Its other fields have to be set by the
+// caller.
+func Assemble(h Header, offsets, edges []byte) []byte {
+	h.Version = CurrentVersion
+	h.HeaderSize = HeaderSize
+	if !h.IsCompressed() {
+		h.NumEdges = uint64(len(edges)) / h.EdgeSize()
+	}
+	h.OffsetsCrc = checksum(offsets)
+	h.EdgesCrc = checksum(edges)
+	res := make([]byte, 0, HeaderSize+len(offsets)+len(edges))
+	res = append(res, h.Encode()...)
+	res = append(res, offsets...)
+	return append(res, edges...)
+}
@@ -0,0 +1,106 @@
+package csrfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func uints(values ...uint32) []byte {
+	var res []byte
+	for _, v := range values {
+		res = binary.LittleEndian.AppendUint32(res, v)
+	}
+	return res
+}
+
+// Two nodes, the first with 2 outgoing and 1 incoming edge, the second
+// with a single incoming edge.
+var (
+	testIndices = uints(0, 2, 3, 3)
+	testEdges   = uints(1, 5, 2, 6, 1, 7, 3, 8)
+)
+
+func TestAssembleAndParse(t *testing.T) {
+	file := Assemble(Header{IDWidth: 4, Start: 10, End: 11}, testIndices, testEdges)
+	assert.True(t, IsVersioned(file))
+	h, err := ParseHeader(file)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4), h.NumEdges)
+	assert.Equal(t, uint64(HeaderSize+16), h.EdgesStart())
+	assert.Nil(t, h.ValidateOffsets(file[h.OffsetTableStart():h.EdgesStart()]))
+	assert.Nil(t, h.ValidateEdges(file[h.EdgesStart():]))
+
+	decoded, err := DecodeHeader(h.Encode())
+	assert.Nil(t, err)
+	assert.Equal(t, h, decoded)
+}
+
+func TestCorruptHeader(t *testing.T) {
+	file := Assemble(Header{IDWidth: 4, Start: 10, End: 11}, testIndices, testEdges)
+	file[13] ^= 1
+	_, err := ParseHeader(file)
+	assert.True(t, errors.Is(err, ErrCorrupt))
+
+	_, err = ParseHeader(file[:20])
+	assert.True(t, errors.Is(err, ErrCorrupt))
+
+	future := Header{Version: CurrentVersion + 1, IDWidth: 4, HeaderSize: HeaderSize}
+	_, err = ParseHeader(future.Encode())
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrCorrupt))
+}
+
+func TestCorruptTables(t *testing.T) {
+	file := Assemble(Header{IDWidth: 4, Start: 10, End: 11}, testIndices, testEdges)
+	h, err := ParseHeader(file)
+	assert.Nil(t, err)
+	edges := append([]byte{}, file[h.EdgesStart():]...)
+	edges[0] ^= 1
+	assert.True(t, errors.Is(h.ValidateEdges(edges), ErrCorrupt))
+	assert.True(t, errors.Is(h.ValidateEdges(edges[:8]), ErrCorrupt))
+
+	//The offsets are checked even when the checksum matches.
+	badIndices := uints(0, 2, 1, 3)
+	file = Assemble(Header{IDWidth: 4, Start: 10, End: 11}, badIndices, testEdges)
+	h, err = ParseHeader(file)
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(h.ValidateOffsets(badIndices), ErrCorrupt))
+	pastEnd := uints(0, 2, 3, 5)
+	file = Assemble(Header{IDWidth: 4, Start: 10, End: 11}, pastEnd, testEdges)
+	h, err = ParseHeader(file)
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(h.ValidateOffsets(pastEnd), ErrCorrupt))
+}
+
+func TestLegacyFiles(t *testing.T) {
+	for _, byteOffsets := range []bool{false, true} {
+		offsets := testIndices
+		if byteOffsets {
+			offsets = uints(24, 40, 48, 48)
+		}
+		file := append(uints(10, 11), offsets...)
+		file = append(file, testEdges...)
+		h, err := ParseHeader(file)
+		assert.Nil(t, err)
+		assert.True(t, h.IsLegacy())
+		offsetTable := file[h.OffsetTableStart():h.EdgesStart()]
+		assert.Nil(t, h.InferLayout(offsetTable))
+		assert.Equal(t, byteOffsets, h.Flags&ByteOffsets != 0)
+		assert.Nil(t, h.ValidateEdges(file[h.EdgesStart():]))
+		assert.Nil(t, h.ValidateOffsets(offsetTable))
+
+		decoded, err := DecodeHeader(h.Encode())
+		assert.Nil(t, err)
+		assert.Equal(t, h.Start, decoded.Start)
+		assert.Equal(t, h.Flags, decoded.Flags)
+		assert.Equal(t, uint32(LegacyHeaderSize), decoded.HeaderSize)
+	}
+	h, err := ParseHeader(uints(10, 11, 7, 7, 7, 7))
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(h.InferLayout(uints(7, 7, 7, 7)), ErrCorrupt))
+	_, err = ParseHeader(uints(11, 10))
+	assert.True(t, errors.Is(err, ErrCorrupt))
+}
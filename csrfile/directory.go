@@ -0,0 +1,150 @@
+package csrfile
+
+import (
+	"encoding/binary"
+)
+
+// DirectoryEntrySize is the size of a single DirectoryEntry.
+const DirectoryEntrySize = 12
+
+// DirectoryEntry points at the edges with the same label in one direction
+// of a node. Start is the byte offset of the first edge in the file. The
+// directory of a node has the entries of its outgoing edges followed by
+// those of its incoming edges, each of them sorted by label.
+type DirectoryEntry struct {
+	Label, Start, Count uint32
+}
+
+// ParseDirectory parses the directory of a single node.
+func ParseDirectory(data []byte) ([]DirectoryEntry, error) {
+	if len(data)%DirectoryEntrySize != 0 {
+		return nil, corrupt("label directory is %d bytes, which is not a multiple of %d",
+			len(data), DirectoryEntrySize)
+	}
+	entries := make([]DirectoryEntry, 0, len(data)/DirectoryEntrySize)
+	for i := 0; i < len(data); i += DirectoryEntrySize {
+		entries = append(entries, DirectoryEntry{
+			Label: binary.LittleEndian.Uint32(data[i:]),
+			Start: binary.LittleEndian.Uint32(data[i+4:]),
+			Count: binary.LittleEndian.Uint32(data[i+8:]),
+		})
+	}
+	return entries, nil
+}
+
+// ValidateDirectoryTable checks the directory table of the file. After
+// that the directory of every node can be fetched from its offset till the
+// offset of the next one.
+func (h *Header) ValidateDirectoryTable(table []byte) error {
+	if uint64(len(table)) != h.DirectoryTableSize() {
+		return corrupt("directory table is %d bytes instead of %d", len(table), h.DirectoryTableSize())
+	}
+	offsets, crc := table[:len(table)-4], binary.LittleEndian.Uint32(table[len(table)-4:])
+	if checksum(offsets) != crc {
+		return corrupt("directory table checksum mismatch")
+	}
+	prev := h.DirectoryTableStart() + h.DirectoryTableSize()
+	for i := 0; i < len(offsets); i += 4 {
+		offset := uint64(binary.LittleEndian.Uint32(offsets[i:]))
+		if (i == 0 && offset != prev) || offset < prev || (offset-prev)%DirectoryEntrySize != 0 {
+			return corrupt("directory offset %d of node %d is invalid", offset, h.Start+uint64(i/4))
+		}
+		prev = offset
+	}
+	return nil
+}
+
+// LabelRange finds the edges with the label among the edges of a node
+// from sectionStart till sectionEnd with the directory of the node. The
+// edges are from the returned start till the returned end, exclusive,
+// which are equal if the node has no such edges.
+func LabelRange(entries []DirectoryEntry, label, sectionStart, sectionEnd uint32) (uint32, uint32, error) {
+	for _, e := range entries {
+		if e.Start < sectionStart || e.Start >= sectionEnd || e.Label != label {
+			continue
+		}
+		end := uint64(e.Start) + uint64(e.Count)*8
+		if e.Count == 0 || (e.Start-sectionStart)%8 != 0 || end > uint64(sectionEnd) {
+			return 0, 0, corrupt("directory entry of label %d points outside of the edges of the node", label)
+		}
+		return e.Start, uint32(end), nil
+	}
+	return sectionStart, sectionStart, nil
+}
+
+// appendDirectory appends the entries for the edges of one direction of a
+// node, which are sorted by label and start at the byte offset start.
+func appendDirectory(dst []byte, edges []Edge, start uint32) []byte {
+	for i := 0; i < len(edges); {
+		j := i
+		for j < len(edges) && edges[j].Label == edges[i].Label {
+			j++
+		}
+		dst = binary.LittleEndian.AppendUint32(dst, edges[i].Label)
+		dst = binary.LittleEndian.AppendUint32(dst, start+uint32(8*i))
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(j-i))
+		i = j
+	}
+	return dst
+}
+
+// validateDirectories checks the directory table and the directories that
+// follow the edges of the file. Every entry has to point at all the
+// edges of its label in the direction.
+func (h *Header) validateDirectories(data []byte, offsetTable []byte) error {
+	tableStart := h.DirectoryTableStart()
+	if uint64(len(data)) < tableStart+h.DirectoryTableSize() {
+		return corrupt("directory table is cut off")
+	}
+	table := data[tableStart : tableStart+h.DirectoryTableSize()]
+	if err := h.ValidateDirectoryTable(table); err != nil {
+		return err
+	}
+	dirStart := tableStart + h.DirectoryTableSize()
+	dirEnd := uint64(binary.LittleEndian.Uint32(table[4*h.NumNodes():]))
+	if uint64(len(data)) != dirEnd+4 {
+		return corrupt("file is %d bytes instead of %d", len(data), dirEnd+4)
+	}
+	if checksum(data[dirStart:dirEnd]) != binary.LittleEndian.Uint32(data[dirEnd:]) {
+		return corrupt("label directories checksum mismatch")
+	}
+	edgesEnd := uint32(tableStart)
+	for i := uint64(0); i < h.NumNodes(); i++ {
+		from := binary.LittleEndian.Uint32(table[4*i:])
+		to := binary.LittleEndian.Uint32(table[4*i+4:])
+		entries, err := ParseDirectory(data[from:to])
+		if err != nil {
+			return err
+		}
+		out := binary.LittleEndian.Uint32(offsetTable[8*i:])
+		in := binary.LittleEndian.Uint32(offsetTable[8*i+4:])
+		end := edgesEnd
+		if i+1 < h.NumNodes() {
+			end = binary.LittleEndian.Uint32(offsetTable[8*i+8:])
+		}
+		//The entries are sorted by their position and do not overlap.
+		prev, counted := out, uint64(0)
+		for _, e := range entries {
+			sectionEnd := in
+			if e.Start >= in {
+				sectionEnd = end
+			}
+			stop := uint64(e.Start) + uint64(e.Count)*8
+			if e.Count == 0 || e.Start < prev || (e.Start-out)%8 != 0 || stop > uint64(sectionEnd) {
+				return corrupt("directory entry of label %d of node %d is invalid", e.Label, h.Start+i)
+			}
+			for pos := uint64(e.Start); pos < stop; pos += 8 {
+				if binary.LittleEndian.Uint32(data[pos:]) != e.Label {
+					return corrupt("directory entry of label %d of node %d points at other labels",
+						e.Label, h.Start+i)
+				}
+			}
+			prev = uint32(stop)
+			counted += uint64(e.Count)
+		}
+		if len(entries) > 0 && counted != uint64(end-out)/8 {
+			return corrupt("label directory of node %d does not cover all of its edges", h.Start+i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package csrfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelDirectory(t *testing.T) {
+	file := mustEncode(t, 10, testNodes(), EncodeOptions{Flags: LabelDirectory, DirectoryMinEdges: 2})
+	h, err := ParseHeader(file)
+	assert.Nil(t, err)
+	assert.True(t, h.HasDirectory())
+	assert.Equal(t, LayoutByteOffsets, h.Layout())
+	table := file[h.DirectoryTableStart() : h.DirectoryTableStart()+h.DirectoryTableSize()]
+	assert.Nil(t, h.ValidateDirectoryTable(table))
+
+	//The first node has a directory, the second one is too small.
+	offsets := file[h.OffsetTableStart():h.EdgesStart()]
+	from, to := binary.LittleEndian.Uint32(table), binary.LittleEndian.Uint32(table[4:])
+	entries, err := ParseDirectory(file[from:to])
+	assert.Nil(t, err)
+	out, in := binary.LittleEndian.Uint32(offsets), binary.LittleEndian.Uint32(offsets[4:])
+	start, end, err := LabelRange(entries, 1, out, in)
+	assert.Nil(t, err)
+	assert.Equal(t, out, start)
+	assert.Equal(t, out+16, end)
+	start, end, err = LabelRange(entries, 5, out, in)
+	assert.Nil(t, err)
+	assert.Equal(t, start, end)
+	assert.Equal(t, binary.LittleEndian.Uint32(table[8:]), binary.LittleEndian.Uint32(table[4:]))
+}
+
+func TestCorruptLabelDirectory(t *testing.T) {
+	file := mustEncode(t, 10, testNodes(), EncodeOptions{Flags: LabelDirectory})
+	h, err := ParseHeader(file)
+	assert.Nil(t, err)
+	//Point the first entry at the edges of the next label while keeping
+	//the checksum valid.
+	table := file[h.DirectoryTableStart() : h.DirectoryTableStart()+h.DirectoryTableSize()]
+	dirStart := binary.LittleEndian.Uint32(table)
+	dirEnd := binary.LittleEndian.Uint32(table[4*h.NumNodes():])
+	binary.LittleEndian.PutUint32(file[dirStart+4:], binary.LittleEndian.Uint32(file[dirStart+4:])+8)
+	binary.LittleEndian.PutUint32(file[dirEnd:], checksum(file[dirStart:dirEnd]))
+	_, _, err = Decode(file)
+	assert.True(t, errors.Is(err, ErrCorrupt))
+
+	table[0] ^= 1
+	assert.True(t, errors.Is(h.ValidateDirectoryTable(table), ErrCorrupt))
+
+	compressed := Header{Version: CurrentVersion, IDWidth: 4, HeaderSize: HeaderSize,
+		Flags: ByteOffsets | Varint | LabelDirectory}
+	_, err = ParseHeader(compressed.Encode())
+	assert.True(t, errors.Is(err, ErrCorrupt))
+}
@@ -0,0 +1,429 @@
+package csrfile
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Edge is a (label, destination) pair. Destinations only take more than 32
+// bits in files with 8 byte node IDs.
+type Edge struct {
+	Label uint32
+	Dest  uint64
+}
+
+// Node holds the outgoing and incoming edges of a single node.
+type Node struct {
+	Out, In []Edge
+}
+
+func compareEdges(a, b Edge) int {
+	if c := cmp.Compare(a.Label, b.Label); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Dest, b.Dest)
+}
+
+// EncodeOptions control the layout of the files written by Encode.
+type EncodeOptions struct {
+	Flags Flags
+	// DirectoryMinEdges is the number of edges a node needs for its label
+	// directory to be written when the LabelDirectory flag is set. Smaller
+	// nodes are read with a single GET.
+	DirectoryMinEdges int
+	// BlockSize is the uncompressed size of the blocks of files with the
+	// Zstd flag, it has to be a multiple of the edge size. DefaultBlockSize
+	// is used if it is 0.
+	BlockSize int
+	// IDWidth is the size of node IDs and offsets, either 4 or 8 bytes. 4
+	// is used if it is 0. Files with 8 byte IDs can only have the byte
+	// offsets layout.
+	IDWidth uint8
+}
+
+// Encode returns a versioned file with the nodes starting at start in the
+// layout of the options. The edges of every node are sorted in place, by
+// label for the layouts with fixed size edges and by label and
+// destination for the varint one. An error is returned if there are no
+// nodes, if the options are invalid or if a node or a destination does
+// not fit in the ID width.
+func Encode(start uint64, nodes []Node, opts EncodeOptions) ([]byte, error) {
+	if len(nodes) == 0 {
+		return nil, errors.New("A file should have at least one node")
+	}
+	if opts.IDWidth == 0 {
+		opts.IDWidth = 4
+	}
+	if opts.IDWidth != 4 && opts.IDWidth != 8 {
+		return nil, fmt.Errorf("Node IDs should be 4 or 8 bytes, not %d", opts.IDWidth)
+	}
+	if opts.IDWidth == 8 && opts.Flags != ByteOffsets {
+		return nil, errors.New("8 byte node IDs are only supported in the byte offsets layout")
+	}
+	if err := checkIDWidth(start, nodes, opts.IDWidth); err != nil {
+		return nil, err
+	}
+	h := Header{
+		IDWidth:    opts.IDWidth,
+		Flags:      opts.Flags,
+		HeaderSize: HeaderSize,
+		Start:      start,
+		End:        start + uint64(len(nodes)) - 1,
+	}
+	if h.Layout() == LayoutVarint || h.HasDirectory() {
+		h.Flags |= ByteOffsets
+	}
+	blockSize := opts.BlockSize
+	if blockSize == 0 {
+		blockSize = DefaultBlockSize
+	}
+	if h.Layout() == LayoutZstd && (blockSize < 0 || uint64(blockSize)%h.EdgeSize() != 0) {
+		return nil, fmt.Errorf("Block size should be a positive multiple of the edge size, it is %d", blockSize)
+	}
+	offsets := make([]byte, 0, h.OffsetTableSize())
+	var edges, directories []byte
+	directoryOffsets := make([]uint32, 0, len(nodes)+1)
+	//Position of the next edge, in bytes for byte offsets and in edges
+	//otherwise.
+	position := func() uint64 {
+		if h.Flags&ByteOffsets != 0 {
+			return h.EdgesStart() + uint64(len(edges))
+		}
+		return uint64(len(edges)) / h.EdgeSize()
+	}
+	appendSection := func(section []Edge, withDirectory bool) {
+		offsets = h.AppendID(offsets, position())
+		if h.Layout() == LayoutVarint {
+			slices.SortFunc(section, compareEdges)
+			edges = AppendVarintSection(edges, section)
+			return
+		}
+		slices.SortStableFunc(section, func(a, b Edge) int {
+			return cmp.Compare(a.Label, b.Label)
+		})
+		if withDirectory {
+			directories = appendDirectory(directories, section, uint32(position()))
+		}
+		for _, e := range section {
+			edges = binary.LittleEndian.AppendUint32(edges, e.Label)
+			edges = h.AppendID(edges, e.Dest)
+		}
+	}
+	for _, n := range nodes {
+		directoryOffsets = append(directoryOffsets, uint32(len(directories)))
+		withDirectory := h.HasDirectory() && len(n.Out)+len(n.In) >= opts.DirectoryMinEdges
+		appendSection(n.Out, withDirectory)
+		appendSection(n.In, withDirectory)
+		h.NumEdges += uint64(len(n.Out) + len(n.In))
+	}
+	if h.Layout() == LayoutZstd {
+		edges = appendBlocks(nil, edges, blockSize, h.EdgesStart())
+	}
+	file := Assemble(h, offsets, edges)
+	if !h.HasDirectory() {
+		return file, nil
+	}
+	directoryOffsets = append(directoryOffsets, uint32(len(directories)))
+	//The directories start after the edges and the table.
+	dirStart := uint32(len(file)) + uint32(h.DirectoryTableSize())
+	table := make([]byte, 0, h.DirectoryTableSize())
+	for _, offset := range directoryOffsets {
+		table = binary.LittleEndian.AppendUint32(table, dirStart+offset)
+	}
+	table = binary.LittleEndian.AppendUint32(table, checksum(table))
+	file = append(file, table...)
+	file = append(file, directories...)
+	return binary.LittleEndian.AppendUint32(file, checksum(directories)), nil
+}
+
+// checkIDWidth checks that the nodes and their destinations fit in 4 byte
+// node IDs if idWidth is 4.
+func checkIDWidth(start uint64, nodes []Node, idWidth uint8) error {
+	if idWidth != 4 {
+		return nil
+	}
+	if end := start + uint64(len(nodes)) - 1; end > math.MaxUint32 {
+		return fmt.Errorf("Node %d does not fit in 4 byte node IDs", end)
+	}
+	for i, n := range nodes {
+		for _, section := range [][]Edge{n.Out, n.In} {
+			for _, e := range section {
+				if e.Dest > math.MaxUint32 {
+					return fmt.Errorf("Destination %d of node %d does not fit in 4 byte node IDs",
+						e.Dest, start+uint64(i))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Decode validates a file in any of the layouts, legacy files included,
+// and returns its header and nodes.
+func Decode(data []byte) (Header, []Node, error) {
+	h, err := ParseHeader(data)
+	if err != nil {
+		return h, nil, err
+	}
+	if uint64(len(data)) < h.EdgesStart() {
+		return h, nil, corrupt("file is only %d bytes long but its edges start at %d", len(data), h.EdgesStart())
+	}
+	offsetTable := data[h.OffsetTableStart():h.EdgesStart()]
+	edges := data[h.EdgesStart():]
+	if h.HasDirectory() {
+		if uint64(len(data)) < h.DirectoryTableStart() {
+			return h, nil, corrupt("file is only %d bytes long but its edges end at %d",
+				len(data), h.DirectoryTableStart())
+		}
+		edges = data[h.EdgesStart():h.DirectoryTableStart()]
+	}
+	if err = h.InferLayout(offsetTable); err != nil {
+		return h, nil, err
+	}
+	if err = h.ValidateEdges(edges); err != nil {
+		return h, nil, err
+	}
+	if h.Layout() == LayoutZstd {
+		if edges, err = h.decompressEdges(edges); err != nil {
+			return h, nil, err
+		}
+	}
+	if err = h.ValidateOffsets(offsetTable); err != nil {
+		return h, nil, err
+	}
+	if h.HasDirectory() {
+		if err = h.validateDirectories(data, offsetTable); err != nil {
+			return h, nil, err
+		}
+	}
+	//Positions of the sections relative to the start of the edges, in
+	//bytes. The last one is the end of the edges.
+	positions := make([]uint64, 0, len(offsetTable)/int(h.IDWidth)+1)
+	for i := 0; i < len(offsetTable); i += int(h.IDWidth) {
+		offset := h.ReadID(offsetTable[i:])
+		if h.Flags&ByteOffsets != 0 {
+			positions = append(positions, offset-h.EdgesStart())
+		} else {
+			positions = append(positions, offset*h.EdgeSize())
+		}
+	}
+	positions = append(positions, uint64(len(edges)))
+	nodes := make([]Node, h.NumNodes())
+	var numEdges uint64
+	for i := range nodes {
+		sections := [2][]Edge{}
+		for j := range sections {
+			from, to := positions[2*i+j], positions[2*i+j+1]
+			if from > to || to > uint64(len(edges)) {
+				return h, nil, corrupt("edges of node %d are out of range", h.Start+uint64(i))
+			}
+			if sections[j], err = h.decodeSection(edges[from:to]); err != nil {
+				return h, nil, err
+			}
+			numEdges += uint64(len(sections[j]))
+		}
+		nodes[i] = Node{Out: sections[0], In: sections[1]}
+	}
+	if numEdges != h.NumEdges {
+		return h, nil, corrupt("file has %d edges instead of %d", numEdges, h.NumEdges)
+	}
+	return h, nodes, nil
+}
+
+func (h *Header) decodeSection(section []byte) ([]Edge, error) {
+	if h.Layout() == LayoutVarint {
+		edges, n, err := DecodeVarintSection(section)
+		if err == nil && n != len(section) {
+			return nil, corrupt("section has %d unexpected trailing bytes", len(section)-n)
+		}
+		return edges, err
+	}
+	if uint64(len(section))%h.EdgeSize() != 0 {
+		return nil, corrupt("section is cut off in the middle of an edge")
+	}
+	edges := make([]Edge, 0, uint64(len(section))/h.EdgeSize())
+	for i := 0; i < len(section); i += int(h.EdgeSize()) {
+		edges = append(edges, Edge{
+			Label: binary.LittleEndian.Uint32(section[i:]),
+			Dest:  h.ReadID(section[i+4:]),
+		})
+	}
+	return edges, nil
+}
+
+// AppendVarintSection appends the compressed edges of one direction of a
+// node to dst. The edges have to be sorted by label and destination.
+//
+// The section starts with the number of labels. Every label is followed
+// by the number of its edges, the size in bytes of their destinations and
+// the destinations. Labels and destinations are stored as the difference
+// to the previous one, the first destination of every label is stored as
+// is. All numbers are unsigned varints.
+func AppendVarintSection(dst []byte, edges []Edge) []byte {
+	var numLabels uint64
+	for i := range edges {
+		if i == 0 || edges[i].Label != edges[i-1].Label {
+			numLabels++
+		}
+	}
+	dst = binary.AppendUvarint(dst, numLabels)
+	var prevLabel uint32
+	var dests []byte
+	for i := 0; i < len(edges); {
+		label := edges[i].Label
+		j := i
+		dests = dests[:0]
+		var prev uint64
+		for ; j < len(edges) && edges[j].Label == label; j++ {
+			dests = binary.AppendUvarint(dests, edges[j].Dest-prev)
+			prev = edges[j].Dest
+		}
+		dst = binary.AppendUvarint(dst, uint64(label-prevLabel))
+		dst = binary.AppendUvarint(dst, uint64(j-i))
+		dst = binary.AppendUvarint(dst, uint64(len(dests)))
+		dst = append(dst, dests...)
+		prevLabel = label
+		i = j
+	}
+	return dst
+}
+
+// varintReader reads the numbers of a compressed section and remembers
+// the first error.
+type varintReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *varintReader) next() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.err = corrupt("invalid varint at byte %d of section", r.pos)
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+// next32 reads a number that has to fit in 32 bits.
+func (r *varintReader) next32() uint32 {
+	v := r.next()
+	if v > 0xFFFFFFFF && r.err == nil {
+		r.err = corrupt("value %d at byte %d of section is too large", v, r.pos)
+	}
+	return uint32(v)
+}
+
+// nextLabel reads the header of the next label and returns the label, the
+// number of its edges and its destinations.
+func (r *varintReader) nextLabel(prevLabel uint32, first bool) (uint32, uint64, []byte) {
+	delta := r.next32()
+	count := r.next()
+	size := r.next()
+	if r.err != nil {
+		return 0, 0, nil
+	}
+	label := prevLabel + delta
+	if label < prevLabel || (!first && delta == 0) {
+		r.err = corrupt("labels of section are not increasing")
+		return 0, 0, nil
+	}
+	//Every destination takes at least one byte.
+	if size > uint64(len(r.data)-r.pos) || count > size {
+		r.err = corrupt("label %d has %d edges in %d bytes but the section has %d bytes left",
+			label, count, size, len(r.data)-r.pos)
+		return 0, 0, nil
+	}
+	dests := r.data[r.pos : r.pos+int(size)]
+	r.pos += int(size)
+	return label, count, dests
+}
+
+func decodeDests(dst []uint32, dests []byte, count uint64) ([]uint32, error) {
+	r := varintReader{data: dests}
+	var prev uint32
+	for i := uint64(0); i < count; i++ {
+		delta := r.next32()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if i > 0 && prev+delta < prev {
+			return nil, corrupt("destinations overflow")
+		}
+		prev += delta
+		dst = append(dst, prev)
+	}
+	if r.pos != len(dests) {
+		return nil, corrupt("destinations have %d unexpected trailing bytes", len(dests)-r.pos)
+	}
+	return dst, nil
+}
+
+// DecodeVarintSection decodes a section written by AppendVarintSection
+// that starts at the beginning of data and returns the edges and the size
+// of the section.
+func DecodeVarintSection(data []byte) ([]Edge, int, error) {
+	r := varintReader{data: data}
+	numLabels := r.next()
+	if r.err != nil {
+		return nil, 0, r.err
+	}
+	//Every label takes at least 3 bytes.
+	if numLabels > uint64(len(data)) {
+		return nil, 0, corrupt("section of %d bytes can not have %d labels", len(data), numLabels)
+	}
+	var edges []Edge
+	var label uint32
+	var dests []uint32
+	for i := uint64(0); i < numLabels; i++ {
+		var count uint64
+		var destBytes []byte
+		label, count, destBytes = r.nextLabel(label, i == 0)
+		if r.err != nil {
+			return nil, 0, r.err
+		}
+		var err error
+		if dests, err = decodeDests(dests[:0], destBytes, count); err != nil {
+			return nil, 0, err
+		}
+		for _, d := range dests {
+			edges = append(edges, Edge{Label: label, Dest: uint64(d)})
+		}
+	}
+	return edges, r.pos, nil
+}
+
+// VarintNeighbours returns the destinations of the edges with the label
+// in a section written by AppendVarintSection. Only the destinations of
+// that label are decoded.
+func VarintNeighbours(section []byte, label uint32) ([]uint32, error) {
+	r := varintReader{data: section}
+	numLabels := r.next()
+	if r.err != nil {
+		return nil, r.err
+	}
+	var current uint32
+	for i := uint64(0); i < numLabels; i++ {
+		var count uint64
+		var dests []byte
+		current, count, dests = r.nextLabel(current, i == 0)
+		if r.err != nil {
+			return nil, r.err
+		}
+		if current == label {
+			return decodeDests(make([]uint32, 0, count), dests, count)
+		}
+		if current > label {
+			break
+		}
+	}
+	return []uint32{}, nil
+}
@@ -0,0 +1,122 @@
+package csrfile
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustEncode(t *testing.T, start uint64, nodes []Node, opts EncodeOptions) []byte {
+	t.Helper()
+	file, err := Encode(start, nodes, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func testNodes() []Node {
+	return []Node{
+		{Out: []Edge{{2, 9}, {1, 300}, {1, 5}}, In: []Edge{{1, 1 << 31}}},
+		{},
+		{In: []Edge{{7, 0}, {7, 0}}},
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, flags := range []Flags{0, ByteOffsets, Varint, LabelDirectory, Zstd} {
+		file := mustEncode(t, 10, testNodes(), EncodeOptions{Flags: flags})
+		h, nodes, err := Decode(file)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(10), h.Start)
+		assert.Equal(t, uint64(12), h.End)
+		assert.Equal(t, uint64(6), h.NumEdges)
+		expected := testNodes()
+		expected[0].Out = []Edge{{1, 5}, {1, 300}, {2, 9}}
+		if flags != Varint {
+			//Only compressed files sort the destinations.
+			expected[0].Out = []Edge{{1, 300}, {1, 5}, {2, 9}}
+		}
+		for i := range nodes {
+			assert.ElementsMatch(t, expected[i].Out, nodes[i].Out)
+			assert.ElementsMatch(t, expected[i].In, nodes[i].In)
+		}
+	}
+	//Legacy files are decoded as well.
+	_, nodes, err := Decode(append(uints(10, 11), append(testIndices, testEdges...)...))
+	assert.Nil(t, err)
+	assert.Equal(t, []Edge{{1, 5}, {2, 6}}, nodes[0].Out)
+	assert.Equal(t, []Edge{{3, 8}}, nodes[1].In)
+}
+
+func TestVarintSection(t *testing.T) {
+	edges := []Edge{{1, 5}, {1, 300}, {2, 9}, {40, 1 << 31}, {40, 0xFFFFFFFF}}
+	section := AppendVarintSection(nil, edges)
+	decoded, n, err := DecodeVarintSection(append(section, 0xFF))
+	assert.Nil(t, err)
+	assert.Equal(t, len(section), n)
+	assert.Equal(t, edges, decoded)
+
+	neighbours, err := VarintNeighbours(section, 40)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{1 << 31, 0xFFFFFFFF}, neighbours)
+	neighbours, err = VarintNeighbours(section, 3)
+	assert.Nil(t, err)
+	assert.Empty(t, neighbours)
+	neighbours, err = VarintNeighbours(AppendVarintSection(nil, nil), 1)
+	assert.Nil(t, err)
+	assert.Empty(t, neighbours)
+
+	for i := 0; i < len(section); i++ {
+		_, _, err = DecodeVarintSection(section[:i])
+		assert.True(t, errors.Is(err, ErrCorrupt), "truncated at %d", i)
+	}
+	//A label that claims more destinations than its bytes hold.
+	_, err = VarintNeighbours([]byte{1, 1, 5, 1, 1}, 1)
+	assert.True(t, errors.Is(err, ErrCorrupt))
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	file := mustEncode(t, 10, testNodes(), EncodeOptions{Flags: Varint})
+	h, err := ParseHeader(file)
+	assert.Nil(t, err)
+	//Keep the checksum valid so that the sections themselves are checked.
+	edges := append([]byte{}, file[h.EdgesStart():]...)
+	edges[len(edges)-1] = 0x80
+	corrupted := Assemble(h, file[h.OffsetTableStart():h.EdgesStart()], edges)
+	_, _, err = Decode(corrupted)
+	assert.True(t, errors.Is(err, ErrCorrupt))
+
+	_, _, err = Decode(file[:len(file)-1])
+	assert.True(t, errors.Is(err, ErrCorrupt))
+}
+
+func TestWideIDs(t *testing.T) {
+	nodes := []Node{
+		{Out: []Edge{{2, 1 << 40}, {1, 5}}, In: []Edge{{1, 1<<33 + 1}}},
+		{In: []Edge{{3, 1 << 33}}},
+	}
+	file := mustEncode(t, 1<<33, nodes, EncodeOptions{Flags: ByteOffsets, IDWidth: 8})
+	h, decoded, err := Decode(file)
+	assert.Nil(t, err)
+	assert.Equal(t, uint8(8), h.IDWidth)
+	assert.Equal(t, uint64(12), h.EdgeSize())
+	assert.Equal(t, uint64(1<<33+1), h.End)
+	assert.Equal(t, []Edge{{1, 5}, {2, 1 << 40}}, decoded[0].Out)
+	assert.Equal(t, []Edge{{3, 1 << 33}}, decoded[1].In)
+	assert.Equal(t, h.EdgesStart(), h.ReadID(file[h.OffsetTableStart():]))
+
+	wide := Header{Version: CurrentVersion, IDWidth: 8, HeaderSize: HeaderSize, Flags: Varint | ByteOffsets}
+	_, err = ParseHeader(wide.Encode())
+	assert.NotNil(t, err)
+	_, err = Encode(0, nodes, EncodeOptions{})
+	assert.NotNil(t, err)
+	_, err = Encode(math.MaxUint32, []Node{{}, {}}, EncodeOptions{})
+	assert.NotNil(t, err)
+	_, err = Encode(0, nodes, EncodeOptions{Flags: Varint, IDWidth: 8})
+	assert.NotNil(t, err)
+	_, err = Encode(0, nil, EncodeOptions{})
+	assert.NotNil(t, err)
+}
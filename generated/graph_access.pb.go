@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.25.0-devel
+// 	protoc-gen-go v1.32.0
 // 	protoc        v3.14.0
 // source: graph_access.proto
 
@@ -236,6 +236,609 @@ func (x *AccessResponse) GetStatus() AccessResponse_ResponseStatus {
 	return AccessResponse_NO_ERROR
 }
 
+// AccessRequest64 is an AccessRequest for graphs whose node ids do not fit
+// in 32 bits, it can be sent for any graph.
+type AccessRequest64 struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	NodeId    uint64                  `protobuf:"varint,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	Label     uint32                  `protobuf:"varint,2,opt,name=label,proto3" json:"label,omitempty"`
+	Direction AccessRequest_Direction `protobuf:"varint,3,opt,name=direction,proto3,enum=graph_access_service.AccessRequest_Direction" json:"direction,omitempty"`
+}
+
+func (x *AccessRequest64) Reset() {
+	*x = AccessRequest64{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_graph_access_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AccessRequest64) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccessRequest64) ProtoMessage() {}
+
+func (x *AccessRequest64) ProtoReflect() protoreflect.Message {
+	mi := &file_graph_access_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccessRequest64.ProtoReflect.Descriptor instead.
+func (*AccessRequest64) Descriptor() ([]byte, []int) {
+	return file_graph_access_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AccessRequest64) GetNodeId() uint64 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *AccessRequest64) GetLabel() uint32 {
+	if x != nil {
+		return x.Label
+	}
+	return 0
+}
+
+func (x *AccessRequest64) GetDirection() AccessRequest_Direction {
+	if x != nil {
+		return x.Direction
+	}
+	return AccessRequest_INCOMING
+}
+
+type AccessResponse64 struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Neighbours []uint64                      `protobuf:"varint,1,rep,packed,name=neighbours,proto3" json:"neighbours,omitempty"`
+	Status     AccessResponse_ResponseStatus `protobuf:"varint,2,opt,name=status,proto3,enum=graph_access_service.AccessResponse_ResponseStatus" json:"status,omitempty"`
+}
+
+func (x *AccessResponse64) Reset() {
+	*x = AccessResponse64{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_graph_access_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AccessResponse64) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccessResponse64) ProtoMessage() {}
+
+func (x *AccessResponse64) ProtoReflect() protoreflect.Message {
+	mi := &file_graph_access_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccessResponse64.ProtoReflect.Descriptor instead.
+func (*AccessResponse64) Descriptor() ([]byte, []int) {
+	return file_graph_access_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AccessResponse64) GetNeighbours() []uint64 {
+	if x != nil {
+		return x.Neighbours
+	}
+	return nil
+}
+
+func (x *AccessResponse64) GetStatus() AccessResponse_ResponseStatus {
+	if x != nil {
+		return x.Status
+	}
+	return AccessResponse_NO_ERROR
+}
+
+// Requests in a batch are answered in the same order in which they were sent.
+type BatchAccessRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Requests []*AccessRequest `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+}
+
+func (x *BatchAccessRequest) Reset() {
+	*x = BatchAccessRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_graph_access_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchAccessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchAccessRequest) ProtoMessage() {}
+
+func (x *BatchAccessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_graph_access_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchAccessRequest.ProtoReflect.Descriptor instead.
+func (*BatchAccessRequest) Descriptor() ([]byte, []int) {
+	return file_graph_access_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchAccessRequest) GetRequests() []*AccessRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+type BatchAccessResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Responses []*AccessResponse `protobuf:"bytes,1,rep,name=responses,proto3" json:"responses,omitempty"` // One response per request, each with its own status
+}
+
+func (x *BatchAccessResponse) Reset() {
+	*x = BatchAccessResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_graph_access_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchAccessResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchAccessResponse) ProtoMessage() {}
+
+func (x *BatchAccessResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_graph_access_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchAccessResponse.ProtoReflect.Descriptor instead.
+func (*BatchAccessResponse) Descriptor() ([]byte, []int) {
+	return file_graph_access_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchAccessResponse) GetResponses() []*AccessResponse {
+	if x != nil {
+		return x.Responses
+	}
+	return nil
+}
+
+// Requests on a stream are tagged with an id chosen by the client. The
+// response for a request carries the same id, responses may arrive in any order.
+type StreamAccessRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId uint64         `protobuf:"varint,1,opt,name=requestId,proto3" json:"requestId,omitempty"`
+	Request   *AccessRequest `protobuf:"bytes,2,opt,name=request,proto3" json:"request,omitempty"`
+}
+
+func (x *StreamAccessRequest) Reset() {
+	*x = StreamAccessRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_graph_access_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamAccessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamAccessRequest) ProtoMessage() {}
+
+func (x *StreamAccessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_graph_access_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamAccessRequest.ProtoReflect.Descriptor instead.
+func (*StreamAccessRequest) Descriptor() ([]byte, []int) {
+	return file_graph_access_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StreamAccessRequest) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *StreamAccessRequest) GetRequest() *AccessRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+type StreamAccessResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId uint64          `protobuf:"varint,1,opt,name=requestId,proto3" json:"requestId,omitempty"`
+	Response  *AccessResponse `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+}
+
+func (x *StreamAccessResponse) Reset() {
+	*x = StreamAccessResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_graph_access_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamAccessResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamAccessResponse) ProtoMessage() {}
+
+func (x *StreamAccessResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_graph_access_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamAccessResponse.ProtoReflect.Descriptor instead.
+func (*StreamAccessResponse) Descriptor() ([]byte, []int) {
+	return file_graph_access_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StreamAccessResponse) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *StreamAccessResponse) GetResponse() *AccessResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+type ExpandRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Source     uint32                `protobuf:"varint,1,opt,name=source,proto3" json:"source,omitempty"`
+	Steps      []*ExpandRequest_Step `protobuf:"bytes,2,rep,name=steps,proto3" json:"steps,omitempty"`            // Steps are followed in order starting from the source
+	MaxPerHop  uint32                `protobuf:"varint,3,opt,name=maxPerHop,proto3" json:"maxPerHop,omitempty"`   // Maximum number of nodes kept per hop, 0 for no limit
+	GroupByHop bool                  `protobuf:"varint,4,opt,name=groupByHop,proto3" json:"groupByHop,omitempty"` // Return the nodes reached by every step instead of just the last one
+}
+
+func (x *ExpandRequest) Reset() {
+	*x = ExpandRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_graph_access_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExpandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpandRequest) ProtoMessage() {}
+
+func (x *ExpandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_graph_access_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpandRequest.ProtoReflect.Descriptor instead.
+func (*ExpandRequest) Descriptor() ([]byte, []int) {
+	return file_graph_access_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ExpandRequest) GetSource() uint32 {
+	if x != nil {
+		return x.Source
+	}
+	return 0
+}
+
+func (x *ExpandRequest) GetSteps() []*ExpandRequest_Step {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+func (x *ExpandRequest) GetMaxPerHop() uint32 {
+	if x != nil {
+		return x.MaxPerHop
+	}
+	return 0
+}
+
+func (x *ExpandRequest) GetGroupByHop() bool {
+	if x != nil {
+		return x.GroupByHop
+	}
+	return false
+}
+
+type ExpandResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Nodes     []uint32                      `protobuf:"varint,1,rep,packed,name=nodes,proto3" json:"nodes,omitempty"`  // Nodes reached by the last step
+	Hops      []*ExpandResponse_Hop         `protobuf:"bytes,2,rep,name=hops,proto3" json:"hops,omitempty"`            // Nodes reached by every step if groupByHop was set
+	Truncated bool                          `protobuf:"varint,3,opt,name=truncated,proto3" json:"truncated,omitempty"` // Set if some hop was cut down to maxPerHop
+	Status    AccessResponse_ResponseStatus `protobuf:"varint,4,opt,name=status,proto3,enum=graph_access_service.AccessResponse_ResponseStatus" json:"status,omitempty"`
+}
+
+func (x *ExpandResponse) Reset() {
+	*x = ExpandResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_graph_access_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExpandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpandResponse) ProtoMessage() {}
+
+func (x *ExpandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_graph_access_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpandResponse.ProtoReflect.Descriptor instead.
+func (*ExpandResponse) Descriptor() ([]byte, []int) {
+	return file_graph_access_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ExpandResponse) GetNodes() []uint32 {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *ExpandResponse) GetHops() []*ExpandResponse_Hop {
+	if x != nil {
+		return x.Hops
+	}
+	return nil
+}
+
+func (x *ExpandResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *ExpandResponse) GetStatus() AccessResponse_ResponseStatus {
+	if x != nil {
+		return x.Status
+	}
+	return AccessResponse_NO_ERROR
+}
+
+type TraverseRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Seeds     []uint32                `protobuf:"varint,1,rep,packed,name=seeds,proto3" json:"seeds,omitempty"`   // Nodes from which the search starts
+	MaxDepth  uint32                  `protobuf:"varint,2,opt,name=maxDepth,proto3" json:"maxDepth,omitempty"`    // Maximum number of hops from a seed
+	Labels    []uint32                `protobuf:"varint,3,rep,packed,name=labels,proto3" json:"labels,omitempty"` // Edge labels that can be followed
+	Direction AccessRequest_Direction `protobuf:"varint,4,opt,name=direction,proto3,enum=graph_access_service.AccessRequest_Direction" json:"direction,omitempty"`
+}
+
+func (x *TraverseRequest) Reset() {
+	*x = TraverseRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_graph_access_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TraverseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraverseRequest) ProtoMessage() {}
+
+func (x *TraverseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_graph_access_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraverseRequest.ProtoReflect.Descriptor instead.
+func (*TraverseRequest) Descriptor() ([]byte, []int) {
+	return file_graph_access_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TraverseRequest) GetSeeds() []uint32 {
+	if x != nil {
+		return x.Seeds
+	}
+	return nil
+}
+
+func (x *TraverseRequest) GetMaxDepth() uint32 {
+	if x != nil {
+		return x.MaxDepth
+	}
+	return 0
+}
+
+func (x *TraverseRequest) GetLabels() []uint32 {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *TraverseRequest) GetDirection() AccessRequest_Direction {
+	if x != nil {
+		return x.Direction
+	}
+	return AccessRequest_INCOMING
+}
+
+type TraverseResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Node   uint32 `protobuf:"varint,1,opt,name=node,proto3" json:"node,omitempty"`
+	Depth  uint32 `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"`   // Number of hops from the closest seed
+	Parent uint32 `protobuf:"varint,3,opt,name=parent,proto3" json:"parent,omitempty"` // Node from which this node was reached, seeds are their own parents
+}
+
+func (x *TraverseResponse) Reset() {
+	*x = TraverseResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_graph_access_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TraverseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraverseResponse) ProtoMessage() {}
+
+func (x *TraverseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_graph_access_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraverseResponse.ProtoReflect.Descriptor instead.
+func (*TraverseResponse) Descriptor() ([]byte, []int) {
+	return file_graph_access_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TraverseResponse) GetNode() uint32 {
+	if x != nil {
+		return x.Node
+	}
+	return 0
+}
+
+func (x *TraverseResponse) GetDepth() uint32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *TraverseResponse) GetParent() uint32 {
+	if x != nil {
+		return x.Parent
+	}
+	return 0
+}
+
 type Stats struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -247,7 +850,7 @@ type Stats struct {
 func (x *Stats) Reset() {
 	*x = Stats{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_graph_access_proto_msgTypes[2]
+		mi := &file_graph_access_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -260,7 +863,7 @@ func (x *Stats) String() string {
 func (*Stats) ProtoMessage() {}
 
 func (x *Stats) ProtoReflect() protoreflect.Message {
-	mi := &file_graph_access_proto_msgTypes[2]
+	mi := &file_graph_access_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -273,7 +876,7 @@ func (x *Stats) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Stats.ProtoReflect.Descriptor instead.
 func (*Stats) Descriptor() ([]byte, []int) {
-	return file_graph_access_proto_rawDescGZIP(), []int{2}
+	return file_graph_access_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *Stats) GetStats() string {
@@ -292,7 +895,7 @@ type StatsRequest struct {
 func (x *StatsRequest) Reset() {
 	*x = StatsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_graph_access_proto_msgTypes[3]
+		mi := &file_graph_access_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -305,7 +908,7 @@ func (x *StatsRequest) String() string {
 func (*StatsRequest) ProtoMessage() {}
 
 func (x *StatsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_graph_access_proto_msgTypes[3]
+	mi := &file_graph_access_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -318,7 +921,109 @@ func (x *StatsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
 func (*StatsRequest) Descriptor() ([]byte, []int) {
-	return file_graph_access_proto_rawDescGZIP(), []int{3}
+	return file_graph_access_proto_rawDescGZIP(), []int{13}
+}
+
+type ExpandRequest_Step struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Label     uint32                  `protobuf:"varint,1,opt,name=label,proto3" json:"label,omitempty"`
+	Direction AccessRequest_Direction `protobuf:"varint,2,opt,name=direction,proto3,enum=graph_access_service.AccessRequest_Direction" json:"direction,omitempty"`
+}
+
+func (x *ExpandRequest_Step) Reset() {
+	*x = ExpandRequest_Step{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_graph_access_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExpandRequest_Step) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpandRequest_Step) ProtoMessage() {}
+
+func (x *ExpandRequest_Step) ProtoReflect() protoreflect.Message {
+	mi := &file_graph_access_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpandRequest_Step.ProtoReflect.Descriptor instead.
+func (*ExpandRequest_Step) Descriptor() ([]byte, []int) {
+	return file_graph_access_proto_rawDescGZIP(), []int{8, 0}
+}
+
+func (x *ExpandRequest_Step) GetLabel() uint32 {
+	if x != nil {
+		return x.Label
+	}
+	return 0
+}
+
+func (x *ExpandRequest_Step) GetDirection() AccessRequest_Direction {
+	if x != nil {
+		return x.Direction
+	}
+	return AccessRequest_INCOMING
+}
+
+type ExpandResponse_Hop struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Nodes []uint32 `protobuf:"varint,1,rep,packed,name=nodes,proto3" json:"nodes,omitempty"`
+}
+
+func (x *ExpandResponse_Hop) Reset() {
+	*x = ExpandResponse_Hop{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_graph_access_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExpandResponse_Hop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpandResponse_Hop) ProtoMessage() {}
+
+func (x *ExpandResponse_Hop) ProtoReflect() protoreflect.Message {
+	mi := &file_graph_access_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpandResponse_Hop.ProtoReflect.Descriptor instead.
+func (*ExpandResponse_Hop) Descriptor() ([]byte, []int) {
+	return file_graph_access_proto_rawDescGZIP(), []int{9, 0}
+}
+
+func (x *ExpandResponse_Hop) GetNodes() []uint32 {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
 }
 
 var File_graph_access_proto protoreflect.FileDescriptor
@@ -350,26 +1055,149 @@ var file_graph_access_proto_rawDesc = []byte{
 	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0c, 0x0a, 0x08,
 	0x4e, 0x4f, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e,
 	0x53, 0x55, 0x50, 0x50, 0x4f, 0x52, 0x54, 0x45, 0x44, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c, 0x53,
-	0x45, 0x52, 0x56, 0x45, 0x52, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x02, 0x22, 0x1d, 0x0a,
-	0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x73, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x73, 0x22, 0x0e, 0x0a, 0x0c,
-	0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x32, 0xba, 0x01, 0x0a,
-	0x0b, 0x47, 0x72, 0x61, 0x70, 0x68, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x5c, 0x0a, 0x0d,
-	0x47, 0x65, 0x74, 0x4e, 0x65, 0x69, 0x67, 0x68, 0x62, 0x6f, 0x75, 0x72, 0x73, 0x12, 0x23, 0x2e,
-	0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65, 0x72,
-	0x76, 0x69, 0x63, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x24, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73,
+	0x45, 0x52, 0x56, 0x45, 0x52, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x02, 0x22, 0x8c, 0x01,
+	0x0a, 0x0f, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x36,
+	0x34, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x06, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x61, 0x62,
+	0x65, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x12,
+	0x4b, 0x0a, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x2d, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73,
 	0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x4d, 0x0a, 0x08, 0x47, 0x65,
-	0x74, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x22, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61,
-	0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x53, 0x74,
-	0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x67, 0x72, 0x61,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x7f, 0x0a, 0x10,
+	0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x36, 0x34,
+	0x12, 0x1e, 0x0a, 0x0a, 0x6e, 0x65, 0x69, 0x67, 0x68, 0x62, 0x6f, 0x75, 0x72, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x04, 0x52, 0x0a, 0x6e, 0x65, 0x69, 0x67, 0x68, 0x62, 0x6f, 0x75, 0x72, 0x73,
+	0x12, 0x4b, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x33, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f,
+	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x55, 0x0a,
+	0x12, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x3f, 0x0a, 0x08, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63,
+	0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x41, 0x63, 0x63,
+	0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x08, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x73, 0x22, 0x59, 0x0a, 0x13, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x63, 0x63,
+	0x65, 0x73, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x42, 0x0a, 0x09, 0x72,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x24,
+	0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x52, 0x09, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x73, 0x22,
+	0x72, 0x0a, 0x13, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x49, 0x64, 0x12, 0x3d, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63,
+	0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x41, 0x63, 0x63,
+	0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x76, 0x0a, 0x14, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x41, 0x63, 0x63,
+	0x65, 0x73, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x40, 0x0a, 0x08, 0x72, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x67, 0x72,
+	0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x90, 0x02, 0x0a, 0x0d,
+	0x45, 0x78, 0x70, 0x61, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x3e, 0x0a, 0x05, 0x73, 0x74, 0x65, 0x70, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63,
+	0x65, 0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x45, 0x78, 0x70, 0x61,
+	0x6e, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x53, 0x74, 0x65, 0x70, 0x52, 0x05,
+	0x73, 0x74, 0x65, 0x70, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x50, 0x65, 0x72, 0x48,
+	0x6f, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x6d, 0x61, 0x78, 0x50, 0x65, 0x72,
+	0x48, 0x6f, 0x70, 0x12, 0x1e, 0x0a, 0x0a, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x42, 0x79, 0x48, 0x6f,
+	0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x42, 0x79,
+	0x48, 0x6f, 0x70, 0x1a, 0x69, 0x0a, 0x04, 0x53, 0x74, 0x65, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x6c,
+	0x61, 0x62, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x6c, 0x61, 0x62, 0x65,
+	0x6c, 0x12, 0x4b, 0x0a, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x2d, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63,
+	0x65, 0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65,
+	0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xec,
+	0x01, 0x0a, 0x0e, 0x45, 0x78, 0x70, 0x61, 0x6e, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0d,
+	0x52, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x3c, 0x0a, 0x04, 0x68, 0x6f, 0x70, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63,
+	0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x45, 0x78, 0x70,
+	0x61, 0x6e, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x48, 0x6f, 0x70, 0x52,
+	0x04, 0x68, 0x6f, 0x70, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74,
+	0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x74, 0x72, 0x75, 0x6e, 0x63, 0x61,
+	0x74, 0x65, 0x64, 0x12, 0x4b, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x33, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65,
+	0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x1a, 0x1b, 0x0a, 0x03, 0x48, 0x6f, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x22, 0xa8, 0x01,
+	0x0a, 0x0f, 0x54, 0x72, 0x61, 0x76, 0x65, 0x72, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x65, 0x65, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0d,
+	0x52, 0x05, 0x73, 0x65, 0x65, 0x64, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x6d, 0x61, 0x78, 0x44, 0x65,
+	0x70, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x44, 0x65,
+	0x70, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x0d, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12, 0x4b, 0x0a, 0x09, 0x64,
+	0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x2d,
+	0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x64,
+	0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x54, 0x0a, 0x10, 0x54, 0x72, 0x61, 0x76,
+	0x65, 0x72, 0x73, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x6e, 0x6f, 0x64, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x64, 0x65, 0x70, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x05, 0x64, 0x65, 0x70, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x22, 0x1d,
+	0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x73, 0x22, 0x0e, 0x0a,
+	0x0c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x32, 0xb5, 0x05,
+	0x0a, 0x0b, 0x47, 0x72, 0x61, 0x70, 0x68, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x5c, 0x0a,
+	0x0d, 0x47, 0x65, 0x74, 0x4e, 0x65, 0x69, 0x67, 0x68, 0x62, 0x6f, 0x75, 0x72, 0x73, 0x12, 0x23,
+	0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65,
+	0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x62, 0x0a, 0x0f, 0x47,
+	0x65, 0x74, 0x4e, 0x65, 0x69, 0x67, 0x68, 0x62, 0x6f, 0x75, 0x72, 0x73, 0x36, 0x34, 0x12, 0x25,
+	0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x36, 0x34, 0x1a, 0x26, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63,
+	0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x41, 0x63, 0x63,
+	0x65, 0x73, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x36, 0x34, 0x22, 0x00, 0x12,
+	0x6b, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x4e, 0x65, 0x69, 0x67, 0x68, 0x62, 0x6f, 0x75, 0x72, 0x73,
+	0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x28, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63,
+	0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x42, 0x61, 0x74,
+	0x63, 0x68, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x29, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x63, 0x63, 0x65,
+	0x73, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x72, 0x0a, 0x13,
+	0x47, 0x65, 0x74, 0x4e, 0x65, 0x69, 0x67, 0x68, 0x62, 0x6f, 0x75, 0x72, 0x73, 0x53, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x12, 0x29, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65,
+	0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2a,
+	0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x41, 0x63, 0x63, 0x65,
+	0x73, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01,
+	0x12, 0x55, 0x0a, 0x06, 0x45, 0x78, 0x70, 0x61, 0x6e, 0x64, 0x12, 0x23, 0x2e, 0x67, 0x72, 0x61,
 	0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
-	0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x22, 0x00, 0x42, 0x39, 0x5a, 0x37, 0x67, 0x69, 0x74,
-	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x64, 0x69, 0x74, 0x79, 0x61, 0x63, 0x68,
-	0x61, 0x6e, 0x64, 0x6c, 0x61, 0x2f, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65,
-	0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x67, 0x65, 0x6e, 0x65, 0x72,
-	0x61, 0x74, 0x65, 0x64, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x2e, 0x45, 0x78, 0x70, 0x61, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x24, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x45, 0x78, 0x70, 0x61, 0x6e, 0x64, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x5d, 0x0a, 0x08, 0x54, 0x72, 0x61, 0x76, 0x65,
+	0x72, 0x73, 0x65, 0x12, 0x25, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65,
+	0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x54, 0x72, 0x61, 0x76, 0x65,
+	0x72, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x67, 0x72, 0x61,
+	0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x2e, 0x54, 0x72, 0x61, 0x76, 0x65, 0x72, 0x73, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x12, 0x4d, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x53, 0x74, 0x61,
+	0x74, 0x73, 0x12, 0x22, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73,
+	0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61,
+	0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x53, 0x74,
+	0x61, 0x74, 0x73, 0x22, 0x00, 0x42, 0x39, 0x5a, 0x37, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
+	0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x64, 0x69, 0x74, 0x79, 0x61, 0x63, 0x68, 0x61, 0x6e, 0x64, 0x6c,
+	0x61, 0x2f, 0x67, 0x72, 0x61, 0x70, 0x68, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x64,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -385,27 +1213,60 @@ func file_graph_access_proto_rawDescGZIP() []byte {
 }
 
 var file_graph_access_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_graph_access_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_graph_access_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
 var file_graph_access_proto_goTypes = []interface{}{
 	(AccessRequest_Direction)(0),       // 0: graph_access_service.AccessRequest.Direction
 	(AccessResponse_ResponseStatus)(0), // 1: graph_access_service.AccessResponse.ResponseStatus
 	(*AccessRequest)(nil),              // 2: graph_access_service.AccessRequest
 	(*AccessResponse)(nil),             // 3: graph_access_service.AccessResponse
-	(*Stats)(nil),                      // 4: graph_access_service.Stats
-	(*StatsRequest)(nil),               // 5: graph_access_service.StatsRequest
+	(*AccessRequest64)(nil),            // 4: graph_access_service.AccessRequest64
+	(*AccessResponse64)(nil),           // 5: graph_access_service.AccessResponse64
+	(*BatchAccessRequest)(nil),         // 6: graph_access_service.BatchAccessRequest
+	(*BatchAccessResponse)(nil),        // 7: graph_access_service.BatchAccessResponse
+	(*StreamAccessRequest)(nil),        // 8: graph_access_service.StreamAccessRequest
+	(*StreamAccessResponse)(nil),       // 9: graph_access_service.StreamAccessResponse
+	(*ExpandRequest)(nil),              // 10: graph_access_service.ExpandRequest
+	(*ExpandResponse)(nil),             // 11: graph_access_service.ExpandResponse
+	(*TraverseRequest)(nil),            // 12: graph_access_service.TraverseRequest
+	(*TraverseResponse)(nil),           // 13: graph_access_service.TraverseResponse
+	(*Stats)(nil),                      // 14: graph_access_service.Stats
+	(*StatsRequest)(nil),               // 15: graph_access_service.StatsRequest
+	(*ExpandRequest_Step)(nil),         // 16: graph_access_service.ExpandRequest.Step
+	(*ExpandResponse_Hop)(nil),         // 17: graph_access_service.ExpandResponse.Hop
 }
 var file_graph_access_proto_depIdxs = []int32{
-	0, // 0: graph_access_service.AccessRequest.direction:type_name -> graph_access_service.AccessRequest.Direction
-	1, // 1: graph_access_service.AccessResponse.status:type_name -> graph_access_service.AccessResponse.ResponseStatus
-	2, // 2: graph_access_service.GraphAccess.GetNeighbours:input_type -> graph_access_service.AccessRequest
-	5, // 3: graph_access_service.GraphAccess.GetStats:input_type -> graph_access_service.StatsRequest
-	3, // 4: graph_access_service.GraphAccess.GetNeighbours:output_type -> graph_access_service.AccessResponse
-	4, // 5: graph_access_service.GraphAccess.GetStats:output_type -> graph_access_service.Stats
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	0,  // 0: graph_access_service.AccessRequest.direction:type_name -> graph_access_service.AccessRequest.Direction
+	1,  // 1: graph_access_service.AccessResponse.status:type_name -> graph_access_service.AccessResponse.ResponseStatus
+	0,  // 2: graph_access_service.AccessRequest64.direction:type_name -> graph_access_service.AccessRequest.Direction
+	1,  // 3: graph_access_service.AccessResponse64.status:type_name -> graph_access_service.AccessResponse.ResponseStatus
+	2,  // 4: graph_access_service.BatchAccessRequest.requests:type_name -> graph_access_service.AccessRequest
+	3,  // 5: graph_access_service.BatchAccessResponse.responses:type_name -> graph_access_service.AccessResponse
+	2,  // 6: graph_access_service.StreamAccessRequest.request:type_name -> graph_access_service.AccessRequest
+	3,  // 7: graph_access_service.StreamAccessResponse.response:type_name -> graph_access_service.AccessResponse
+	16, // 8: graph_access_service.ExpandRequest.steps:type_name -> graph_access_service.ExpandRequest.Step
+	17, // 9: graph_access_service.ExpandResponse.hops:type_name -> graph_access_service.ExpandResponse.Hop
+	1,  // 10: graph_access_service.ExpandResponse.status:type_name -> graph_access_service.AccessResponse.ResponseStatus
+	0,  // 11: graph_access_service.TraverseRequest.direction:type_name -> graph_access_service.AccessRequest.Direction
+	0,  // 12: graph_access_service.ExpandRequest.Step.direction:type_name -> graph_access_service.AccessRequest.Direction
+	2,  // 13: graph_access_service.GraphAccess.GetNeighbours:input_type -> graph_access_service.AccessRequest
+	4,  // 14: graph_access_service.GraphAccess.GetNeighbours64:input_type -> graph_access_service.AccessRequest64
+	6,  // 15: graph_access_service.GraphAccess.GetNeighboursBatch:input_type -> graph_access_service.BatchAccessRequest
+	8,  // 16: graph_access_service.GraphAccess.GetNeighboursStream:input_type -> graph_access_service.StreamAccessRequest
+	10, // 17: graph_access_service.GraphAccess.Expand:input_type -> graph_access_service.ExpandRequest
+	12, // 18: graph_access_service.GraphAccess.Traverse:input_type -> graph_access_service.TraverseRequest
+	15, // 19: graph_access_service.GraphAccess.GetStats:input_type -> graph_access_service.StatsRequest
+	3,  // 20: graph_access_service.GraphAccess.GetNeighbours:output_type -> graph_access_service.AccessResponse
+	5,  // 21: graph_access_service.GraphAccess.GetNeighbours64:output_type -> graph_access_service.AccessResponse64
+	7,  // 22: graph_access_service.GraphAccess.GetNeighboursBatch:output_type -> graph_access_service.BatchAccessResponse
+	9,  // 23: graph_access_service.GraphAccess.GetNeighboursStream:output_type -> graph_access_service.StreamAccessResponse
+	11, // 24: graph_access_service.GraphAccess.Expand:output_type -> graph_access_service.ExpandResponse
+	13, // 25: graph_access_service.GraphAccess.Traverse:output_type -> graph_access_service.TraverseResponse
+	14, // 26: graph_access_service.GraphAccess.GetStats:output_type -> graph_access_service.Stats
+	20, // [20:27] is the sub-list for method output_type
+	13, // [13:20] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_graph_access_proto_init() }
@@ -439,7 +1300,7 @@ func file_graph_access_proto_init() {
 			}
 		}
 		file_graph_access_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Stats); i {
+			switch v := v.(*AccessRequest64); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -451,6 +1312,126 @@ func file_graph_access_proto_init() {
 			}
 		}
 		file_graph_access_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AccessResponse64); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_graph_access_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BatchAccessRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_graph_access_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BatchAccessResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_graph_access_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamAccessRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_graph_access_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamAccessResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_graph_access_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExpandRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_graph_access_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExpandResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_graph_access_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TraverseRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_graph_access_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TraverseResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_graph_access_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Stats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_graph_access_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*StatsRequest); i {
 			case 0:
 				return &v.state
@@ -462,6 +1443,30 @@ func file_graph_access_proto_init() {
 				return nil
 			}
 		}
+		file_graph_access_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExpandRequest_Step); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_graph_access_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExpandResponse_Hop); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -469,7 +1474,7 @@ func file_graph_access_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_graph_access_proto_rawDesc,
 			NumEnums:      2,
-			NumMessages:   4,
+			NumMessages:   16,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -1,4 +1,8 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.14.0
+// source: graph_access.proto
 
 package generated
 
@@ -14,11 +18,26 @@ import (
 // Requires gRPC-Go v1.32.0 or later.
 const _ = grpc.SupportPackageIsVersion7
 
+const (
+	GraphAccess_GetNeighbours_FullMethodName       = "/graph_access_service.GraphAccess/GetNeighbours"
+	GraphAccess_GetNeighbours64_FullMethodName     = "/graph_access_service.GraphAccess/GetNeighbours64"
+	GraphAccess_GetNeighboursBatch_FullMethodName  = "/graph_access_service.GraphAccess/GetNeighboursBatch"
+	GraphAccess_GetNeighboursStream_FullMethodName = "/graph_access_service.GraphAccess/GetNeighboursStream"
+	GraphAccess_Expand_FullMethodName              = "/graph_access_service.GraphAccess/Expand"
+	GraphAccess_Traverse_FullMethodName            = "/graph_access_service.GraphAccess/Traverse"
+	GraphAccess_GetStats_FullMethodName            = "/graph_access_service.GraphAccess/GetStats"
+)
+
 // GraphAccessClient is the client API for GraphAccess service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type GraphAccessClient interface {
 	GetNeighbours(ctx context.Context, in *AccessRequest, opts ...grpc.CallOption) (*AccessResponse, error)
+	GetNeighbours64(ctx context.Context, in *AccessRequest64, opts ...grpc.CallOption) (*AccessResponse64, error)
+	GetNeighboursBatch(ctx context.Context, in *BatchAccessRequest, opts ...grpc.CallOption) (*BatchAccessResponse, error)
+	GetNeighboursStream(ctx context.Context, opts ...grpc.CallOption) (GraphAccess_GetNeighboursStreamClient, error)
+	Expand(ctx context.Context, in *ExpandRequest, opts ...grpc.CallOption) (*ExpandResponse, error)
+	Traverse(ctx context.Context, in *TraverseRequest, opts ...grpc.CallOption) (GraphAccess_TraverseClient, error)
 	GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*Stats, error)
 }
 
@@ -32,16 +51,106 @@ func NewGraphAccessClient(cc grpc.ClientConnInterface) GraphAccessClient {
 
 func (c *graphAccessClient) GetNeighbours(ctx context.Context, in *AccessRequest, opts ...grpc.CallOption) (*AccessResponse, error) {
 	out := new(AccessResponse)
-	err := c.cc.Invoke(ctx, "/graph_access_service.GraphAccess/GetNeighbours", in, out, opts...)
+	err := c.cc.Invoke(ctx, GraphAccess_GetNeighbours_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *graphAccessClient) GetNeighbours64(ctx context.Context, in *AccessRequest64, opts ...grpc.CallOption) (*AccessResponse64, error) {
+	out := new(AccessResponse64)
+	err := c.cc.Invoke(ctx, GraphAccess_GetNeighbours64_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *graphAccessClient) GetNeighboursBatch(ctx context.Context, in *BatchAccessRequest, opts ...grpc.CallOption) (*BatchAccessResponse, error) {
+	out := new(BatchAccessResponse)
+	err := c.cc.Invoke(ctx, GraphAccess_GetNeighboursBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *graphAccessClient) GetNeighboursStream(ctx context.Context, opts ...grpc.CallOption) (GraphAccess_GetNeighboursStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &GraphAccess_ServiceDesc.Streams[0], GraphAccess_GetNeighboursStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &graphAccessGetNeighboursStreamClient{stream}
+	return x, nil
+}
+
+type GraphAccess_GetNeighboursStreamClient interface {
+	Send(*StreamAccessRequest) error
+	Recv() (*StreamAccessResponse, error)
+	grpc.ClientStream
+}
+
+type graphAccessGetNeighboursStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *graphAccessGetNeighboursStreamClient) Send(m *StreamAccessRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *graphAccessGetNeighboursStreamClient) Recv() (*StreamAccessResponse, error) {
+	m := new(StreamAccessResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *graphAccessClient) Expand(ctx context.Context, in *ExpandRequest, opts ...grpc.CallOption) (*ExpandResponse, error) {
+	out := new(ExpandResponse)
+	err := c.cc.Invoke(ctx, GraphAccess_Expand_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
+func (c *graphAccessClient) Traverse(ctx context.Context, in *TraverseRequest, opts ...grpc.CallOption) (GraphAccess_TraverseClient, error) {
+	stream, err := c.cc.NewStream(ctx, &GraphAccess_ServiceDesc.Streams[1], GraphAccess_Traverse_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &graphAccessTraverseClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GraphAccess_TraverseClient interface {
+	Recv() (*TraverseResponse, error)
+	grpc.ClientStream
+}
+
+type graphAccessTraverseClient struct {
+	grpc.ClientStream
+}
+
+func (x *graphAccessTraverseClient) Recv() (*TraverseResponse, error) {
+	m := new(TraverseResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *graphAccessClient) GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*Stats, error) {
 	out := new(Stats)
-	err := c.cc.Invoke(ctx, "/graph_access_service.GraphAccess/GetStats", in, out, opts...)
+	err := c.cc.Invoke(ctx, GraphAccess_GetStats_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -53,6 +162,11 @@ func (c *graphAccessClient) GetStats(ctx context.Context, in *StatsRequest, opts
 // for forward compatibility
 type GraphAccessServer interface {
 	GetNeighbours(context.Context, *AccessRequest) (*AccessResponse, error)
+	GetNeighbours64(context.Context, *AccessRequest64) (*AccessResponse64, error)
+	GetNeighboursBatch(context.Context, *BatchAccessRequest) (*BatchAccessResponse, error)
+	GetNeighboursStream(GraphAccess_GetNeighboursStreamServer) error
+	Expand(context.Context, *ExpandRequest) (*ExpandResponse, error)
+	Traverse(*TraverseRequest, GraphAccess_TraverseServer) error
 	GetStats(context.Context, *StatsRequest) (*Stats, error)
 	mustEmbedUnimplementedGraphAccessServer()
 }
@@ -64,6 +178,21 @@ type UnimplementedGraphAccessServer struct {
 func (UnimplementedGraphAccessServer) GetNeighbours(context.Context, *AccessRequest) (*AccessResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetNeighbours not implemented")
 }
+func (UnimplementedGraphAccessServer) GetNeighbours64(context.Context, *AccessRequest64) (*AccessResponse64, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNeighbours64 not implemented")
+}
+func (UnimplementedGraphAccessServer) GetNeighboursBatch(context.Context, *BatchAccessRequest) (*BatchAccessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNeighboursBatch not implemented")
+}
+func (UnimplementedGraphAccessServer) GetNeighboursStream(GraphAccess_GetNeighboursStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetNeighboursStream not implemented")
+}
+func (UnimplementedGraphAccessServer) Expand(context.Context, *ExpandRequest) (*ExpandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Expand not implemented")
+}
+func (UnimplementedGraphAccessServer) Traverse(*TraverseRequest, GraphAccess_TraverseServer) error {
+	return status.Errorf(codes.Unimplemented, "method Traverse not implemented")
+}
 func (UnimplementedGraphAccessServer) GetStats(context.Context, *StatsRequest) (*Stats, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
 }
@@ -90,7 +219,7 @@ func _GraphAccess_GetNeighbours_Handler(srv interface{}, ctx context.Context, de
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/graph_access_service.GraphAccess/GetNeighbours",
+		FullMethod: GraphAccess_GetNeighbours_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(GraphAccessServer).GetNeighbours(ctx, req.(*AccessRequest))
@@ -98,6 +227,107 @@ func _GraphAccess_GetNeighbours_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GraphAccess_GetNeighbours64_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccessRequest64)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GraphAccessServer).GetNeighbours64(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GraphAccess_GetNeighbours64_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GraphAccessServer).GetNeighbours64(ctx, req.(*AccessRequest64))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GraphAccess_GetNeighboursBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchAccessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GraphAccessServer).GetNeighboursBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GraphAccess_GetNeighboursBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GraphAccessServer).GetNeighboursBatch(ctx, req.(*BatchAccessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GraphAccess_GetNeighboursStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GraphAccessServer).GetNeighboursStream(&graphAccessGetNeighboursStreamServer{stream})
+}
+
+type GraphAccess_GetNeighboursStreamServer interface {
+	Send(*StreamAccessResponse) error
+	Recv() (*StreamAccessRequest, error)
+	grpc.ServerStream
+}
+
+type graphAccessGetNeighboursStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *graphAccessGetNeighboursStreamServer) Send(m *StreamAccessResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *graphAccessGetNeighboursStreamServer) Recv() (*StreamAccessRequest, error) {
+	m := new(StreamAccessRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _GraphAccess_Expand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExpandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GraphAccessServer).Expand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GraphAccess_Expand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GraphAccessServer).Expand(ctx, req.(*ExpandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GraphAccess_Traverse_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TraverseRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GraphAccessServer).Traverse(m, &graphAccessTraverseServer{stream})
+}
+
+type GraphAccess_TraverseServer interface {
+	Send(*TraverseResponse) error
+	grpc.ServerStream
+}
+
+type graphAccessTraverseServer struct {
+	grpc.ServerStream
+}
+
+func (x *graphAccessTraverseServer) Send(m *TraverseResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _GraphAccess_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(StatsRequest)
 	if err := dec(in); err != nil {
@@ -108,7 +338,7 @@ func _GraphAccess_GetStats_Handler(srv interface{}, ctx context.Context, dec fun
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/graph_access_service.GraphAccess/GetStats",
+		FullMethod: GraphAccess_GetStats_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(GraphAccessServer).GetStats(ctx, req.(*StatsRequest))
@@ -127,11 +357,35 @@ var GraphAccess_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetNeighbours",
 			Handler:    _GraphAccess_GetNeighbours_Handler,
 		},
+		{
+			MethodName: "GetNeighbours64",
+			Handler:    _GraphAccess_GetNeighbours64_Handler,
+		},
+		{
+			MethodName: "GetNeighboursBatch",
+			Handler:    _GraphAccess_GetNeighboursBatch_Handler,
+		},
+		{
+			MethodName: "Expand",
+			Handler:    _GraphAccess_Expand_Handler,
+		},
 		{
 			MethodName: "GetStats",
 			Handler:    _GraphAccess_GetStats_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetNeighboursStream",
+			Handler:       _GraphAccess_GetNeighboursStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Traverse",
+			Handler:       _GraphAccess_Traverse_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "graph_access.proto",
 }
@@ -1,17 +1,52 @@
 package graphaccess
 
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/adityachandla/graph_access_service/caches"
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
 const SizeIntBytes = 4
 
 type GraphAccess interface {
-	GetNeighbours(Request) []uint32
+	GetNeighbours(context.Context, Request) ([]uint32, error)
+	// GetNeighboursBatch answers all the requests at once. The results
+	// are in the same order as the requests.
+	GetNeighboursBatch(context.Context, []Request) []BatchResult
+	// GetNeighbours64 answers requests for graphs whose node IDs do not
+	// fit in 32 bits. Nodes of files with 4 byte node IDs can be requested
+	// as well.
+	GetNeighbours64(context.Context, Request64) ([]uint64, error)
 	GetStats() string
 }
 
+// BatchResult is the result of a single request in a batch. Err is set
+// if the lookup for that request failed, in which case Neighbours is nil.
+type BatchResult struct {
+	Neighbours []uint32
+	Err        error
+}
+
 type Request struct {
 	Node, Label uint32
 	Direction   Direction
 }
 
+// Request64 is a Request for a node whose ID can take more than 32 bits.
+type Request64 struct {
+	Node      uint64
+	Label     uint32
+	Direction Direction
+}
+
 type Direction byte
 
 const (
@@ -20,6 +55,115 @@ const (
 	BOTH
 )
 
+// MaxBatchFetches is the maximum number of fetches that a single batch
+// runs at once. Expand and Traverse read every hop with a batch, so it
+// bounds their hops as well. It should only be changed before the
+// accessors are used.
+var MaxBatchFetches = 64
+
+// forEachLimited calls fn with every index below n on at most
+// MaxBatchFetches goroutines and waits for all the calls to return.
+func forEachLimited(n int, fn func(int)) {
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < min(n, max(MaxBatchFetches, 1)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < n; i = int(next.Add(1) - 1) {
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// batchConcurrently runs the lookups of the requests concurrently, at most
+// MaxBatchFetches at a time, and collects the results in the order of the
+// requests. A failing lookup only fails its own result.
+func batchConcurrently(ctx context.Context, requests []Request,
+	lookup func(context.Context, Request) ([]uint32, error)) []BatchResult {
+	results := make([]BatchResult, len(requests))
+	forEachLimited(len(requests), func(idx int) {
+		neighbours, err := lookup(ctx, requests[idx])
+		results[idx] = BatchResult{Neighbours: neighbours, Err: err}
+	})
+	return results
+}
+
+// getNeighbours64 answers a 64 bit request with the lookup of an accessor
+// that only reads files with 4 byte node IDs.
+func getNeighbours64(ctx context.Context, req Request64,
+	lookup func(context.Context, Request) ([]uint32, error)) ([]uint64, error) {
+	if req.Node > math.MaxUint32 {
+		return nil, fmt.Errorf("Node %d does not fit in 4 byte node IDs", req.Node)
+	}
+	neighbours, err := lookup(ctx, Request{Node: uint32(req.Node), Label: req.Label, Direction: req.Direction})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]uint64, len(neighbours))
+	for i, n := range neighbours {
+		res[i] = uint64(n)
+	}
+	return res, nil
+}
+
+// CacheConfig chooses the policy and the budget of the cache of an
+// accessor. The cache holds up to Bytes bytes of values if Bytes is
+// positive, otherwise it holds up to Size values. The zero value of a
+// field keeps the accessor's default.
+type CacheConfig struct {
+	Policy caches.Policy
+	Size   int
+	Bytes  int64
+}
+
+// newCache creates the cache of the config, bytes is the number of bytes
+// held by a value.
+func newCache[K comparable, V any](config CacheConfig, defaultPolicy caches.Policy, defaultSize int,
+	bytes func(V) int64) (caches.Cache[K, V], error) {
+	if config.Policy == "" {
+		config.Policy = defaultPolicy
+	}
+	if config.Bytes > 0 {
+		return caches.NewWeighted[K, V](config.Policy, config.Bytes, bytes)
+	}
+	if config.Size == 0 {
+		config.Size = defaultSize
+	}
+	return caches.New[K, V](config.Policy, config.Size)
+}
+
+func statsToString(stats map[string]uint64) string {
+	resultBytes, err := json.Marshal(stats)
+	if err != nil {
+		panic(err)
+	}
+	return string(resultBytes)
+}
+
+// statsWithFetcher adds the counters kept by the fetcher to the stats
+// of an accessor.
+func statsWithFetcher(stats map[string]uint64, fetcher storage.Fetcher) string {
+	maps.Copy(stats, storage.CollectStats(fetcher))
+	return statsToString(stats)
+}
+
+// accessorsOfLayout are the names of the accessors that read every
+// layout, as they are passed to the -accessor flag.
+var accessorsOfLayout = map[csrfile.Layout]string{
+	csrfile.LayoutIndices:     "simple",
+	csrfile.LayoutByteOffsets: "offset or prefetch",
+	csrfile.LayoutVarint:      "varint",
+	csrfile.LayoutZstd:        "zstd",
+}
+
+func errWrongLayout(layout csrfile.Layout) error {
+	return fmt.Errorf("File has the %s layout, it can only be read by the %s accessor",
+		layout, accessorsOfLayout[layout])
+}
+
 // Stores the key of the file that
 // stores nodes starting from `start`
 // to `end` inclusive.
@@ -1,7 +1,11 @@
 package graphaccess
 
 import (
+	"context"
+	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestEdgesMiddle(t *testing.T) {
@@ -43,3 +47,44 @@ func arrayEqual[T comparable](one, two []T) bool {
 	}
 	return true
 }
+
+func TestBatchKeepsOrder(t *testing.T) {
+	requests := []Request{{Node: 3}, {Node: 1}, {Node: 2}}
+	results := batchConcurrently(context.Background(), requests, func(_ context.Context, req Request) ([]uint32, error) {
+		if req.Node == 1 {
+			return nil, fmt.Errorf("lookup failed")
+		}
+		return []uint32{req.Node * 10}, nil
+	})
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[1].Err == nil || results[1].Neighbours != nil {
+		t.Fatalf("Expected failure for node 1")
+	}
+	if !arrayEqual(results[0].Neighbours, []uint32{30}) || results[0].Err != nil {
+		t.Fail()
+	}
+	if !arrayEqual(results[2].Neighbours, []uint32{20}) || results[2].Err != nil {
+		t.Fail()
+	}
+}
+
+func TestBatchFetchLimit(t *testing.T) {
+	var running, peak atomic.Int32
+	requests := make([]Request, 500)
+	results := batchConcurrently(context.Background(), requests, func(context.Context, Request) ([]uint32, error) {
+		n := running.Add(1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		return nil, nil
+	})
+	if len(results) != len(requests) {
+		t.Fatalf("Expected %d results, got %d", len(requests), len(results))
+	}
+	if peak.Load() > int32(MaxBatchFetches) {
+		t.Fatalf("%d lookups ran at once, the limit is %d", peak.Load(), MaxBatchFetches)
+	}
+}
@@ -0,0 +1,62 @@
+package graphaccess
+
+import "context"
+
+// Step is a single hop of an expansion. All the edges with Label in
+// Direction are followed from every node in the current frontier.
+type Step struct {
+	Label     uint32
+	Direction Direction
+}
+
+// Expand walks the steps starting from source and returns the nodes that
+// every step reached. A node is left out of a hop if an earlier hop with
+// the same step already reached it, so repeating a step walks outwards
+// without expanding a node twice, while a path of different steps, such
+// as knows then worksAt, keeps every node that its last step reached. The
+// source counts as reached by every step. Only the nodes of the last hop
+// are expanded by the next step. Each hop is sent to the accessor as a
+// single batch, this lets accessors like PrefetchCsr see the whole
+// frontier at once.
+//
+// maxPerHop bounds every hop and not the total: if it is greater than 0,
+// a hop that reaches more than maxPerHop nodes is cut down to maxPerHop
+// before it is expanded further and truncated is set.
+func Expand(ctx context.Context, ga GraphAccess, source uint32, steps []Step, maxPerHop int) (hops [][]uint32, truncated bool, err error) {
+	hops = make([][]uint32, 0, len(steps))
+	frontier := []uint32{source}
+	//Nodes reached by every step so far.
+	reached := make(map[Step]map[uint32]struct{})
+	for _, step := range steps {
+		seen, ok := reached[step]
+		if !ok {
+			seen = map[uint32]struct{}{source: {}}
+			reached[step] = seen
+		}
+		requests := make([]Request, len(frontier))
+		for i, node := range frontier {
+			requests[i] = Request{Node: node, Label: step.Label, Direction: step.Direction}
+		}
+		next := make([]uint32, 0)
+		for _, res := range ga.GetNeighboursBatch(ctx, requests) {
+			if res.Err != nil {
+				return nil, false, res.Err
+			}
+			for _, n := range res.Neighbours {
+				if _, ok := seen[n]; ok {
+					continue
+				}
+				//Cut nodes are not marked as seen, a later hop can reach them.
+				if maxPerHop > 0 && len(next) == maxPerHop {
+					truncated = true
+					continue
+				}
+				seen[n] = struct{}{}
+				next = append(next, n)
+			}
+		}
+		hops = append(hops, next)
+		frontier = next
+	}
+	return hops, truncated, nil
+}
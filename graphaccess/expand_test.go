@@ -0,0 +1,88 @@
+package graphaccess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mapAccess answers requests from an in memory adjacency list. Labels
+// and directions are ignored.
+type mapAccess map[uint32][]uint32
+
+func (m mapAccess) GetNeighbours(_ context.Context, req Request) ([]uint32, error) {
+	return m[req.Node], nil
+}
+
+func (m mapAccess) GetNeighboursBatch(ctx context.Context, requests []Request) []BatchResult {
+	return batchConcurrently(ctx, requests, m.GetNeighbours)
+}
+
+func (m mapAccess) GetNeighbours64(ctx context.Context, req Request64) ([]uint64, error) {
+	return getNeighbours64(ctx, req, m.GetNeighbours)
+}
+
+func (m mapAccess) GetStats() string {
+	return ""
+}
+
+func TestExpandHops(t *testing.T) {
+	graph := mapAccess{1: {2, 3}, 2: {4, 5}, 3: {5, 6}, 5: {1}}
+	steps := []Step{{1, OUTGOING}, {1, OUTGOING}, {1, OUTGOING}}
+	hops, truncated, err := Expand(context.Background(), graph, 1, steps, 0)
+	assert.Nil(t, err)
+	assert.False(t, truncated)
+	assert.Equal(t, [][]uint32{{2, 3}, {4, 5, 6}, {}}, hops)
+}
+
+func TestExpandSkipsReachedNodes(t *testing.T) {
+	graph := mapAccess{1: {2, 3}, 2: {1, 3, 4}, 3: {2, 4}, 4: {5}}
+	steps := []Step{{1, OUTGOING}, {1, OUTGOING}, {1, OUTGOING}, {1, OUTGOING}}
+	hops, _, err := Expand(context.Background(), graph, 1, steps, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]uint32{{2, 3}, {4}, {5}, {}}, hops)
+}
+
+func TestExpandTruncates(t *testing.T) {
+	graph := mapAccess{1: {2, 3, 4}, 2: {5}, 3: {6}, 4: {7}}
+	hops, truncated, err := Expand(context.Background(), graph, 1, []Step{{1, OUTGOING}, {1, OUTGOING}}, 2)
+	assert.Nil(t, err)
+	assert.True(t, truncated)
+	assert.Equal(t, [][]uint32{{2, 3}, {5, 6}}, hops)
+}
+
+// labelAccess answers requests from an adjacency list per label.
+type labelAccess map[uint32]mapAccess
+
+func (l labelAccess) GetNeighbours(ctx context.Context, req Request) ([]uint32, error) {
+	return l[req.Label].GetNeighbours(ctx, req)
+}
+
+func (l labelAccess) GetNeighboursBatch(ctx context.Context, requests []Request) []BatchResult {
+	return batchConcurrently(ctx, requests, l.GetNeighbours)
+}
+
+func (l labelAccess) GetNeighbours64(ctx context.Context, req Request64) ([]uint64, error) {
+	return getNeighbours64(ctx, req, l.GetNeighbours)
+}
+
+func (l labelAccess) GetStats() string {
+	return ""
+}
+
+func TestExpandPath(t *testing.T) {
+	//Node 2 is reached by both labels, it is kept by the second step.
+	graph := labelAccess{
+		1: {1: {2, 3}, 3: {2}, 4: {3, 5}},
+		2: {2: {2}, 3: {4}},
+	}
+	hops, _, err := Expand(context.Background(), graph, 1, []Step{{1, OUTGOING}, {2, OUTGOING}}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]uint32{{2, 3}, {2, 4}}, hops)
+
+	//The second step with label 1 skips the nodes that the first reached.
+	hops, _, err = Expand(context.Background(), graph, 1, []Step{{1, OUTGOING}, {2, OUTGOING}, {1, OUTGOING}}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]uint32{{2, 3}, {2, 4}, {5}}, hops)
+}
@@ -1,6 +1,9 @@
 package graphaccess
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 type future[T any] struct {
 	val T
@@ -22,3 +25,22 @@ func (f *future[T]) get() T {
 	f.wg.Wait()
 	return f.val
 }
+
+// getContext waits for the value only until ctx is done.
+func (f *future[T]) getContext(ctx context.Context) (T, error) {
+	if ctx.Done() == nil {
+		return f.get(), nil
+	}
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return f.val, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
@@ -1,8 +1,11 @@
 package graphaccess
 
 import (
-	"github.com/stretchr/testify/assert"
+	"context"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestFuture(t *testing.T) {
@@ -13,3 +16,16 @@ func TestFuture(t *testing.T) {
 	assert.Equal(t, 22, f.get())
 	assert.Equal(t, 22, f.get())
 }
+
+func TestFutureContext(t *testing.T) {
+	f := newFuture[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := f.getContext(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	f.put(22)
+	val, err := f.getContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 22, val)
+}
@@ -0,0 +1,315 @@
+package graphaccess
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+// ManifestObject is the name of the object that describes all the files
+// of a graph. Objects whose name starts with an underscore hold metadata
+// and not nodes.
+const ManifestObject = "_manifest"
+
+// ProbeParallelism is the maximum number of files that are probed at the
+// same time when there is no manifest.
+const ProbeParallelism = 32
+
+const (
+	manifestMagic      = "GMAN"
+	manifestVersion    = 1
+	manifestHasOffsets = 1
+)
+
+// ManifestEntry describes a single file. Offsets is the offset table
+// stored in the file, it is nil if the manifest was written without the
+// offset tables. Directory is the directory table of files with label
+// directories and BlockIndex the block index of zstd files, they are only
+// there along with the offsets.
+type ManifestEntry struct {
+	ObjectName string
+	Header     csrfile.Header
+	Offsets    []byte
+	Directory  []byte
+	BlockIndex []byte
+}
+
+func (e *ManifestEntry) isZstd() bool {
+	return e.Header.Layout() == csrfile.LayoutZstd
+}
+
+// hasOffsets tells if the entry has all the tables that the accessors
+// keep in memory.
+func (e *ManifestEntry) hasOffsets() bool {
+	return e.Offsets != nil && (!e.Header.HasDirectory() || e.Directory != nil) &&
+		(!e.isZstd() || e.BlockIndex != nil)
+}
+
+// Manifest lets the accessors start by reading a single object instead
+// of probing every file.
+//
+// It starts with the magic "GMAN", a version and flags, each of them 2
+// bytes, and the number of files in 4 bytes. For every file there is its
+// header encoded with csrfile.Header.Encode, the length of the object key
+// in 2 bytes and the key. If the offsets flag is set the offset tables of
+// all the files follow in the same order, each of them followed by the
+// directory table of the file if it has label directories or by its block
+// index if it is a zstd file. All integers are little endian.
+type Manifest struct {
+	Entries []ManifestEntry
+}
+
+func (m *Manifest) hasOffsets() bool {
+	for i := range m.Entries {
+		if !m.Entries[i].hasOffsets() {
+			return false
+		}
+	}
+	return len(m.Entries) > 0
+}
+
+func (m *Manifest) Encode() []byte {
+	var flags uint16
+	if m.hasOffsets() {
+		flags |= manifestHasOffsets
+	}
+	res := []byte(manifestMagic)
+	res = binary.LittleEndian.AppendUint16(res, manifestVersion)
+	res = binary.LittleEndian.AppendUint16(res, flags)
+	res = binary.LittleEndian.AppendUint32(res, uint32(len(m.Entries)))
+	for _, e := range m.Entries {
+		res = append(res, e.Header.Encode()...)
+		res = binary.LittleEndian.AppendUint16(res, uint16(len(e.ObjectName)))
+		res = append(res, e.ObjectName...)
+	}
+	if flags&manifestHasOffsets != 0 {
+		for _, e := range m.Entries {
+			res = append(res, e.Offsets...)
+			res = append(res, e.Directory...)
+			res = append(res, e.BlockIndex...)
+		}
+	}
+	return res
+}
+
+var errTruncatedManifest = errors.New("Manifest is truncated")
+
+func DecodeManifest(data []byte) (*Manifest, error) {
+	if len(data) < 12 || string(data[:4]) != manifestMagic {
+		return nil, errors.New("Manifest does not start with the magic bytes")
+	}
+	if version := binary.LittleEndian.Uint16(data[4:6]); version != manifestVersion {
+		return nil, fmt.Errorf("Unsupported manifest version %d", version)
+	}
+	flags := binary.LittleEndian.Uint16(data[6:8])
+	numEntries := binary.LittleEndian.Uint32(data[8:12])
+	pos := 12
+	m := &Manifest{Entries: make([]ManifestEntry, 0, numEntries)}
+	for i := uint32(0); i < numEntries; i++ {
+		if len(data) < pos+csrfile.HeaderSize+2 {
+			return nil, errTruncatedManifest
+		}
+		header, err := csrfile.DecodeHeader(data[pos:])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid header of manifest entry %d: %w", i, err)
+		}
+		e := ManifestEntry{Header: header}
+		pos += csrfile.HeaderSize
+		keyLen := int(binary.LittleEndian.Uint16(data[pos : pos+2]))
+		pos += 2
+		if len(data) < pos+keyLen {
+			return nil, errTruncatedManifest
+		}
+		e.ObjectName = string(data[pos : pos+keyLen])
+		pos += keyLen
+		m.Entries = append(m.Entries, e)
+	}
+	if flags&manifestHasOffsets != 0 {
+		for i := range m.Entries {
+			size := int(m.Entries[i].Header.OffsetTableSize())
+			if len(data) < pos+size {
+				return nil, errTruncatedManifest
+			}
+			m.Entries[i].Offsets = data[pos : pos+size]
+			pos += size
+			if m.Entries[i].Header.HasDirectory() {
+				size = int(m.Entries[i].Header.DirectoryTableSize())
+				if len(data) < pos+size {
+					return nil, errTruncatedManifest
+				}
+				m.Entries[i].Directory = data[pos : pos+size]
+				pos += size
+			}
+			if m.Entries[i].isZstd() {
+				indexSize, err := m.Entries[i].Header.BlockIndexSize(data[pos:])
+				if err != nil {
+					return nil, fmt.Errorf("Invalid block index of manifest entry %d: %w", i, err)
+				}
+				if uint64(len(data)-pos) < indexSize {
+					return nil, errTruncatedManifest
+				}
+				m.Entries[i].BlockIndex = data[pos : pos+int(indexSize)]
+				pos += int(indexSize)
+			}
+		}
+	}
+	if pos != len(data) {
+		return nil, fmt.Errorf("Manifest has %d unexpected trailing bytes", len(data)-pos)
+	}
+	return m, nil
+}
+
+// loadManifest reads the manifest of the graph. If there is no manifest
+// the files are probed instead. If withOffsets is set the entries are
+// guaranteed to have their offset tables.
+func loadManifest(fetcher storage.Fetcher, withOffsets bool) (*Manifest, error) {
+	data, err := fetcher.Fetch(ManifestObject, storage.BRangeStart(0))
+	if err != nil && !storage.IsNotExist(err) {
+		return nil, fmt.Errorf("Unable to fetch manifest: %w", err)
+	}
+	if err != nil {
+		log.Println("No manifest found, probing all files")
+		return ProbeManifest(fetcher, withOffsets)
+	}
+	m, err := DecodeManifest(data)
+	if err != nil {
+		return nil, err
+	}
+	if withOffsets && !m.hasOffsets() {
+		if err = probeEntries(fetcher, m.Entries, true); err != nil {
+			return nil, err
+		}
+	}
+	log.Printf("Loaded manifest with %d files\n", len(m.Entries))
+	return m, nil
+}
+
+// ProbeManifest builds the manifest by reading the node range, and if
+// withOffsets is set the offset table, of every file.
+func ProbeManifest(fetcher storage.Fetcher, withOffsets bool) (*Manifest, error) {
+	files, err := fetcher.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+	return ProbeObjects(fetcher, files, withOffsets)
+}
+
+// ProbeObjects builds the manifest of the data objects among the names,
+// like ProbeManifest does. A command that has written a graph passes the
+// names it wrote so that files of an earlier run are left out.
+func ProbeObjects(fetcher storage.Fetcher, names []string, withOffsets bool) (*Manifest, error) {
+	m := &Manifest{Entries: make([]ManifestEntry, 0, len(names))}
+	for _, name := range names {
+		if IsDataObject(name) {
+			m.Entries = append(m.Entries, ManifestEntry{ObjectName: name})
+		}
+	}
+	if err := probeEntries(fetcher, m.Entries, withOffsets); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// probeEntries fills in the node ranges of the entries, and their offset
+// tables if withOffsets is set. At most ProbeParallelism files are probed
+// at once.
+func probeEntries(fetcher storage.Fetcher, entries []ManifestEntry, withOffsets bool) error {
+	errs := make([]error, len(entries))
+	slots := make(chan struct{}, ProbeParallelism)
+	var wg sync.WaitGroup
+	for i := range entries {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(e *ManifestEntry, err *error) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			*err = probeEntry(fetcher, e, withOffsets)
+		}(&entries[i], &errs[i])
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// probeEntry reads the header of the file, the layout of legacy files is
+// only known if the offsets are read as well. The directory table and the
+// block index are read along with the offsets.
+func probeEntry(fetcher storage.Fetcher, e *ManifestEntry, withOffsets bool) error {
+	var err error
+	e.Header, err = fetchHeader(fetcher, e.ObjectName)
+	if err != nil {
+		return err
+	}
+	if !withOffsets {
+		return nil
+	}
+	h := &e.Header
+	e.Offsets, err = fetcher.Fetch(e.ObjectName,
+		storage.BRange(h.OffsetTableStart(), h.EdgesStart()-1))
+	if err != nil {
+		return err
+	}
+	if err = h.InferLayout(e.Offsets); err != nil {
+		return fmt.Errorf("Unable to read %s: %w", e.ObjectName, err)
+	}
+	if e.isZstd() {
+		return probeBlockIndex(fetcher, e)
+	}
+	if !h.HasDirectory() {
+		return nil
+	}
+	tableStart := h.DirectoryTableStart()
+	e.Directory, err = fetcher.Fetch(e.ObjectName,
+		storage.BRange(tableStart, tableStart+h.DirectoryTableSize()-1))
+	return err
+}
+
+// probeBlockIndex reads the preamble of the block index, which tells its
+// size, and then the whole index.
+func probeBlockIndex(fetcher storage.Fetcher, e *ManifestEntry) error {
+	start := e.Header.EdgesStart()
+	preamble, err := fetcher.Fetch(e.ObjectName, storage.BRange(start, start+csrfile.BlockIndexPreambleSize-1))
+	if err != nil {
+		return err
+	}
+	size, err := e.Header.BlockIndexSize(preamble)
+	if err != nil {
+		return fmt.Errorf("Unable to read %s: %w", e.ObjectName, err)
+	}
+	e.BlockIndex, err = fetcher.Fetch(e.ObjectName, storage.BRange(start, start+size-1))
+	return err
+}
+
+// fetchHeader reads the header of the file, the rest of a versioned
+// header is only fetched once the file is known to have one.
+func fetchHeader(fetcher storage.Fetcher, objectName string) (csrfile.Header, error) {
+	prefix, err := fetcher.Fetch(objectName, storage.BRange(0, csrfile.PrefixSize-1))
+	if err != nil {
+		return csrfile.Header{}, err
+	}
+	if csrfile.IsVersioned(prefix) {
+		prefix, err = fetcher.Fetch(objectName, storage.BRange(0, csrfile.HeaderSize-1))
+		if err != nil {
+			return csrfile.Header{}, err
+		}
+	}
+	h, err := csrfile.ParseHeader(prefix)
+	if err != nil {
+		return csrfile.Header{}, fmt.Errorf("Unable to read %s: %w", objectName, err)
+	}
+	return h, nil
+}
+
+// IsDataObject is false for the metadata objects and for files that are
+// being written.
+func IsDataObject(name string) bool {
+	base := name[strings.LastIndex(name, "/")+1:]
+	return !strings.HasPrefix(base, "_") && !strings.HasPrefix(base, ".")
+}
@@ -0,0 +1,59 @@
+package graphaccess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	for _, withOffsets := range []bool{true, false} {
+		m, err := ProbeManifest(testGraph(true), withOffsets)
+		assert.Nil(t, err)
+		assert.Len(t, m.Entries, 2)
+		decoded, err := DecodeManifest(m.Encode())
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, m.Entries, decoded.Entries)
+		assert.Equal(t, withOffsets, decoded.hasOffsets())
+	}
+
+	m, err := ProbeManifest(testGraph(true), true)
+	assert.Nil(t, err)
+	data := m.Encode()
+	_, err = DecodeManifest(data[:len(data)-1])
+	assert.NotNil(t, err)
+	_, err = DecodeManifest(append(data, 0))
+	assert.NotNil(t, err)
+}
+
+func TestProbeObjects(t *testing.T) {
+	fetcher := testGraph(true)
+	m, err := ProbeObjects(fetcher, []string{"b", PermutationObject}, false)
+	assert.Nil(t, err)
+	assert.Len(t, m.Entries, 1)
+	assert.Equal(t, "b", m.Entries[0].ObjectName)
+	assert.Equal(t, uint64(2), m.Entries[0].Header.Start)
+}
+
+func TestStartupFromManifest(t *testing.T) {
+	for _, withOffsets := range []bool{true, false} {
+		fetcher := testGraph(true)
+		//Metadata objects are not probed.
+		fetcher.objects["_other"] = []byte{1}
+		m, err := ProbeManifest(fetcher, withOffsets)
+		assert.Nil(t, err)
+		assert.Len(t, m.Entries, 2)
+		fetcher.objects[ManifestObject] = m.Encode()
+
+		fetcher.fetches.Store(0)
+		csr, err := NewOffsetCsr(fetcher, 0)
+		assert.Nil(t, err)
+		if withOffsets {
+			assert.Equal(t, int32(1), fetcher.fetches.Load())
+		}
+		res, err := csr.GetNeighbours(context.Background(), Request{Node: 3, Label: 1, Direction: BOTH})
+		assert.Nil(t, err)
+		assert.Equal(t, []uint32{0, 0}, res)
+	}
+}
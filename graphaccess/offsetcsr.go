@@ -1,37 +1,69 @@
 package graphaccess
 
 import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"github.com/adityachandla/graph_access_service/bin_util"
+	"github.com/adityachandla/graph_access_service/csrfile"
 	"github.com/adityachandla/graph_access_service/storage"
+	"math"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"unsafe"
 )
 
 type OffsetCsr struct {
 	offsets fileOffsets
+	//wide are the files with 8 byte node IDs.
+	wide    wideFiles
 	fetcher storage.Fetcher
+	planner *readPlanner
+	//Number of requests that were read with a label directory.
+	directoryReads atomic.Uint64
 }
 
-func NewOffsetCsr(fetcher storage.Fetcher) *OffsetCsr {
-	files := fetcher.ListFiles()
-	offsets := make(fileOffsets, 0, len(files))
-	fileOffsetChannel := make(chan *fileOffset)
-	wg := sync.WaitGroup{}
-	for _, f := range files {
-		wg.Add(1)
-		go func(filename string) {
-			defer wg.Done()
-			fetchFileOffset(filename, fetcher, fileOffsetChannel)
-		}(f)
+// NewOffsetCsr reads the offsets of all the files from the manifest, or
+// from the files themselves if there is no manifest. Lookups of several
+// nodes stored less than maxReadGap bytes apart are served by a single
+// range GET, a negative maxReadGap fetches every node separately.
+func NewOffsetCsr(fetcher storage.Fetcher, maxReadGap int) (*OffsetCsr, error) {
+	offsets, wide, err := loadFileOffsets(fetcher, csrfile.LayoutByteOffsets)
+	if err != nil {
+		return nil, err
 	}
-	go func() {
-		wg.Wait()
-		close(fileOffsetChannel)
-	}()
-	for offset := range fileOffsetChannel {
-		offsets = append(offsets, offset)
+	return &OffsetCsr{offsets: offsets, wide: wide, fetcher: fetcher, planner: newReadPlanner(fetcher, maxReadGap)}, nil
+}
+
+// loadFileOffsets reads the offset tables of all the files, which must
+// have the layout, sorted by their first node. Files with 8 byte node IDs
+// are returned separately, they can only have the byte offsets layout.
+func loadFileOffsets(fetcher storage.Fetcher, layout csrfile.Layout) (fileOffsets, wideFiles, error) {
+	manifest, err := loadManifest(fetcher, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	offsets := make(fileOffsets, 0, len(manifest.Entries))
+	var wide wideFiles
+	errs := make([]error, len(manifest.Entries))
+	for i, e := range manifest.Entries {
+		if e.Header.IDWidth == 8 {
+			var file *wideFile
+			if file, errs[i] = newWideFile(e, layout); file != nil {
+				wide = append(wide, file)
+			}
+			continue
+		}
+		var file *fileOffset
+		if file, errs[i] = newFileOffset(e, layout); file != nil {
+			offsets = append(offsets, file)
+		}
+	}
+	if err = errors.Join(errs...); err != nil {
+		return nil, nil, err
 	}
 	slices.SortFunc(offsets, func(a, b *fileOffset) int {
 		if a.nodeRange.start > b.nodeRange.start {
@@ -39,78 +71,314 @@ func NewOffsetCsr(fetcher storage.Fetcher) *OffsetCsr {
 		}
 		return -1
 	})
-	return &OffsetCsr{offsets, fetcher}
-}
-
-func fetchFileOffset(filename string, fetcher storage.Fetcher, outputChannel chan<- *fileOffset) {
-	startEndBytes := fetcher.Fetch(filename, storage.BRange(0, 7))
-	start := bin_util.ByteToUint(startEndBytes[0:4])
-	end := bin_util.ByteToUint(startEndBytes[4:])
-	nodeRange := nodeRangePath{
-		start:      start,
-		end:        end,
-		objectName: filename,
-	}
-	sizeOfOffsets := 2 * SizeIntBytes * (end - start + 1)
-	offsetBytes := fetcher.Fetch(filename, storage.BRange(8, 8+sizeOfOffsets-1))
-	offsetPairs := bin_util.ByteArrayToPairArray(offsetBytes)
-	offsetStruct := &fileOffset{
-		nodeRange: nodeRange,
+	slices.SortFunc(wide, func(a, b *wideFile) int {
+		return cmp.Compare(a.start, b.start)
+	})
+	return offsets, wide, nil
+}
+
+// newFileOffset checks that the file has the layout and that its offsets
+// are all within the file.
+func newFileOffset(e ManifestEntry, layout csrfile.Layout) (*fileOffset, error) {
+	h := e.Header
+	if err := h.InferLayout(e.Offsets); err != nil {
+		return nil, fmt.Errorf("Unable to read %s: %w", e.ObjectName, err)
+	}
+	if h.Layout() != layout {
+		return nil, fmt.Errorf("Unable to read %s: %w", e.ObjectName, errWrongLayout(h.Layout()))
+	}
+	if err := h.ValidateOffsets(e.Offsets); err != nil {
+		return nil, fmt.Errorf("Unable to read %s: %w", e.ObjectName, err)
+	}
+	offsetPairs := bin_util.PairArrayView(e.Offsets)
+	file := &fileOffset{
+		nodeRange: nodeRangePath{
+			start:      uint32(h.Start),
+			end:        uint32(h.End),
+			objectName: e.ObjectName,
+		},
 		offsetArr: *(*[]nodeOffset)(unsafe.Pointer(&offsetPairs)),
 	}
-	outputChannel <- offsetStruct
+	if h.HasDirectory() {
+		if err := h.ValidateDirectoryTable(e.Directory); err != nil {
+			return nil, fmt.Errorf("Unable to read %s: %w", e.ObjectName, err)
+		}
+		file.directory = e.Directory
+		file.edgesEnd = uint32(h.DirectoryTableStart())
+	}
+	if h.Layout() == csrfile.LayoutZstd {
+		blocks, err := h.ParseBlockIndex(e.BlockIndex)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read %s: %w", e.ObjectName, err)
+		}
+		file.blocks = blocks
+	}
+	return file, nil
 }
 
-func (csr *OffsetCsr) GetNeighbours(req Request) []uint32 {
-	file := csr.offsets.find(req.Node)
+func (csr *OffsetCsr) GetNeighbours(ctx context.Context, req Request) ([]uint32, error) {
+	file, err := csr.offsets.find(req.Node)
+	if err != nil {
+		return csr.getFromWideFile(ctx, req, err)
+	}
+	if file.hasDirectory(req.Node) {
+		res := csr.GetNeighboursBatch(ctx, []Request{req})[0]
+		return res.Neighbours, res.Err
+	}
 	offset, numOut := file.fetchOffset(req)
 
-	resultBytes := csr.fetcher.Fetch(file.nodeRange.objectName, offset)
-	resultPairs := bin_util.ByteArrayToPairArray(resultBytes)
-	resultEdges := *(*[]edge)(unsafe.Pointer(&resultPairs))
+	resultBytes, err := csr.planner.fetch(ctx, file.nodeRange.objectName, offset)
+	if err != nil {
+		return nil, err
+	}
+	return decodeNeighbours(req, file.nodeRange.objectName, resultBytes, numOut)
+}
 
+// GetNeighboursBatch reads the nodes of all the requests through the
+// read planner so nodes stored close to each other are fetched together.
+// Nodes with a label directory are read with it, at the same time as the
+// other nodes.
+func (csr *OffsetCsr) GetNeighboursBatch(ctx context.Context, requests []Request) []BatchResult {
+	results := make([]BatchResult, len(requests))
+	files := make([]*fileOffset, len(requests))
+	var whole, labelled []int
+	for i, req := range requests {
+		file, err := csr.offsets.find(req.Node)
+		if err != nil {
+			results[i].Neighbours, results[i].Err = csr.getFromWideFile(ctx, req, err)
+			continue
+		}
+		files[i] = file
+		if file.hasDirectory(req.Node) {
+			labelled = append(labelled, i)
+		} else {
+			whole = append(whole, i)
+		}
+	}
+	var wg sync.WaitGroup
+	if len(labelled) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			csr.readWithDirectory(ctx, requests, files, labelled, results)
+		}()
+	}
+	csr.readWhole(ctx, requests, files, whole, results)
+	wg.Wait()
+	return results
+}
+
+// readWhole answers the requests at the indices by reading all the edges
+// of their nodes in the requested direction.
+func (csr *OffsetCsr) readWhole(ctx context.Context, requests []Request, files []*fileOffset,
+	indices []int, results []BatchResult) {
+	reads := make([]rangeRead, len(indices))
+	numOut := make([]uint32, len(indices))
+	for j, i := range indices {
+		offset, n := files[i].fetchOffset(requests[i])
+		reads[j] = newRangeRead(files[i].nodeRange.objectName, offset)
+		numOut[j] = n
+	}
+	data, errs := csr.planner.read(ctx, reads)
+	for j, i := range indices {
+		if errs[j] != nil {
+			results[i].Err = errs[j]
+			continue
+		}
+		results[i].Neighbours, results[i].Err = decodeNeighbours(requests[i], reads[j].objectName, data[j], numOut[j])
+	}
+}
+
+// readWithDirectory answers the requests at the indices by reading the
+// label directories of their nodes first and then only the edges with
+// the requested label.
+func (csr *OffsetCsr) readWithDirectory(ctx context.Context, requests []Request, files []*fileOffset,
+	indices []int, results []BatchResult) {
+	csr.directoryReads.Add(uint64(len(indices)))
+	reads := make([]rangeRead, len(indices))
+	for j, i := range indices {
+		reads[j] = newRangeRead(files[i].nodeRange.objectName, files[i].directoryRange(requests[i].Node))
+	}
+	directories, errs := csr.planner.read(ctx, reads)
+	var labelReads []rangeRead
+	//The request that every read of edges belongs to.
+	var owners []int
+	for j, i := range indices {
+		if errs[j] != nil {
+			results[i].Err = errs[j]
+			continue
+		}
+		ranges, err := files[i].labelRanges(requests[i], directories[j])
+		if err != nil {
+			results[i].Err = fmt.Errorf("Unable to read %s: %w", files[i].nodeRange.objectName, err)
+			continue
+		}
+		results[i].Neighbours = []uint32{}
+		for _, r := range ranges {
+			labelReads = append(labelReads, newRangeRead(files[i].nodeRange.objectName, r))
+			owners = append(owners, i)
+		}
+	}
+	data, errs := csr.planner.read(ctx, labelReads)
+	for k, i := range owners {
+		if results[i].Err != nil {
+			continue
+		}
+		if errs[k] != nil {
+			results[i] = BatchResult{Err: errs[k]}
+			continue
+		}
+		edges, err := decodeEdges(labelReads[k].objectName, data[k])
+		if err == nil && len(edges) != int(labelReads[k].end-labelReads[k].start+1)/8 {
+			err = fmt.Errorf("Unable to read %s: %w: edges are cut off", labelReads[k].objectName, csrfile.ErrCorrupt)
+		}
+		for _, e := range edges {
+			if err == nil && e.label != requests[i].Label {
+				err = fmt.Errorf("Unable to read %s: %w: label directory of node %d points at other labels",
+					labelReads[k].objectName, csrfile.ErrCorrupt, requests[i].Node)
+			}
+			results[i].Neighbours = append(results[i].Neighbours, e.dest)
+		}
+		if err != nil {
+			results[i] = BatchResult{Err: err}
+		}
+	}
+}
+
+// decodeNeighbours returns the destinations of the edges with the label
+// of the request. The first numOut edges are outgoing for BOTH requests.
+func decodeNeighbours(req Request, objectName string, resultBytes []byte, numOut uint32) ([]uint32, error) {
+	resultEdges, err := decodeEdges(objectName, resultBytes)
+	if err != nil {
+		return nil, err
+	}
 	if req.Direction != BOTH {
-		return getEdgesWithLabel(resultEdges, req.Label)
+		return getEdgesWithLabel(resultEdges, req.Label), nil
+	}
+	if int(numOut) > len(resultEdges) {
+		return nil, fmt.Errorf("Unable to read %s: %w: node %d has fewer edges than its offsets",
+			objectName, csrfile.ErrCorrupt, req.Node)
 	}
 	filtered := getEdgesWithLabel(resultEdges[:numOut], req.Label)
-	return append(filtered, getEdgesWithLabel(resultEdges[numOut:], req.Label)...)
+	return append(filtered, getEdgesWithLabel(resultEdges[numOut:], req.Label)...), nil
 }
 
-func (csr *OffsetCsr) fetchAllEdges(node uint32) []edge {
-	file := csr.offsets.find(node)
-	byteRange := file.fetchOffsetAllEdges(node)
+// decodeEdges fails instead of panicking if the file was cut off in the
+// middle of an edge.
+func decodeEdges(objectName string, resultBytes []byte) ([]edge, error) {
+	if len(resultBytes)%(2*SizeIntBytes) != 0 {
+		return nil, fmt.Errorf("Unable to read %s: %w: edges are cut off", objectName, csrfile.ErrCorrupt)
+	}
+	resultPairs := bin_util.PairArrayView(resultBytes)
+	return *(*[]edge)(unsafe.Pointer(&resultPairs)), nil
+}
 
-	resultBytes := csr.fetcher.Fetch(file.nodeRange.objectName, byteRange)
-	resultPairs := bin_util.ByteArrayToPairArray(resultBytes)
-	return *(*[]edge)(unsafe.Pointer(&resultPairs))
+// fetchAllEdgesBatch fetches all the edges of the nodes through the read
+// planner, the results are in the order of the nodes.
+func (csr *OffsetCsr) fetchAllEdgesBatch(ctx context.Context, nodes []uint32) []fetchResult {
+	return csr.fetchAllEdgesWith(ctx, csr.planner, nodes)
+}
+
+// fetchAllEdgesWith is fetchAllEdgesBatch through another planner, so that
+// its GETs are counted separately.
+func (csr *OffsetCsr) fetchAllEdgesWith(ctx context.Context, planner *readPlanner, nodes []uint32) []fetchResult {
+	results := make([]fetchResult, len(nodes))
+	reads := make([]rangeRead, 0, len(nodes))
+	readIdx := make([]int, 0, len(nodes))
+	for i, node := range nodes {
+		file, err := csr.offsets.find(node)
+		if err != nil {
+			results[i].err = err
+			continue
+		}
+		reads = append(reads, newRangeRead(file.nodeRange.objectName, file.fetchOffsetAllEdges(node)))
+		readIdx = append(readIdx, i)
+	}
+	data, errs := planner.read(ctx, reads)
+	for j, i := range readIdx {
+		if errs[j] != nil {
+			results[i].err = errs[j]
+			continue
+		}
+		results[i].edges, results[i].err = decodeEdges(reads[j].objectName, data[j])
+	}
+	return results
+}
+
+// GetNeighbours64 reads nodes of files with 8 byte node IDs, nodes of the
+// other files are read like GetNeighbours.
+func (csr *OffsetCsr) GetNeighbours64(ctx context.Context, req Request64) ([]uint64, error) {
+	file, err := csr.wide.find(req.Node)
+	if err != nil {
+		return getNeighbours64(ctx, req, csr.GetNeighbours)
+	}
+	data, errs := csr.planner.read(ctx, []rangeRead{newRangeRead(file.objectName, file.fetchOffset(req))})
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	return decodeWideNeighbours(file.objectName, data[0], req.Label)
+}
+
+// getFromWideFile answers a request for a node that is not in a file with
+// 4 byte node IDs, it fails with notFound if no file has the node. The
+// neighbours have to fit in 32 bits.
+func (csr *OffsetCsr) getFromWideFile(ctx context.Context, req Request, notFound error) ([]uint32, error) {
+	if _, err := csr.wide.find(uint64(req.Node)); err != nil {
+		return nil, notFound
+	}
+	neighbours, err := csr.GetNeighbours64(ctx, Request64{Node: uint64(req.Node), Label: req.Label, Direction: req.Direction})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]uint32, len(neighbours))
+	for i, n := range neighbours {
+		if n > math.MaxUint32 {
+			return nil, fmt.Errorf("Neighbour %d of node %d does not fit in 32 bits", n, req.Node)
+		}
+		res[i] = uint32(n)
+	}
+	return res, nil
 }
 
 func (csr *OffsetCsr) GetStats() string {
-	return ""
+	return statsWithFetcher(csr.statsMap(), csr.fetcher)
+}
+
+func (csr *OffsetCsr) statsMap() map[string]uint64 {
+	stats := csr.planner.stats()
+	stats["directoryReads"] = csr.directoryReads.Load()
+	return stats
 }
 
 type fileOffsets []*fileOffset
 
-func (fo fileOffsets) find(node uint32) *fileOffset {
+func (fo fileOffsets) find(node uint32) (*fileOffset, error) {
 	low := 0
 	high := len(fo) - 1
 	for low <= high {
 		mid := low + ((high - low) / 2)
 		if fo[mid].contains(node) {
-			return fo[mid]
+			return fo[mid], nil
 		} else if fo[mid].nodeRange.start > node {
 			high = mid - 1
 		} else {
 			low = mid + 1
 		}
 	}
-	panic(fmt.Errorf("Node %d not found in fileOffsets\n", node))
+	return nil, fmt.Errorf("Node %d not found in fileOffsets", node)
 }
 
 type fileOffset struct {
 	//nodeRange stores the filename along with the start and end node information.
 	nodeRange nodeRangePath
 	offsetArr []nodeOffset
+	//directory is the directory table of files with label directories,
+	//their edges end at edgesEnd instead of at the end of the file.
+	directory []byte
+	edgesEnd  uint32
+	//blocks is the block index of zstd files, whose offsets are indices
+	//into the uncompressed edges.
+	blocks *csrfile.BlockIndex
 }
 
 func (offset *fileOffset) contains(node uint32) bool {
@@ -122,32 +390,95 @@ func (offset *fileOffset) fetchOffset(req Request) (storage.ByteRange, uint32) {
 	if req.Direction == OUTGOING {
 		start := offset.offsetArr[idx].outgoing
 		numOut := (offset.offsetArr[idx].incoming - start) / (2 * SizeIntBytes)
-		return storage.BRange(start, offset.offsetArr[idx].incoming-1), numOut
+		return storage.BRange(uint64(start), uint64(offset.offsetArr[idx].incoming-1)), numOut
 	} else if req.Direction == INCOMING {
-		start := offset.offsetArr[idx].incoming
-		if int(idx) < len(offset.offsetArr)-1 {
-			return storage.BRange(start, offset.offsetArr[idx+1].outgoing-1), 0
-		} else {
-			return storage.BRangeStart(start), 0
-		}
+		return offset.untilNext(offset.offsetArr[idx].incoming, idx), 0
 	}
 	//Both incoming and outgoing
 	start := offset.offsetArr[idx].outgoing
 	numOut := (offset.offsetArr[idx].incoming - start) / (2 * SizeIntBytes)
+	return offset.untilNext(start, idx), numOut
+}
+
+// untilNext is the range from start till the edges of the node after
+// idx, or till the end of the edges for the last node.
+func (offset *fileOffset) untilNext(start, idx uint32) storage.ByteRange {
 	if int(idx) < len(offset.offsetArr)-1 {
-		return storage.BRange(start, offset.offsetArr[idx+1].outgoing-1), numOut
-	} else {
-		return storage.BRangeStart(start), numOut
+		return storage.BRange(uint64(start), uint64(offset.offsetArr[idx+1].outgoing-1))
+	}
+	if offset.edgesEnd != 0 {
+		return storage.BRange(uint64(start), uint64(offset.edgesEnd-1))
 	}
+	return storage.BRangeStart(uint64(start))
 }
+
+// numOutgoing is the number of outgoing edges of the node, they are
+// stored before its incoming edges.
+func (offset *fileOffset) numOutgoing(node uint32) uint32 {
+	return offset.outgoingSize(node) / (2 * SizeIntBytes)
+}
+
+// outgoingSize is the number of bytes taken by the outgoing edges of the
+// node.
+func (offset *fileOffset) outgoingSize(node uint32) uint32 {
+	idx := node - offset.nodeRange.start
+	return offset.offsetArr[idx].incoming - offset.offsetArr[idx].outgoing
+}
+
 func (offset *fileOffset) fetchOffsetAllEdges(node uint32) storage.ByteRange {
 	idx := node - offset.nodeRange.start
-	start := offset.offsetArr[idx].outgoing
-	if int(idx) < len(offset.offsetArr)-1 {
-		return storage.BRange(start, offset.offsetArr[idx+1].outgoing-1)
-	} else {
-		return storage.BRangeStart(start)
+	return offset.untilNext(offset.offsetArr[idx].outgoing, idx)
+}
+
+func (offset *fileOffset) hasDirectory(node uint32) bool {
+	if offset.directory == nil {
+		return false
+	}
+	idx := node - offset.nodeRange.start
+	return binary.LittleEndian.Uint32(offset.directory[4*idx+4:]) > binary.LittleEndian.Uint32(offset.directory[4*idx:])
+}
+
+// directoryRange is the range of the label directory of the node.
+func (offset *fileOffset) directoryRange(node uint32) storage.ByteRange {
+	idx := node - offset.nodeRange.start
+	return storage.BRange(uint64(binary.LittleEndian.Uint32(offset.directory[4*idx:])),
+		uint64(binary.LittleEndian.Uint32(offset.directory[4*idx+4:])-1))
+}
+
+// labelRanges returns the ranges of the edges with the label of the
+// request found in the directory of the node, the outgoing edges come
+// first. Directions without such edges are left out.
+func (offset *fileOffset) labelRanges(req Request, directory []byte) ([]storage.ByteRange, error) {
+	entries, err := csrfile.ParseDirectory(directory)
+	if err != nil {
+		return nil, err
+	}
+	idx := req.Node - offset.nodeRange.start
+	_, end := offset.untilNext(offset.offsetArr[idx].outgoing, idx).Bounds()
+	for _, e := range entries {
+		if e.Start < offset.offsetArr[idx].outgoing || uint64(e.Start) > end {
+			return nil, fmt.Errorf("%w: label directory of node %d points outside of its edges",
+				csrfile.ErrCorrupt, req.Node)
+		}
+	}
+	sections := make([][2]uint32, 0, 2)
+	if req.Direction != INCOMING {
+		sections = append(sections, [2]uint32{offset.offsetArr[idx].outgoing, offset.offsetArr[idx].incoming})
+	}
+	if req.Direction != OUTGOING {
+		sections = append(sections, [2]uint32{offset.offsetArr[idx].incoming, uint32(end) + 1})
+	}
+	ranges := make([]storage.ByteRange, 0, len(sections))
+	for _, section := range sections {
+		start, stop, err := csrfile.LabelRange(entries, req.Label, section[0], section[1])
+		if err != nil {
+			return nil, err
+		}
+		if stop > start {
+			ranges = append(ranges, storage.BRange(uint64(start), uint64(stop-1)))
+		}
 	}
+	return ranges, nil
 }
 
 type nodeOffset struct {
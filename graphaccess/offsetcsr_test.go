@@ -0,0 +1,292 @@
+package graphaccess
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// memFetcher serves objects from memory, failing every fetch of the
+// objects in failing.
+type memFetcher struct {
+	objects map[string][]byte
+	failing map[string]bool
+	fetches atomic.Int32
+}
+
+func (m *memFetcher) Fetch(objectName string, bRange storage.ByteRange) ([]byte, error) {
+	return m.FetchContext(context.Background(), objectName, bRange)
+}
+
+func (m *memFetcher) FetchContext(ctx context.Context, objectName string, bRange storage.ByteRange) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.fetches.Add(1)
+	if m.failing[objectName] {
+		return nil, errors.New("fetch failed")
+	}
+	obj, ok := m.objects[objectName]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", objectName, fs.ErrNotExist)
+	}
+	start, end := bRange.Bounds()
+	if end == 0 || int(end) >= len(obj) {
+		return obj[start:], nil
+	}
+	return obj[start : end+1], nil
+}
+
+func (m *memFetcher) ListFiles() ([]string, error) {
+	files := make([]string, 0, len(m.objects))
+	for k := range m.objects {
+		files = append(files, k)
+	}
+	return files, nil
+}
+
+// nodeEdges holds the outgoing and incoming edges of a node.
+type nodeEdges struct {
+	out, in []edge
+}
+
+// csrTables lays out the offset table and the edges of the nodes the
+// same way as the converter does. The offsets are byte offsets in the
+// file for OffsetCsr or indices in the edge section for Csr.
+func csrTables(nodes []nodeEdges, byteOffsets bool, tableStart uint32) ([]byte, []byte) {
+	var offsets, edges []byte
+	offset, edgeSize := uint32(0), uint32(1)
+	if byteOffsets {
+		offset, edgeSize = tableStart+uint32(8*len(nodes)), 8
+	}
+	for _, n := range nodes {
+		offsets = binary.LittleEndian.AppendUint32(offsets, offset)
+		offset += edgeSize * uint32(len(n.out))
+		offsets = binary.LittleEndian.AppendUint32(offsets, offset)
+		offset += edgeSize * uint32(len(n.in))
+	}
+	for _, n := range nodes {
+		for _, e := range append(append([]edge{}, n.out...), n.in...) {
+			edges = binary.LittleEndian.AppendUint32(edges, e.label)
+			edges = binary.LittleEndian.AppendUint32(edges, e.dest)
+		}
+	}
+	return offsets, edges
+}
+
+// encodeCsr lays out the nodes starting at start in a file without a
+// header.
+func encodeCsr(start uint32, nodes []nodeEdges, byteOffsets bool) []byte {
+	res := binary.LittleEndian.AppendUint32(nil, start)
+	res = binary.LittleEndian.AppendUint32(res, start+uint32(len(nodes))-1)
+	offsets, edges := csrTables(nodes, byteOffsets, csrfile.LegacyHeaderSize)
+	return append(append(res, offsets...), edges...)
+}
+
+func encodeVersionedCsr(start uint32, nodes []nodeEdges, byteOffsets bool) []byte {
+	h := csrfile.Header{IDWidth: 4, Start: uint64(start), End: uint64(start) + uint64(len(nodes)) - 1}
+	if byteOffsets {
+		h.Flags = csrfile.ByteOffsets
+	}
+	offsets, edges := csrTables(nodes, byteOffsets, csrfile.HeaderSize)
+	return csrfile.Assemble(h, offsets, edges)
+}
+
+func toFileNodes(nodes []nodeEdges) []csrfile.Node {
+	toEdges := func(edges []edge) []csrfile.Edge {
+		res := make([]csrfile.Edge, len(edges))
+		for i, e := range edges {
+			res[i] = csrfile.Edge{Label: e.label, Dest: uint64(e.dest)}
+		}
+		return res
+	}
+	fileNodes := make([]csrfile.Node, len(nodes))
+	for i, n := range nodes {
+		fileNodes[i] = csrfile.Node{Out: toEdges(n.out), In: toEdges(n.in)}
+	}
+	return fileNodes
+}
+
+// mustEncode encodes the nodes of a test file, which are always valid.
+func mustEncode(start uint64, nodes []csrfile.Node, opts csrfile.EncodeOptions) []byte {
+	data, err := csrfile.Encode(start, nodes, opts)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// encodeDirectoryCsr writes label directories for the nodes with at least
+// two edges.
+func encodeDirectoryCsr(start uint32, nodes []nodeEdges, _ bool) []byte {
+	return mustEncode(uint64(start), toFileNodes(nodes),
+		csrfile.EncodeOptions{Flags: csrfile.LabelDirectory, DirectoryMinEdges: 2})
+}
+
+func testGraph(byteOffsets bool) *memFetcher {
+	return testGraphWith(encodeCsr, byteOffsets)
+}
+
+func testGraphWith(encode func(uint32, []nodeEdges, bool) []byte, byteOffsets bool) *memFetcher {
+	return &memFetcher{objects: map[string][]byte{
+		"a": encode(0, []nodeEdges{
+			{out: []edge{{1, 2}, {1, 3}, {2, 4}}, in: []edge{{1, 3}}},
+			{out: []edge{}, in: []edge{{2, 3}}},
+		}, byteOffsets),
+		"b": encode(2, []nodeEdges{
+			{out: []edge{{2, 5}}, in: []edge{{1, 0}}},
+			{out: []edge{{1, 0}, {2, 1}}, in: []edge{{1, 0}}},
+		}, byteOffsets),
+	}}
+}
+
+func TestOffsetCsrNeighbours(t *testing.T) {
+	csr, err := NewOffsetCsr(testGraph(true), 0)
+	assert.Nil(t, err)
+	res, err := csr.GetNeighbours(context.Background(), Request{Node: 0, Label: 1, Direction: OUTGOING})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{2, 3}, res)
+	res, err = csr.GetNeighbours(context.Background(), Request{Node: 3, Label: 1, Direction: BOTH})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{0, 0}, res)
+	res, err = csr.GetNeighbours(context.Background(), Request{Node: 3, Label: 2, Direction: INCOMING})
+	assert.Nil(t, err)
+	assert.Empty(t, res)
+}
+
+func TestOffsetCsrErrors(t *testing.T) {
+	fetcher := testGraph(true)
+	csr, err := NewOffsetCsr(fetcher, 0)
+	assert.Nil(t, err)
+	_, err = csr.GetNeighbours(context.Background(), Request{Node: 9, Label: 1, Direction: OUTGOING})
+	assert.NotNil(t, err)
+
+	fetcher.failing = map[string]bool{"b": true}
+	results := csr.GetNeighboursBatch(context.Background(), []Request{
+		{Node: 0, Label: 1, Direction: OUTGOING},
+		{Node: 2, Label: 1, Direction: OUTGOING},
+	})
+	assert.Nil(t, results[0].Err)
+	assert.NotNil(t, results[1].Err)
+
+	_, err = NewOffsetCsr(fetcher, 0)
+	assert.NotNil(t, err)
+}
+
+func TestOffsetCsrCancelled(t *testing.T) {
+	csr, err := NewOffsetCsr(testGraph(true), 0)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = csr.GetNeighbours(ctx, Request{Node: 0, Label: 1, Direction: OUTGOING})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestLabelDirectory(t *testing.T) {
+	plain, err := NewOffsetCsr(testGraph(true), 0)
+	assert.Nil(t, err)
+	for _, withManifest := range []bool{false, true} {
+		fetcher := testGraphWith(encodeDirectoryCsr, true)
+		if withManifest {
+			m, err := ProbeManifest(fetcher, true)
+			assert.Nil(t, err)
+			fetcher.objects[ManifestObject] = m.Encode()
+		}
+		csr, err := NewOffsetCsr(fetcher, 0)
+		assert.Nil(t, err)
+		var requests []Request
+		for node := uint32(0); node < 4; node++ {
+			for label := uint32(0); label < 3; label++ {
+				for _, dir := range []Direction{INCOMING, OUTGOING, BOTH} {
+					requests = append(requests, Request{Node: node, Label: label, Direction: dir})
+				}
+			}
+		}
+		batch := csr.GetNeighboursBatch(context.Background(), requests)
+		for i, req := range requests {
+			expected, err := plain.GetNeighbours(context.Background(), req)
+			assert.Nil(t, err)
+			actual, err := csr.GetNeighbours(context.Background(), req)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, actual, "%v", req)
+			assert.Nil(t, batch[i].Err)
+			assert.Equal(t, expected, batch[i].Neighbours, "%v", req)
+		}
+		assert.NotContains(t, csr.GetStats(), "\"directoryReads\":0")
+
+		//Reading all the edges of the last node stops before the directories.
+		res := csr.fetchAllEdgesBatch(context.Background(), []uint32{3})
+		assert.Nil(t, res[0].err)
+		assert.Equal(t, []edge{{1, 0}, {2, 1}, {1, 0}}, res[0].edges)
+	}
+}
+
+func TestCorruptLabelDirectory(t *testing.T) {
+	fetcher := testGraphWith(encodeDirectoryCsr, true)
+	b := fetcher.objects["b"]
+	//Point the first entry of the last directory at the wrong edge.
+	binary.LittleEndian.PutUint32(b[len(b)-4-csrfile.DirectoryEntrySize+4:], 0)
+	csr, err := NewOffsetCsr(fetcher, 0)
+	assert.Nil(t, err)
+	_, err = csr.GetNeighbours(context.Background(), Request{Node: 3, Label: 2, Direction: INCOMING})
+	assert.True(t, errors.Is(err, csrfile.ErrCorrupt), "%v", err)
+
+	h, err := csrfile.ParseHeader(b)
+	assert.Nil(t, err)
+	b[h.DirectoryTableStart()] ^= 1
+	_, err = NewOffsetCsr(fetcher, 0)
+	assert.True(t, errors.Is(err, csrfile.ErrCorrupt), "%v", err)
+}
+
+// writeGraph stores the objects of the fetcher as files in dir.
+func writeGraph(tb testing.TB, dir string, graph *memFetcher) {
+	for name, obj := range graph.objects {
+		if err := os.WriteFile(filepath.Join(dir, name), obj, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkOffsetCsrMmap(b *testing.B) {
+	dir := b.TempDir()
+	writeGraph(b, dir, testGraph(true))
+	csr, err := NewOffsetCsr(storage.InitializeMmapService(dir), 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	req := Request{Node: 0, Label: 1, Direction: OUTGOING}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = csr.GetNeighbours(context.Background(), req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSimpleCsrMmap(b *testing.B) {
+	dir := b.TempDir()
+	writeGraph(b, dir, testGraph(false))
+	csr, err := NewSimpleCsr(storage.InitializeMmapService(dir))
+	if err != nil {
+		b.Fatal(err)
+	}
+	req := Request{Node: 0, Label: 1, Direction: OUTGOING}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = csr.GetNeighbours(context.Background(), req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package graphaccess
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+// PartitionsObject is the name of the object that describes the
+// partitions of a label partitioned bucket.
+const PartitionsObject = "_partitions"
+
+// Partition is a group of labels whose edges are stored in the objects
+// under Prefix, which are laid out like the objects of a bucket that is
+// not partitioned and have their own manifest. The default partition
+// stores the labels that are not in any other partition.
+type Partition struct {
+	Prefix  string   `json:"prefix"`
+	Labels  []uint32 `json:"labels,omitempty"`
+	Default bool     `json:"default,omitempty"`
+}
+
+// PartitionIndex is stored as JSON in the PartitionsObject.
+type PartitionIndex struct {
+	Partitions []Partition `json:"partitions"`
+}
+
+// statsMapper is implemented by the accessors, statsMap returns their
+// counters without those of the fetcher.
+type statsMapper interface {
+	statsMap() map[string]uint64
+}
+
+// PartitionedCsr reads a bucket in which the edges of every label are
+// stored in the objects of a single partition. Every partition has its
+// own accessor, which only reads the objects of that partition.
+type PartitionedCsr struct {
+	byLabel map[uint32]GraphAccess
+	//Accessor of the default partition, nil if there is none.
+	others     GraphAccess
+	partitions []GraphAccess
+	fetcher    storage.Fetcher
+}
+
+// NewPartitionedCsr reads the partition index of the bucket and creates
+// the accessor of every partition with newAccessor.
+func NewPartitionedCsr(fetcher storage.Fetcher,
+	newAccessor func(storage.Fetcher) (GraphAccess, error)) (*PartitionedCsr, error) {
+	data, err := fetcher.Fetch(PartitionsObject, storage.BRangeStart(0))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch partition index: %w", err)
+	}
+	var index PartitionIndex
+	if err = json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("Unable to decode partition index: %w", err)
+	}
+	if len(index.Partitions) == 0 {
+		return nil, errors.New("Partition index has no partitions")
+	}
+	csr := &PartitionedCsr{byLabel: make(map[uint32]GraphAccess), fetcher: fetcher}
+	for _, p := range index.Partitions {
+		accessor, err := newAccessor(storage.NewPrefixFetcher(fetcher, p.Prefix))
+		if err != nil {
+			return nil, fmt.Errorf("Unable to initialize partition %s: %w", p.Prefix, err)
+		}
+		csr.partitions = append(csr.partitions, accessor)
+		if p.Default {
+			if csr.others != nil {
+				return nil, errors.New("Partition index has more than one default partition")
+			}
+			csr.others = accessor
+		}
+		for _, label := range p.Labels {
+			if _, found := csr.byLabel[label]; found {
+				return nil, fmt.Errorf("Label %d is in more than one partition", label)
+			}
+			csr.byLabel[label] = accessor
+		}
+	}
+	log.Printf("Initialized %d partitions\n", len(csr.partitions))
+	return csr, nil
+}
+
+// partition returns the accessor of the partition that stores the label,
+// it is nil if no partition does.
+func (csr *PartitionedCsr) partition(label uint32) GraphAccess {
+	if accessor, found := csr.byLabel[label]; found {
+		return accessor
+	}
+	return csr.others
+}
+
+// GetNeighbours has no neighbours for a label that no partition stores.
+func (csr *PartitionedCsr) GetNeighbours(ctx context.Context, req Request) ([]uint32, error) {
+	accessor := csr.partition(req.Label)
+	if accessor == nil {
+		return []uint32{}, nil
+	}
+	return accessor.GetNeighbours(ctx, req)
+}
+
+func (csr *PartitionedCsr) GetNeighbours64(ctx context.Context, req Request64) ([]uint64, error) {
+	accessor := csr.partition(req.Label)
+	if accessor == nil {
+		return []uint64{}, nil
+	}
+	return accessor.GetNeighbours64(ctx, req)
+}
+
+// GetNeighboursBatch splits the requests by partition, the partitions are
+// read concurrently.
+func (csr *PartitionedCsr) GetNeighboursBatch(ctx context.Context, requests []Request) []BatchResult {
+	results := make([]BatchResult, len(requests))
+	byPartition := make(map[GraphAccess][]int)
+	for i, req := range requests {
+		accessor := csr.partition(req.Label)
+		if accessor == nil {
+			results[i].Neighbours = []uint32{}
+			continue
+		}
+		byPartition[accessor] = append(byPartition[accessor], i)
+	}
+	var wg sync.WaitGroup
+	for accessor, indices := range byPartition {
+		wg.Add(1)
+		go func(accessor GraphAccess, indices []int) {
+			defer wg.Done()
+			batch := make([]Request, len(indices))
+			for j, i := range indices {
+				batch[j] = requests[i]
+			}
+			for j, res := range accessor.GetNeighboursBatch(ctx, batch) {
+				results[indices[j]] = res
+			}
+		}(accessor, indices)
+	}
+	wg.Wait()
+	return results
+}
+
+// GetStats adds up the counters of all the partitions, the counters of
+// the fetcher that they share are only added once.
+func (csr *PartitionedCsr) GetStats() string {
+	stats := make(map[string]uint64)
+	for _, accessor := range csr.partitions {
+		mapper, ok := accessor.(statsMapper)
+		if !ok {
+			continue
+		}
+		for k, v := range mapper.statsMap() {
+			stats[k] += v
+		}
+	}
+	stats["partitions"] = uint64(len(csr.partitions))
+	return statsWithFetcher(stats, csr.fetcher)
+}
@@ -0,0 +1,102 @@
+package graphaccess
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/adityachandla/graph_access_service/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// partitionedGraph stores the edges with label 1 of testGraph in one
+// partition and all the others in the default partition.
+func partitionedGraph(t *testing.T) *memFetcher {
+	filter := func(edges []edge, keep func(uint32) bool) []edge {
+		res := []edge{}
+		for _, e := range edges {
+			if keep(e.label) {
+				res = append(res, e)
+			}
+		}
+		return res
+	}
+	nodes := map[string][]nodeEdges{
+		"a": {
+			{out: []edge{{1, 2}, {1, 3}, {2, 4}}, in: []edge{{1, 3}}},
+			{out: []edge{}, in: []edge{{2, 3}}},
+		},
+		"b": {
+			{out: []edge{{2, 5}}, in: []edge{{1, 0}}},
+			{out: []edge{{1, 0}, {2, 1}}, in: []edge{{1, 0}}},
+		},
+	}
+	start := map[string]uint32{"a": 0, "b": 2}
+	index := PartitionIndex{Partitions: []Partition{
+		{Prefix: "labels-1/", Labels: []uint32{1}},
+		{Prefix: "labels-other/", Default: true},
+	}}
+	graph := &memFetcher{objects: make(map[string][]byte)}
+	for name, fileNodes := range nodes {
+		for _, p := range index.Partitions {
+			keep := func(label uint32) bool { return (label == 1) == !p.Default }
+			split := make([]nodeEdges, len(fileNodes))
+			for i, n := range fileNodes {
+				split[i] = nodeEdges{out: filter(n.out, keep), in: filter(n.in, keep)}
+			}
+			graph.objects[p.Prefix+name] = encodeVersionedCsr(start[name], split, true)
+		}
+	}
+	indexBytes, err := json.Marshal(index)
+	assert.Nil(t, err)
+	graph.objects[PartitionsObject] = indexBytes
+	return graph
+}
+
+func TestPartitionedCsr(t *testing.T) {
+	plain, err := NewOffsetCsr(testGraph(true), 0)
+	assert.Nil(t, err)
+	graph := partitionedGraph(t)
+	csr, err := NewPartitionedCsr(graph, func(fetcher storage.Fetcher) (GraphAccess, error) {
+		return NewOffsetCsr(fetcher, 0)
+	})
+	assert.Nil(t, err)
+	var requests []Request
+	for node := uint32(0); node < 4; node++ {
+		for label := uint32(0); label < 3; label++ {
+			for _, dir := range []Direction{INCOMING, OUTGOING, BOTH} {
+				requests = append(requests, Request{Node: node, Label: label, Direction: dir})
+			}
+		}
+	}
+	batch := csr.GetNeighboursBatch(context.Background(), requests)
+	for i, req := range requests {
+		expected, err := plain.GetNeighbours(context.Background(), req)
+		assert.Nil(t, err)
+		actual, err := csr.GetNeighbours(context.Background(), req)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, actual, "%v", req)
+		assert.Nil(t, batch[i].Err)
+		assert.Equal(t, expected, batch[i].Neighbours, "%v", req)
+	}
+	assert.Contains(t, csr.GetStats(), "\"partitions\":2")
+
+	//Requests for label 1 only read the objects of its partition.
+	graph.failing = map[string]bool{"labels-other/a": true, "labels-other/b": true}
+	res, err := csr.GetNeighbours(context.Background(), Request{Node: 0, Label: 1, Direction: OUTGOING})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{2, 3}, res)
+}
+
+func TestPartitionIndexErrors(t *testing.T) {
+	newOffset := func(fetcher storage.Fetcher) (GraphAccess, error) {
+		return NewOffsetCsr(fetcher, 0)
+	}
+	_, err := NewPartitionedCsr(testGraph(true), newOffset)
+	assert.NotNil(t, err)
+
+	graph := partitionedGraph(t)
+	graph.objects[PartitionsObject] = []byte(`{"partitions":[{"prefix":"labels-1/","labels":[1]},{"prefix":"labels-other/","labels":[1]}]}`)
+	_, err = NewPartitionedCsr(graph, newOffset)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,228 @@
+package graphaccess
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"math"
+
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+// PermutationObject is the name of the object that maps the node IDs of a
+// relabeled graph back to the IDs it was built with.
+const PermutationObject = "_permutation"
+
+const (
+	permutationMagic      = "GPRM"
+	permutationVersion    = 1
+	permutationHeaderSize = 24
+)
+
+// Permutation maps the original IDs of the nodes from Start to Start+n-1
+// to the IDs that they are stored with, nodes outside of that range keep
+// their IDs.
+//
+// It is stored as the magic "GPRM", a version and flags, each of them 2
+// bytes, the start node and the number of nodes, each of them 8 bytes,
+// the new ID of every node minus the start node in 4 bytes and the CRC of
+// everything before it. All integers are little endian.
+type Permutation struct {
+	Start uint64
+	//Stored ID minus Start of every original ID minus Start.
+	toNew []uint32
+	//Original ID minus Start of every stored ID minus Start.
+	toOld []uint32
+}
+
+// NewPermutation checks that newIndex, the new ID minus start of every
+// node, maps every node of the range to a different node of the range.
+func NewPermutation(start uint64, newIndex []uint32) (*Permutation, error) {
+	if uint64(len(newIndex)) > math.MaxUint32+1 {
+		return nil, fmt.Errorf("Permutation of %d nodes has more than 2^32 nodes", len(newIndex))
+	}
+	if start > math.MaxUint64-uint64(len(newIndex)) {
+		return nil, fmt.Errorf("Permutation of %d nodes from %d overflows", len(newIndex), start)
+	}
+	p := &Permutation{Start: start, toNew: newIndex, toOld: make([]uint32, len(newIndex))}
+	seen := make([]bool, len(newIndex))
+	for old, idx := range newIndex {
+		if int(idx) >= len(newIndex) || seen[idx] {
+			return nil, fmt.Errorf("Node %d is mapped to an invalid or repeated node %d",
+				start+uint64(old), start+uint64(idx))
+		}
+		seen[idx] = true
+		p.toOld[idx] = uint32(old)
+	}
+	return p, nil
+}
+
+func (p *Permutation) NumNodes() int {
+	return len(p.toNew)
+}
+
+func (p *Permutation) contains(id uint64) bool {
+	return id >= p.Start && id-p.Start < uint64(len(p.toNew))
+}
+
+// ToNew returns the ID that the node with the original ID is stored with.
+func (p *Permutation) ToNew(id uint64) uint64 {
+	if !p.contains(id) {
+		return id
+	}
+	return p.Start + uint64(p.toNew[id-p.Start])
+}
+
+// ToOld returns the original ID of the node that is stored with the ID.
+func (p *Permutation) ToOld(id uint64) uint64 {
+	if !p.contains(id) {
+		return id
+	}
+	return p.Start + uint64(p.toOld[id-p.Start])
+}
+
+func (p *Permutation) Encode() []byte {
+	res := make([]byte, 0, permutationHeaderSize+4*len(p.toNew)+4)
+	res = append(res, permutationMagic...)
+	res = binary.LittleEndian.AppendUint16(res, permutationVersion)
+	res = binary.LittleEndian.AppendUint16(res, 0)
+	res = binary.LittleEndian.AppendUint64(res, p.Start)
+	res = binary.LittleEndian.AppendUint64(res, uint64(len(p.toNew)))
+	for _, idx := range p.toNew {
+		res = binary.LittleEndian.AppendUint32(res, idx)
+	}
+	return binary.LittleEndian.AppendUint32(res, crc32.ChecksumIEEE(res))
+}
+
+func DecodePermutation(data []byte) (*Permutation, error) {
+	if len(data) < permutationHeaderSize+4 || string(data[:4]) != permutationMagic {
+		return nil, errors.New("Permutation does not start with the magic bytes")
+	}
+	if version := binary.LittleEndian.Uint16(data[4:6]); version != permutationVersion {
+		return nil, fmt.Errorf("Unsupported permutation version %d", version)
+	}
+	start := binary.LittleEndian.Uint64(data[8:16])
+	numNodes := binary.LittleEndian.Uint64(data[16:24])
+	if size := uint64(len(data)) - permutationHeaderSize - 4; size%4 != 0 || size/4 != numNodes {
+		return nil, fmt.Errorf("Permutation of %d nodes is %d bytes long", numNodes, len(data))
+	}
+	crcPos := len(data) - 4
+	if binary.LittleEndian.Uint32(data[crcPos:]) != crc32.ChecksumIEEE(data[:crcPos]) {
+		return nil, errors.New("Permutation checksum mismatch")
+	}
+	newIndex := make([]uint32, numNodes)
+	for i := range newIndex {
+		newIndex[i] = binary.LittleEndian.Uint32(data[permutationHeaderSize+4*i:])
+	}
+	return NewPermutation(start, newIndex)
+}
+
+// LoadPermutation reads the permutation of the graph, it is nil if the
+// graph was not relabeled.
+func LoadPermutation(fetcher storage.Fetcher) (*Permutation, error) {
+	data, err := fetcher.Fetch(PermutationObject, storage.BRangeStart(0))
+	if storage.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch permutation: %w", err)
+	}
+	p, err := DecodePermutation(data)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Loaded permutation of %d nodes\n", p.NumNodes())
+	return p, nil
+}
+
+// PermutedCsr lets clients use the original IDs of a relabeled graph. The
+// nodes of the requests are translated to the IDs they are stored with
+// and the neighbours back to the original IDs, in the order in which
+// they are stored.
+type PermutedCsr struct {
+	accessor GraphAccess
+	perm     *Permutation
+}
+
+func NewPermutedCsr(accessor GraphAccess, perm *Permutation) *PermutedCsr {
+	return &PermutedCsr{accessor: accessor, perm: perm}
+}
+
+func (csr *PermutedCsr) toNew32(id uint32) (uint32, error) {
+	res := csr.perm.ToNew(uint64(id))
+	if res > math.MaxUint32 {
+		return 0, fmt.Errorf("Node %d is stored as %d, which does not fit in 4 byte node IDs", id, res)
+	}
+	return uint32(res), nil
+}
+
+// toOld32 translates the neighbours into a new slice, the accessors can
+// return slices that they keep cached.
+func (csr *PermutedCsr) toOld32(neighbours []uint32) ([]uint32, error) {
+	res := make([]uint32, len(neighbours))
+	for i, n := range neighbours {
+		old := csr.perm.ToOld(uint64(n))
+		if old > math.MaxUint32 {
+			return nil, fmt.Errorf("Neighbour %d does not fit in 4 byte node IDs", old)
+		}
+		res[i] = uint32(old)
+	}
+	return res, nil
+}
+
+func (csr *PermutedCsr) GetNeighbours(ctx context.Context, req Request) ([]uint32, error) {
+	node, err := csr.toNew32(req.Node)
+	if err != nil {
+		return nil, err
+	}
+	req.Node = node
+	neighbours, err := csr.accessor.GetNeighbours(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return csr.toOld32(neighbours)
+}
+
+func (csr *PermutedCsr) GetNeighbours64(ctx context.Context, req Request64) ([]uint64, error) {
+	req.Node = csr.perm.ToNew(req.Node)
+	neighbours, err := csr.accessor.GetNeighbours64(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]uint64, len(neighbours))
+	for i, n := range neighbours {
+		res[i] = csr.perm.ToOld(n)
+	}
+	return res, nil
+}
+
+func (csr *PermutedCsr) GetNeighboursBatch(ctx context.Context, requests []Request) []BatchResult {
+	results := make([]BatchResult, len(requests))
+	translated := make([]Request, 0, len(requests))
+	//Index of every translated request in requests.
+	indices := make([]int, 0, len(requests))
+	for i, req := range requests {
+		node, err := csr.toNew32(req.Node)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		req.Node = node
+		translated = append(translated, req)
+		indices = append(indices, i)
+	}
+	for j, res := range csr.accessor.GetNeighboursBatch(ctx, translated) {
+		if res.Err == nil {
+			res.Neighbours, res.Err = csr.toOld32(res.Neighbours)
+		}
+		results[indices[j]] = res
+	}
+	return results
+}
+
+func (csr *PermutedCsr) GetStats() string {
+	return csr.accessor.GetStats()
+}
@@ -0,0 +1,76 @@
+package graphaccess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermutationEncoding(t *testing.T) {
+	p, err := NewPermutation(1, []uint32{2, 0, 1})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), p.ToNew(1))
+	assert.Equal(t, uint64(1), p.ToOld(3))
+	assert.Equal(t, uint64(10), p.ToNew(10))
+	assert.Equal(t, uint64(0), p.ToOld(0))
+
+	decoded, err := DecodePermutation(p.Encode())
+	assert.Nil(t, err)
+	assert.Equal(t, p, decoded)
+	data := p.Encode()
+	data[permutationHeaderSize]++
+	_, err = DecodePermutation(data)
+	assert.NotNil(t, err)
+	_, err = DecodePermutation(p.Encode()[:permutationHeaderSize+4])
+	assert.NotNil(t, err)
+
+	_, err = NewPermutation(0, []uint32{0, 0})
+	assert.NotNil(t, err)
+	_, err = NewPermutation(0, []uint32{2, 0})
+	assert.NotNil(t, err)
+}
+
+func TestLoadPermutation(t *testing.T) {
+	graph := testGraph(true)
+	p, err := LoadPermutation(graph)
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+
+	p, _ = NewPermutation(0, []uint32{1, 0})
+	graph.objects[PermutationObject] = p.Encode()
+	loaded, err := LoadPermutation(graph)
+	assert.Nil(t, err)
+	assert.Equal(t, p, loaded)
+}
+
+func TestPermutedCsr(t *testing.T) {
+	//The original graph is 1 -> {2, 3}, 2 -> {1}, which is stored with
+	//1, 2 and 3 relabeled to 3, 1 and 2.
+	p, err := NewPermutation(1, []uint32{2, 0, 1})
+	assert.Nil(t, err)
+	stored := mapAccess{3: {1, 2}, 1: {3}, 10: {1}}
+	csr := NewPermutedCsr(stored, p)
+	ctx := context.Background()
+
+	res, err := csr.GetNeighbours(ctx, Request{Node: 1, Label: 1, Direction: OUTGOING})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{2, 3}, res)
+	assert.Equal(t, []uint32{1, 2}, stored[3])
+	res64, err := csr.GetNeighbours64(ctx, Request64{Node: 2, Label: 1, Direction: OUTGOING})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{1}, res64)
+
+	batch := csr.GetNeighboursBatch(ctx, []Request{{Node: 10}, {Node: 3}, {Node: 2}})
+	assert.Equal(t, []uint32{2}, batch[0].Neighbours)
+	assert.Empty(t, batch[1].Neighbours)
+	assert.Equal(t, []uint32{1}, batch[2].Neighbours)
+
+	var visited []uint32
+	err = Traverse(ctx, csr, []uint32{2}, 2, []uint32{1}, OUTGOING, func(v Visit) error {
+		visited = append(visited, v.Node)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{2, 1, 3}, visited)
+}
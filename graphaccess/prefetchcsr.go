@@ -1,94 +1,288 @@
 package graphaccess
 
 import (
-	"encoding/json"
+	"context"
+	"fmt"
 	"github.com/adityachandla/graph_access_service/caches"
+	"github.com/adityachandla/graph_access_service/csrfile"
 	"github.com/adityachandla/graph_access_service/storage"
+	"maps"
 	"sync/atomic"
+	"unsafe"
 )
 
 const NumFetchers = 5
 
+// PrefetchCacheSize is the default number of responses cached by
+// PrefetchCsr.
+const PrefetchCacheSize = 1000
+
+// PrefetchedNodes is the number of prefetched nodes that PrefetchCsr
+// caches if its caches are not limited by bytes.
+const PrefetchedNodes = 100
+
+// PrefetchShare is the share of the byte budget of PrefetchCsr that holds
+// the prefetched edges, 1/PrefetchShare of the bytes, the rest holds the
+// responses.
+const PrefetchShare = 8
+
+// PrefetchBurst is the maximum number of nodes that a prefetch routine
+// fetches at once.
+const PrefetchBurst = 16
+
 type PrefetchCsr struct {
 	offsetCsr  *OffsetCsr
 	prefetcher *Prefetcher
-	cache      *caches.Lrfu[Request, []uint32]
-	stats      PrefetchStats
+	//prefetchPlanner reads the nodes of the prefetcher, the GETs of the
+	//planner of offsetCsr are the ones made for requests.
+	prefetchPlanner *readPlanner
+	cache           caches.Cache[Request, []uint32]
+	//cacheBytes is set if the cache is limited by the bytes of the
+	//responses.
+	cacheBytes bool
+	//Requests that are being fetched from S3.
+	inFlight *caches.InFlight[Request, []uint32]
+	stats    PrefetchStats
 }
 
 type PrefetchStats struct {
 	CacheHits      atomic.Uint32
 	PrefetcherHits atomic.Uint32
 	InFlightHits   atomic.Uint32
-	S3Fetches      atomic.Uint32
+	Coalesced      atomic.Uint32
 }
 
 func (s *PrefetchStats) convertToString() string {
-	res := make(map[string]uint32, 4)
-	res["cacheHits"] = s.CacheHits.Load()
-	res["prefetcherHits"] = s.PrefetcherHits.Load()
-	res["inFlightHits"] = s.InFlightHits.Load()
-	res["S3Fetches"] = s.S3Fetches.Load()
-	resultBytes, err := json.Marshal(res)
-	if err != nil {
-		panic(err)
-	}
-	return string(resultBytes)
+	return statsToString(s.toMap())
+}
+
+func (s *PrefetchStats) toMap() map[string]uint64 {
+	res := make(map[string]uint64, 4)
+	res["cacheHits"] = uint64(s.CacheHits.Load())
+	res["prefetcherHits"] = uint64(s.PrefetcherHits.Load())
+	res["inFlightHits"] = uint64(s.InFlightHits.Load())
+	res["coalesced"] = uint64(s.Coalesced.Load())
+	return res
+}
+
+func responseBytes(neighbours []uint32) int64 {
+	return int64(unsafe.Sizeof(neighbours)) + 4*int64(len(neighbours))
+}
+
+// NewPrefetchCsr creates a PrefetchCsr, maxReadGap is passed on to the
+// OffsetCsr that it fetches nodes with. Up to PrefetchCacheSize responses
+// are cached by LRFU.
+func NewPrefetchCsr(fetcher storage.Fetcher, maxReadGap int) (*PrefetchCsr, error) {
+	return NewPrefetchCsrWithCache(fetcher, maxReadGap, CacheConfig{})
 }
 
-func NewPrefetchCsr(fetcher storage.Fetcher) *PrefetchCsr {
+// NewPrefetchCsrWithCache is NewPrefetchCsr with the policy and the number
+// of responses of the cache of the config. If the config has a byte
+// budget, it is shared by the responses and the prefetched edges.
+func NewPrefetchCsrWithCache(fetcher storage.Fetcher, maxReadGap int, cacheConfig CacheConfig) (*PrefetchCsr, error) {
+	prefetchCache := caches.NewPrefetchCache[uint32, []edge](PrefetchedNodes)
+	if cacheConfig.Bytes > 0 {
+		prefetchBytes := max(cacheConfig.Bytes/PrefetchShare, 1)
+		prefetchCache = caches.NewWeightedPrefetchCache[uint32, []edge](prefetchBytes, edgesBytes)
+		cacheConfig.Bytes = max(cacheConfig.Bytes-prefetchBytes, 1)
+	}
+	cache, err := newCache[Request, []uint32](cacheConfig, caches.PolicyLRFU, PrefetchCacheSize, responseBytes)
+	if err != nil {
+		return nil, err
+	}
+	offsetCsr, err := NewOffsetCsr(fetcher, maxReadGap)
+	if err != nil {
+		return nil, err
+	}
 	p := &PrefetchCsr{
-		offsetCsr: NewOffsetCsr(fetcher),
-		cache:     caches.NewLrfuCache[Request, []uint32](1000, 0.2),
+		offsetCsr:  offsetCsr,
+		cache:      cache,
+		cacheBytes: cacheConfig.Bytes > 0,
+		inFlight:   caches.NewInFlight[Request, []uint32](),
 	}
-	p.prefetcher = NewPrefetcher(NumFetchers, 100, p.offsetCsr.fetchAllEdges)
-	return p
+	p.prefetchPlanner = newReadPlanner(fetcher, maxReadGap)
+	p.prefetcher = newPrefetcher(NumFetchers, PrefetchBurst, prefetchCache, func(ctx context.Context, nodes []uint32) []fetchResult {
+		return p.offsetCsr.fetchAllEdgesWith(ctx, p.prefetchPlanner, nodes)
+	})
+	return p, nil
 }
 
-func (p *PrefetchCsr) GetNeighbours(req Request) []uint32 {
-	response := p.fetchResponse(req)
+// GetNeighbours hands the neighbours to the prefetcher once the request
+// is done. They are abandoned once ctx is done, unless it was detached
+// with DetachPrefetch.
+func (p *PrefetchCsr) GetNeighbours(ctx context.Context, req Request) ([]uint32, error) {
+	response, err := p.fetchResponse(ctx, req)
+	if err != nil {
+		return nil, err
+	}
 	if !p.cache.Present(req) {
 		p.cache.Put(req, response)
 	}
-	p.prefetcher.write(response)
-	return response
+	p.prefetcher.write(prefetchContext(ctx), response)
+	return response, nil
+}
+
+// GetNeighboursBatch answers the requests that are cached or being
+// prefetched, the rest are fetched together by the OffsetCsr unless the
+// same requests are already being fetched. The neighbours of the whole
+// batch are handed to the prefetcher at once, so a traversal that is
+// cancelled drops the prefetch of its next level.
+func (p *PrefetchCsr) GetNeighboursBatch(ctx context.Context, requests []Request) []BatchResult {
+	results := make([]BatchResult, len(requests))
+	missing := make([]Request, 0)
+	missingIdx := make([]int, 0)
+	for i, req := range requests {
+		response, found, err := p.fetchCached(ctx, req)
+		if err != nil || found {
+			results[i] = BatchResult{Neighbours: response, Err: err}
+			continue
+		}
+		missing = append(missing, req)
+		missingIdx = append(missingIdx, i)
+	}
+	if len(missing) > 0 {
+		responses, shared, errs := p.inFlight.DoBatch(ctx, missing, p.fetchBatch)
+		for j, i := range missingIdx {
+			if shared[j] {
+				p.stats.Coalesced.Add(1)
+			}
+			results[i] = BatchResult{Neighbours: responses[j], Err: errs[j]}
+		}
+	}
+	var neighbours []uint32
+	for i, res := range results {
+		if res.Err != nil {
+			continue
+		}
+		if !p.cache.Present(requests[i]) {
+			p.cache.Put(requests[i], res.Neighbours)
+		}
+		neighbours = append(neighbours, res.Neighbours...)
+	}
+	p.prefetcher.write(prefetchContext(ctx), neighbours)
+	return results
+}
+
+type detachedPrefetchKey struct{}
+
+// DetachPrefetch returns a context whose requests keep prefetching the
+// neighbours they found after ctx is done. The context of a unary RPC is
+// cancelled as soon as it returns, while the neighbours of a lookup are
+// prefetched for the lookups that follow it.
+func DetachPrefetch(ctx context.Context) context.Context {
+	return context.WithValue(ctx, detachedPrefetchKey{}, true)
+}
+
+// prefetchContext is the context the prefetch of the neighbours found by
+// a request is abandoned with.
+func prefetchContext(ctx context.Context) context.Context {
+	if detached, _ := ctx.Value(detachedPrefetchKey{}).(bool); detached {
+		return context.WithoutCancel(ctx)
+	}
+	return ctx
+}
+
+// GetNeighbours64 reads nodes of files with 8 byte node IDs with the
+// OffsetCsr, they are neither cached nor prefetched.
+func (p *PrefetchCsr) GetNeighbours64(ctx context.Context, req Request64) ([]uint64, error) {
+	if _, err := p.offsetCsr.wide.find(req.Node); err == nil {
+		return p.offsetCsr.GetNeighbours64(ctx, req)
+	}
+	return getNeighbours64(ctx, req, p.GetNeighbours)
 }
 
 func (p *PrefetchCsr) GetStats() string {
-	return p.stats.convertToString()
+	return statsWithFetcher(p.statsMap(), p.offsetCsr.fetcher)
 }
 
-func (p *PrefetchCsr) fetchResponse(req Request) []uint32 {
-	//Check the LRFU cache
+func (p *PrefetchCsr) statsMap() map[string]uint64 {
+	stats := p.stats.toMap()
+	maps.Copy(stats, p.offsetCsr.statsMap())
+	stats["S3Fetches"] = p.offsetCsr.planner.gets.Load()
+	stats["prefetchFetches"] = p.prefetchPlanner.gets.Load()
+	stats["mergedReads"] += p.prefetchPlanner.mergedReads.Load()
+	stats["bytesFetched"] += p.prefetchPlanner.bytesFetched.Load()
+	stats["cachedResponses"] = uint64(p.cache.Len())
+	if p.cacheBytes {
+		stats["cacheBytes"] = uint64(p.cache.Weight())
+		stats["prefetchCacheBytes"] = uint64(p.prefetcher.prefetchCache.Weight())
+	}
+	return stats
+}
+
+// fetchBatch reads the requests with a single batch of the OffsetCsr.
+func (p *PrefetchCsr) fetchBatch(ctx context.Context, requests []Request) ([][]uint32, []error) {
+	responses := make([][]uint32, len(requests))
+	errs := make([]error, len(requests))
+	for i, res := range p.offsetCsr.GetNeighboursBatch(ctx, requests) {
+		responses[i], errs[i] = res.Neighbours, res.Err
+	}
+	return responses, errs
+}
+
+func (p *PrefetchCsr) fetchResponse(ctx context.Context, req Request) ([]uint32, error) {
+	response, found, err := p.fetchCached(ctx, req)
+	if err != nil || found {
+		return response, err
+	}
+	//Fetch from S3, unless the same request is already being fetched.
+	response, shared, err := p.inFlight.Do(ctx, req, func(ctx context.Context) ([]uint32, error) {
+		return p.offsetCsr.GetNeighbours(ctx, req)
+	})
+	if shared {
+		p.stats.Coalesced.Add(1)
+	}
+	return response, err
+}
+
+// fetchCached answers the request from the caches or from a prefetch
+// that is in flight, found is false if the request has to be fetched.
+func (p *PrefetchCsr) fetchCached(ctx context.Context, req Request) ([]uint32, bool, error) {
+	//Check the response cache
 	response, found := p.cache.Get(req)
 	if found {
 		p.stats.CacheHits.Add(1)
-		return response
+		return response, true, nil
 	}
 	//Then check the Prefetcher cache
 	edges, found := p.prefetcher.getFromPrefetchCache(req.Node)
 	if found {
 		p.stats.PrefetcherHits.Add(1)
-		return filterResponse(req, edges, p.offsetCsr.offsets)
+		response, err := filterResponse(req, edges, p.offsetCsr.offsets)
+		return response, true, err
 	}
 	//Then check the in-flight queue
 	edgesFuture, found := p.prefetcher.getFromInFlightQueue(req.Node)
 	if found {
-		p.stats.InFlightHits.Add(1)
-		return filterResponse(req, edgesFuture.get(), p.offsetCsr.offsets)
+		result, err := edgesFuture.getContext(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		//If the prefetch failed we fall back to fetching it ourselves.
+		if result.err == nil {
+			p.stats.InFlightHits.Add(1)
+			response, err := filterResponse(req, result.edges, p.offsetCsr.offsets)
+			return response, true, err
+		}
 	}
-	//Fetch from S3
-	p.stats.S3Fetches.Add(1)
-	return p.offsetCsr.GetNeighbours(req)
+	return nil, false, nil
 }
 
-func filterResponse(req Request, edges []edge, offsets fileOffsets) []uint32 {
-	_, numOutgoing := offsets.find(req.Node).fetchOffset(req)
+func filterResponse(req Request, edges []edge, offsets fileOffsets) ([]uint32, error) {
+	file, err := offsets.find(req.Node)
+	if err != nil {
+		return nil, err
+	}
+	numOutgoing := file.numOutgoing(req.Node)
+	if int(numOutgoing) > len(edges) {
+		return nil, fmt.Errorf("Unable to read %s: %w: node %d has fewer edges than its offsets",
+			file.nodeRange.objectName, csrfile.ErrCorrupt, req.Node)
+	}
 	if req.Direction == OUTGOING {
-		return getEdgesWithLabel(edges[:numOutgoing], req.Label)
+		return getEdgesWithLabel(edges[:numOutgoing], req.Label), nil
 	} else if req.Direction == INCOMING {
-		return getEdgesWithLabel(edges[numOutgoing:], req.Label)
+		return getEdgesWithLabel(edges[numOutgoing:], req.Label), nil
 	}
 	outgoing := getEdgesWithLabel(edges[:numOutgoing], req.Label)
-	return append(outgoing, getEdgesWithLabel(edges[numOutgoing:], req.Label)...)
+	return append(outgoing, getEdgesWithLabel(edges[numOutgoing:], req.Label)...), nil
 }
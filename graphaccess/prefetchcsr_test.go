@@ -1,8 +1,14 @@
 package graphaccess
 
 import (
-	"github.com/stretchr/testify/assert"
+	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/adityachandla/graph_access_service/storage"
+	"github.com/stretchr/testify/assert"
 )
 
 // Channel creation takes about 40 ns.
@@ -16,6 +22,98 @@ func BenchmarkFutureCreation(b *testing.B) {
 
 func TestMarshalling(t *testing.T) {
 	stats := PrefetchStats{}
-	assert.Equal(t, "{\"S3Fetches\":0,\"cacheHits\":0,\"inFlightHits\":0,\"prefetcherHits\":0}",
+	assert.Equal(t, "{\"cacheHits\":0,\"coalesced\":0,\"inFlightHits\":0,\"prefetcherHits\":0}",
 		stats.convertToString())
 }
+
+// gatedFetcher holds fetches until gate is closed, if gate is set.
+type gatedFetcher struct {
+	*memFetcher
+	gate    chan struct{}
+	entered atomic.Int32
+}
+
+func (g *gatedFetcher) FetchContext(ctx context.Context, objectName string, bRange storage.ByteRange) ([]byte, error) {
+	if g.gate != nil {
+		g.entered.Add(1)
+		<-g.gate
+	}
+	return g.memFetcher.FetchContext(ctx, objectName, bRange)
+}
+
+func TestPrefetchCsrBatchCoalesced(t *testing.T) {
+	fetcher := &gatedFetcher{memFetcher: testGraph(true)}
+	p, err := NewPrefetchCsr(fetcher, 64)
+	assert.Nil(t, err)
+	fetcher.gate = make(chan struct{})
+	requests := []Request{
+		{Node: 0, Label: 1, Direction: OUTGOING},
+		{Node: 1, Label: 1, Direction: OUTGOING},
+		{Node: 3, Label: 1, Direction: BOTH},
+	}
+	results := make([][]BatchResult, 2)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = p.GetNeighboursBatch(context.Background(), requests)
+		}(i)
+		//The second batch starts while the first one is fetching.
+		for fetcher.entered.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(fetcher.gate)
+	wg.Wait()
+	assert.Equal(t, results[0], results[1])
+	stats := p.statsMap()
+	assert.Equal(t, uint64(len(requests)), stats["coalesced"])
+	//Nodes 0 and 1 are read with one GET, node 3 is in the other object.
+	assert.Equal(t, uint64(2), stats["S3Fetches"])
+}
+
+func TestPrefetchCsrAbandonsCancelledTraversal(t *testing.T) {
+	p, err := NewPrefetchCsr(testGraph(true), 64)
+	assert.Nil(t, err)
+	fetched := make(chan []uint32, 10)
+	gate := make(chan struct{})
+	p.prefetcher = NewPrefetcher(1, PrefetchBurst, 100, func(_ context.Context, nodes []uint32) []fetchResult {
+		fetched <- nodes
+		<-gate
+		return make([]fetchResult, len(nodes))
+	})
+	//The routine is busy so that the nodes written below stay queued.
+	p.prefetcher.write(context.Background(), []uint32{100})
+	assert.Equal(t, []uint32{100}, <-fetched)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err = Traverse(ctx, p, []uint32{0}, 1, []uint32{1}, OUTGOING, func(Visit) error { return nil })
+	assert.Nil(t, err)
+	cancel()
+	ctx, cancel = context.WithCancel(context.Background())
+	res, err := p.GetNeighbours(DetachPrefetch(ctx), Request{Node: 3, Label: 1, Direction: BOTH})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{0, 0}, res)
+	cancel()
+	close(gate)
+	//The next level of the traversal, nodes 2 and 3, is never fetched.
+	assert.Equal(t, []uint32{0}, <-fetched)
+	assert.Empty(t, fetched)
+}
+
+func TestPrefetchCsrByteBudget(t *testing.T) {
+	p, err := NewPrefetchCsrWithCache(testGraph(true), 64, CacheConfig{Bytes: 800})
+	assert.Nil(t, err)
+	_, err = p.GetNeighbours(context.Background(), Request{Node: 0, Label: 1, Direction: OUTGOING})
+	assert.Nil(t, err)
+	//Nodes 2 and 3 are prefetched into the share of the budget.
+	for p.prefetcher.prefetchCache.Len() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	stats := p.statsMap()
+	assert.Greater(t, stats["prefetchCacheBytes"], uint64(0))
+	assert.LessOrEqual(t, stats["prefetchCacheBytes"], uint64(800/PrefetchShare))
+	assert.LessOrEqual(t, stats["cacheBytes"], uint64(800-800/PrefetchShare))
+}
@@ -1,29 +1,67 @@
 package graphaccess
 
 import (
+	"context"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
 	"github.com/adityachandla/graph_access_service/caches"
 	"github.com/adityachandla/graph_access_service/lists"
-	"sync"
 )
 
 type Prefetcher struct {
-	inFlightIds []uint32
-	edgesFuture []*future[[]edge]
+	//Every routine fetches a burst of nodes at a time.
+	inFlightIds [][]uint32
+	edgesFuture [][]*future[fetchResult]
 	locks       []sync.Mutex
+	burstSize   int
 
 	prefetchCache *caches.PrefetchCache[uint32, []edge]
-	prefetchQueue *lists.CircularQueue[uint32]
-	//This function will fetch all edges for a node.
-	fetcher func(uint32) []edge
+	prefetchQueue *lists.CircularQueue[prefetchItem]
+	//This function will fetch all edges for each of the nodes.
+	fetcher func(context.Context, []uint32) []fetchResult
+}
+
+// prefetchItem is a node that should be prefetched on behalf of the
+// request that owns ctx. Once ctx is done the prefetch is abandoned.
+type prefetchItem struct {
+	node uint32
+	ctx  context.Context
+}
+
+// fetchResult is the outcome of a prefetch. Requests waiting on a failed
+// prefetch have to fetch the edges themselves.
+type fetchResult struct {
+	edges []edge
+	err   error
 }
 
-func NewPrefetcher(numThreads int, prefetchCacheSize int, fetcher func(uint32) []edge) *Prefetcher {
+// NewPrefetcher starts numThreads routines that each fetch up to
+// burstSize queued nodes with a single call to fetcher. Up to
+// prefetchCacheSize prefetched nodes are cached.
+func NewPrefetcher(numThreads, burstSize, prefetchCacheSize int,
+	fetcher func(context.Context, []uint32) []fetchResult) *Prefetcher {
+	return newPrefetcher(numThreads, burstSize, caches.NewPrefetchCache[uint32, []edge](prefetchCacheSize), fetcher)
+}
+
+// edgesBytes is the number of bytes held by the prefetched edges of a
+// node.
+func edgesBytes(edges []edge) int64 {
+	return int64(unsafe.Sizeof(edges)) + int64(unsafe.Sizeof(edge{}))*int64(len(edges))
+}
+
+func newPrefetcher(numThreads, burstSize int, prefetchCache *caches.PrefetchCache[uint32, []edge],
+	fetcher func(context.Context, []uint32) []fetchResult) *Prefetcher {
 	pf := &Prefetcher{
-		inFlightIds:   make([]uint32, numThreads),
-		edgesFuture:   make([]*future[[]edge], numThreads),
+		inFlightIds:   make([][]uint32, numThreads),
+		edgesFuture:   make([][]*future[fetchResult], numThreads),
 		locks:         make([]sync.Mutex, numThreads),
-		prefetchCache: caches.NewPrefetchCache[uint32, []edge](prefetchCacheSize),
-		prefetchQueue: lists.NewCircularQueue[uint32](100),
+		burstSize:     max(burstSize, 1),
+		prefetchCache: prefetchCache,
+		prefetchQueue: lists.NewCircularQueue[prefetchItem](100),
 		fetcher:       fetcher,
 	}
 	for i := 0; i < numThreads; i++ {
@@ -32,28 +70,116 @@ func NewPrefetcher(numThreads int, prefetchCacheSize int, fetcher func(uint32) [
 	return pf
 }
 
-func (pf *Prefetcher) write(result []uint32) {
-	pf.prefetchQueue.Write(result)
+func (pf *Prefetcher) write(ctx context.Context, result []uint32) {
+	items := make([]prefetchItem, len(result))
+	for i, node := range result {
+		items[i] = prefetchItem{node: node, ctx: ctx}
+	}
+	pf.prefetchQueue.Write(items)
 }
 
 func (pf *Prefetcher) prefetchRoutine(index int) {
 	for {
-		val := pf.prefetchQueue.Read()
+		nodes := make([]uint32, 0, pf.burstSize)
+		ctxs := make([]context.Context, 0, pf.burstSize)
+		//Nodes that are already queued are fetched with this one, whichever
+		//request wrote them. The nodes of requests that are done are
+		//dropped.
+		item := pf.prefetchQueue.Read()
+		for {
+			if item.ctx.Err() == nil {
+				if added := pf.addToBurst(nodes, item.node); len(added) > len(nodes) {
+					nodes = added
+					ctxs = append(ctxs, item.ctx)
+				}
+			}
+			if len(nodes) == pf.burstSize {
+				break
+			}
+			var ok bool
+			if item, ok = pf.prefetchQueue.TryRead(); !ok {
+				break
+			}
+		}
+		if len(nodes) == 0 {
+			continue
+		}
+		ctx, cancel := burstContext(ctxs)
+		pf.fetchBurst(index, ctx, nodes)
+		cancel()
+	}
+}
+
+// burstContext returns a context that is done once all the contexts are,
+// so that a burst is fetched as long as one of the requests that wrote
+// its nodes is not done.
+func burstContext(ctxs []context.Context) (context.Context, context.CancelFunc) {
+	if len(ctxs) == 1 {
+		return ctxs[0], func() {}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var live atomic.Int32
+	live.Store(int32(len(ctxs)))
+	stops := make([]func() bool, len(ctxs))
+	for i, c := range ctxs {
+		stops[i] = context.AfterFunc(c, func() {
+			if live.Add(-1) == 0 {
+				cancel()
+			}
+		})
+	}
+	return ctx, func() {
+		for _, stop := range stops {
+			stop()
+		}
+		cancel()
+	}
+}
 
-		pf.locks[index].Lock()
-		pf.inFlightIds[index] = val
-		pf.edgesFuture[index] = newFuture[[]edge]()
-		pf.locks[index].Unlock()
+// addToBurst adds the node to the burst unless it is already cached,
+// being fetched or in the burst. The same node can be written more than
+// once, for example by concurrent requests for nodes with common
+// neighbours.
+func (pf *Prefetcher) addToBurst(nodes []uint32, node uint32) []uint32 {
+	if slices.Contains(nodes, node) || pf.prefetchCache.Present(node) {
+		return nodes
+	}
+	if _, found := pf.getFromInFlightQueue(node); found {
+		return nodes
+	}
+	return append(nodes, node)
+}
 
-		resultEdges := pf.fetcher(val)
+func (pf *Prefetcher) fetchBurst(index int, ctx context.Context, nodes []uint32) {
+	futures := make([]*future[fetchResult], len(nodes))
+	for i := range futures {
+		futures[i] = newFuture[fetchResult]()
+	}
+	pf.locks[index].Lock()
+	pf.inFlightIds[index] = nodes
+	pf.edgesFuture[index] = futures
+	pf.locks[index].Unlock()
 
-		pf.locks[index].Lock()
-		pf.edgesFuture[index].put(resultEdges)
-		pf.inFlightIds[index] = 0
-		pf.edgesFuture[index] = nil
-		pf.locks[index].Unlock()
+	results := pf.fetcher(ctx, nodes)
 
-		pf.prefetchCache.Put(val, resultEdges)
+	pf.locks[index].Lock()
+	for i, res := range results {
+		futures[i].put(res)
+	}
+	pf.inFlightIds[index] = nil
+	pf.edgesFuture[index] = nil
+	pf.locks[index].Unlock()
+
+	for i, res := range results {
+		if res.err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Unable to prefetch %d: %s\n", nodes[i], res.err)
+			}
+			continue
+		}
+		//The edges can be a view of a merged read, they are copied so
+		//that the cache does not hold on to the whole read.
+		pf.prefetchCache.Put(nodes[i], slices.Clone(res.edges))
 	}
 }
 
@@ -61,11 +187,11 @@ func (pf *Prefetcher) getFromPrefetchCache(node uint32) ([]edge, bool) {
 	return pf.prefetchCache.Get(node)
 }
 
-func (pf *Prefetcher) getFromInFlightQueue(node uint32) (*future[[]edge], bool) {
+func (pf *Prefetcher) getFromInFlightQueue(node uint32) (*future[fetchResult], bool) {
 	for i := 0; i < len(pf.inFlightIds); i++ {
 		pf.locks[i].Lock()
-		if pf.inFlightIds[i] == node {
-			res := pf.edgesFuture[i]
+		if idx := slices.Index(pf.inFlightIds[i], node); idx >= 0 {
+			res := pf.edgesFuture[i][idx]
 			pf.locks[i].Unlock()
 			return res, true
 		}
@@ -1,31 +1,39 @@
 package graphaccess
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
+	"runtime"
 	"testing"
 	"time"
 )
 
-func fetcher(num uint32) []edge {
+func fetcher(_ context.Context, nums []uint32) []fetchResult {
 	time.Sleep(10 * time.Millisecond)
-	return []edge{{1, num}, {1, num + 1}, {1, num + 3}}
+	res := make([]fetchResult, len(nums))
+	for i, num := range nums {
+		res[i].edges = []edge{{1, num}, {1, num + 1}, {1, num + 3}}
+	}
+	return res
 }
 
 func TestPrefetchFunctionality(t *testing.T) {
-	pf := NewPrefetcher(2, 10, fetcher)
+	pf := NewPrefetcher(2, 1, 10, fetcher)
 	go func() {
 		for i := 1; i <= 10; i++ {
-			pf.write([]uint32{uint32(i)})
+			pf.write(context.Background(), []uint32{uint32(i)})
 		}
 	}()
 	for i := 1; i <= 10; i += 2 {
 		//i and i+1 should be in flight
 		f, found := pf.getFromInFlightQueue(uint32(i))
 		for !found {
+			runtime.Gosched()
 			f, found = pf.getFromInFlightQueue(uint32(i))
 		}
 		f1, found := pf.getFromInFlightQueue(uint32(i + 1))
 		for !found {
+			runtime.Gosched()
 			f1, found = pf.getFromInFlightQueue(uint32(i + 1))
 		}
 		f.get()
@@ -37,3 +45,66 @@ func TestPrefetchFunctionality(t *testing.T) {
 		assert.Equal(t, 3, len(v))
 	}
 }
+
+func TestPrefetchAbandoned(t *testing.T) {
+	fetched := make(chan uint32, 10)
+	pf := NewPrefetcher(1, 1, 10, func(_ context.Context, nodes []uint32) []fetchResult {
+		fetched <- nodes[0]
+		return make([]fetchResult, 1)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pf.write(ctx, []uint32{1, 2})
+	pf.write(context.Background(), []uint32{3})
+	assert.Equal(t, uint32(3), <-fetched)
+	assert.Empty(t, fetched)
+}
+
+func TestPrefetchBurst(t *testing.T) {
+	bursts := make(chan []uint32, 10)
+	block := make(chan struct{})
+	pf := NewPrefetcher(1, 4, 10, func(_ context.Context, nodes []uint32) []fetchResult {
+		bursts <- nodes
+		<-block
+		return make([]fetchResult, len(nodes))
+	})
+	pf.write(context.Background(), []uint32{1})
+	assert.Equal(t, []uint32{1}, <-bursts)
+	//Queued while the first burst is being fetched.
+	pf.write(context.Background(), []uint32{2, 3, 3, 1})
+	close(block)
+	assert.Equal(t, []uint32{3, 2}, <-bursts)
+	for _, node := range []uint32{1, 2, 3} {
+		_, found := pf.getFromPrefetchCache(node)
+		for !found {
+			runtime.Gosched()
+			_, found = pf.getFromPrefetchCache(node)
+		}
+	}
+}
+
+func TestPrefetchBurstOfRequests(t *testing.T) {
+	bursts := make(chan []uint32, 10)
+	block := make(chan struct{})
+	var burstCtx context.Context
+	pf := NewPrefetcher(1, 4, 10, func(ctx context.Context, nodes []uint32) []fetchResult {
+		burstCtx = ctx
+		bursts <- nodes
+		<-block
+		return make([]fetchResult, len(nodes))
+	})
+	pf.write(context.Background(), []uint32{1})
+	assert.Equal(t, []uint32{1}, <-bursts)
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+	pf.write(first, []uint32{2})
+	pf.write(second, []uint32{3})
+	block <- struct{}{}
+	//The nodes of both requests are fetched together, until both are done.
+	assert.Equal(t, []uint32{2, 3}, <-bursts)
+	cancelFirst()
+	assert.Nil(t, burstCtx.Err())
+	cancelSecond()
+	<-burstCtx.Done()
+	close(block)
+}
@@ -0,0 +1,164 @@
+package graphaccess
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync/atomic"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+// rangeRead is a byte range of a single node that has to be read from
+// an object. An end of 0 reads till the end of the object.
+type rangeRead struct {
+	objectName string
+	start, end uint64
+}
+
+func newRangeRead(objectName string, bRange storage.ByteRange) rangeRead {
+	start, end := bRange.Bounds()
+	return rangeRead{objectName, start, end}
+}
+
+// isEmpty is true for nodes without edges in the range, their start is
+// past their end.
+func (r rangeRead) isEmpty() bool {
+	return r.end != 0 && r.end < r.start
+}
+
+// mergedRead is a single GET that serves all the reads in it.
+type mergedRead struct {
+	objectName string
+	start, end uint64
+	reads      []int
+}
+
+// MaxMergedReadBytes is the largest range that reads are merged into, a
+// single read that is larger is still fetched on its own. It should only
+// be changed before the accessors are used.
+var MaxMergedReadBytes int64 = 1 << 20
+
+// readPlanner groups the reads of nodes that are stored close to each
+// other in the same object into single range GETs.
+type readPlanner struct {
+	fetcher storage.Fetcher
+	//Reads with fewer than maxGap bytes between them are merged. A
+	//negative maxGap turns off merging.
+	maxGap int
+	//Number of GETs that were saved by merging reads.
+	mergedReads atomic.Uint64
+	//Number of bytes of edges that were fetched.
+	bytesFetched atomic.Uint64
+	//Number of GETs.
+	gets atomic.Uint64
+}
+
+func newReadPlanner(fetcher storage.Fetcher, maxGap int) *readPlanner {
+	return &readPlanner{fetcher: fetcher, maxGap: maxGap}
+}
+
+// plan sorts the reads by object and offset and merges the reads whose
+// gap is below maxGap, as long as the merged range stays within
+// MaxMergedReadBytes.
+func (rp *readPlanner) plan(reads []rangeRead) []mergedRead {
+	order := make([]int, 0, len(reads))
+	for i, r := range reads {
+		if !r.isEmpty() {
+			order = append(order, i)
+		}
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		if c := cmp.Compare(reads[a].objectName, reads[b].objectName); c != 0 {
+			return c
+		}
+		return cmp.Compare(reads[a].start, reads[b].start)
+	})
+	plan := make([]mergedRead, 0, len(order))
+	for _, idx := range order {
+		r := reads[idx]
+		if len(plan) > 0 && rp.canMerge(&plan[len(plan)-1], r) {
+			last := &plan[len(plan)-1]
+			if r.end == 0 || r.end > last.end {
+				last.end = r.end
+			}
+			last.reads = append(last.reads, idx)
+			continue
+		}
+		plan = append(plan, mergedRead{r.objectName, r.start, r.end, []int{idx}})
+	}
+	return plan
+}
+
+func (rp *readPlanner) canMerge(m *mergedRead, r rangeRead) bool {
+	if rp.maxGap < 0 || m.objectName != r.objectName {
+		return false
+	}
+	//Reads till the end of the object include everything after them.
+	if m.end == 0 {
+		return true
+	}
+	if r.end != 0 && int64(max(r.end, m.end)-m.start+1) > MaxMergedReadBytes {
+		return false
+	}
+	return int64(r.start) <= int64(m.end)+1+int64(rp.maxGap)
+}
+
+// read fetches all the reads and returns the bytes of every read in the
+// order of the reads. At most MaxBatchFetches GETs run at once. A failed
+// GET fails all the reads that it serves.
+func (rp *readPlanner) read(ctx context.Context, reads []rangeRead) ([][]byte, []error) {
+	data := make([][]byte, len(reads))
+	errs := make([]error, len(reads))
+	plan := rp.plan(reads)
+	for _, m := range plan {
+		rp.mergedReads.Add(uint64(len(m.reads) - 1))
+	}
+	forEachLimited(len(plan), func(i int) {
+		m := plan[i]
+		bRange := storage.BRange(m.start, m.end)
+		if m.end == 0 {
+			bRange = storage.BRangeStart(m.start)
+		}
+		resultBytes, err := rp.fetch(ctx, m.objectName, bRange)
+		for _, idx := range m.reads {
+			if err != nil {
+				errs[idx] = err
+				continue
+			}
+			data[idx], errs[idx] = splitRead(m.objectName, resultBytes, m.start, reads[idx])
+		}
+	})
+	return data, errs
+}
+
+func (rp *readPlanner) stats() map[string]uint64 {
+	return map[string]uint64{
+		"mergedReads":  rp.mergedReads.Load(),
+		"bytesFetched": rp.bytesFetched.Load(),
+	}
+}
+
+// fetch reads a single range and counts the fetched bytes.
+func (rp *readPlanner) fetch(ctx context.Context, objectName string, bRange storage.ByteRange) ([]byte, error) {
+	rp.gets.Add(1)
+	resultBytes, err := rp.fetcher.FetchContext(ctx, objectName, bRange)
+	rp.bytesFetched.Add(uint64(len(resultBytes)))
+	return resultBytes, err
+}
+
+// splitRead returns the bytes of r from the bytes fetched starting at
+// offset start. Fetched bytes that end before r are a corrupt object.
+func splitRead(objectName string, fetched []byte, start uint64, r rangeRead) ([]byte, error) {
+	from := int(r.start - start)
+	if r.end == 0 && from <= len(fetched) {
+		return fetched[from:], nil
+	}
+	if r.end != 0 && int(r.end-start) < len(fetched) {
+		return fetched[from : r.end-start+1], nil
+	}
+	return nil, fmt.Errorf("Unable to read %s: %w: %d bytes were fetched from offset %d, the range %d-%d is past them",
+		objectName, csrfile.ErrCorrupt, len(fetched), start, r.start, r.end)
+}
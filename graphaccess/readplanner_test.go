@@ -0,0 +1,91 @@
+package graphaccess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanMergesCloseReads(t *testing.T) {
+	rp := newReadPlanner(nil, 10)
+	plan := rp.plan([]rangeRead{
+		{"a", 100, 119},
+		{"b", 0, 7},
+		{"a", 40, 79},
+		{"a", 90, 89},
+		{"a", 131, 0},
+		{"a", 80, 95},
+	})
+	assert.Equal(t, []mergedRead{
+		{"a", 40, 119, []int{2, 5, 0}},
+		{"a", 131, 0, []int{4}},
+		{"b", 0, 7, []int{1}},
+	}, plan)
+
+	rp.maxGap = -1
+	assert.Len(t, rp.plan([]rangeRead{{"a", 0, 7}, {"a", 8, 15}}), 2)
+}
+
+func TestPlanCapsMergedReads(t *testing.T) {
+	defer func(old int64) { MaxMergedReadBytes = old }(MaxMergedReadBytes)
+	MaxMergedReadBytes = 32
+	rp := newReadPlanner(nil, 10)
+	plan := rp.plan([]rangeRead{{"a", 0, 15}, {"a", 16, 31}, {"a", 32, 47}, {"a", 48, 99}})
+	assert.Equal(t, []mergedRead{
+		{"a", 0, 31, []int{0, 1}},
+		{"a", 32, 47, []int{2}},
+		{"a", 48, 99, []int{3}},
+	}, plan)
+}
+
+func TestShortReadIsCorrupt(t *testing.T) {
+	_, err := splitRead("a", make([]byte, 8), 0, rangeRead{"a", 4, 11})
+	assert.ErrorIs(t, err, csrfile.ErrCorrupt)
+	_, err = splitRead("a", make([]byte, 8), 0, rangeRead{"a", 9, 0})
+	assert.ErrorIs(t, err, csrfile.ErrCorrupt)
+	data, err := splitRead("a", make([]byte, 8), 0, rangeRead{"a", 4, 7})
+	assert.Nil(t, err)
+	assert.Len(t, data, 4)
+}
+
+func TestOffsetCsrBatchMerged(t *testing.T) {
+	fetcher := testGraph(true)
+	csr, err := NewOffsetCsr(fetcher, 64)
+	assert.Nil(t, err)
+	requests := []Request{
+		{Node: 0, Label: 1, Direction: OUTGOING},
+		{Node: 1, Label: 2, Direction: INCOMING},
+		{Node: 3, Label: 1, Direction: BOTH},
+		{Node: 2, Label: 2, Direction: OUTGOING},
+		{Node: 1, Label: 1, Direction: OUTGOING},
+	}
+	fetcher.fetches.Store(0)
+	results := csr.GetNeighboursBatch(context.Background(), requests)
+	//One GET per object.
+	assert.Equal(t, int32(2), fetcher.fetches.Load())
+	for i, req := range requests {
+		expected, err := csr.GetNeighbours(context.Background(), req)
+		assert.Nil(t, err)
+		assert.Nil(t, results[i].Err)
+		assert.Equal(t, len(expected), len(results[i].Neighbours))
+		assert.ElementsMatch(t, expected, results[i].Neighbours)
+	}
+	assert.Contains(t, csr.GetStats(), "\"mergedReads\":2")
+}
+
+func TestFetchAllEdgesBatch(t *testing.T) {
+	csr, err := NewOffsetCsr(testGraph(true), 0)
+	assert.Nil(t, err)
+	results := csr.fetchAllEdgesBatch(context.Background(), []uint32{0, 9, 3})
+	assert.Nil(t, results[0].err)
+	assert.Equal(t, []edge{{1, 2}, {1, 3}, {2, 4}, {1, 3}}, results[0].edges)
+	assert.NotNil(t, results[1].err)
+	assert.Equal(t, []edge{{1, 0}, {2, 1}, {1, 0}}, results[2].edges)
+
+	incoming, err := filterResponse(Request{Node: 0, Label: 1, Direction: INCOMING},
+		results[0].edges, csr.offsets)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{3}, incoming)
+}
@@ -1,16 +1,17 @@
 package graphaccess
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
+	"math"
 	"slices"
-	"sync"
 	"sync/atomic"
 	"unsafe"
 
 	"github.com/adityachandla/graph_access_service/bin_util"
 	"github.com/adityachandla/graph_access_service/caches"
+	"github.com/adityachandla/graph_access_service/csrfile"
 	"github.com/adityachandla/graph_access_service/storage"
 )
 
@@ -18,25 +19,32 @@ const LruSizeFiles = 7
 
 type Csr struct {
 	nodePaths []nodeRangePath
-	lru       *caches.LRU[string, csrRepr]
-	fetcher   storage.Fetcher
-	stats     CsrStats
+	lru       caches.Cache[string, csrRepr]
+	//lruBytes is set if the lru is limited by the bytes of the files.
+	lruBytes bool
+	//Objects that are being fetched, concurrent misses on the same
+	//object wait for the same fetch.
+	inFlight *caches.InFlight[string, csrRepr]
+	fetcher  storage.Fetcher
+	stats    CsrStats
 }
 
 type CsrStats struct {
 	CacheHits atomic.Uint32
 	S3Fetches atomic.Uint32
+	Coalesced atomic.Uint32
 }
 
 func (s *CsrStats) convertToString() string {
-	res := make(map[string]uint32)
-	res["cacheHits"] = s.CacheHits.Load()
-	res["S3Fetches"] = s.S3Fetches.Load()
-	resultBytes, err := json.Marshal(res)
-	if err != nil {
-		panic(err)
-	}
-	return string(resultBytes)
+	return statsToString(s.toMap())
+}
+
+func (s *CsrStats) toMap() map[string]uint64 {
+	res := make(map[string]uint64)
+	res["cacheHits"] = uint64(s.CacheHits.Load())
+	res["S3Fetches"] = uint64(s.S3Fetches.Load())
+	res["coalesced"] = uint64(s.Coalesced.Load())
+	return res
 }
 
 type csrRepr struct {
@@ -49,69 +57,182 @@ type nodeIndex struct {
 	outgoing, incoming uint32
 }
 
-func NewSimpleCsr(fetcher storage.Fetcher) *Csr {
-	objects := fetcher.ListFiles()
-	//For each object, we need to fetch the start and end stored in that file.
-	//Start and end will be the first 8 bytes of the file.
-	nodePaths := make([]nodeRangePath, len(objects))
-	bRange := storage.BRange(0, 7)
-	var wg sync.WaitGroup
-	for i := range objects {
-		idx := i
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			startEndBytes := fetcher.Fetch(objects[idx], bRange)
-			nodePaths[idx].start = bin_util.ByteToUint(startEndBytes[:4])
-			nodePaths[idx].end = bin_util.ByteToUint(startEndBytes[4:])
-			nodePaths[idx].objectName = objects[idx]
-		}()
-	}
-	wg.Wait()
+func csrReprBytes(repr csrRepr) int64 {
+	return int64(unsafe.Sizeof(repr)) +
+		int64(len(repr.indices))*int64(unsafe.Sizeof(nodeIndex{})) +
+		int64(len(repr.edges))*int64(unsafe.Sizeof(edge{}))
+}
+
+// NewSimpleCsr reads the node ranges of all the files from the manifest,
+// or from the files themselves if there is no manifest. The last
+// LruSizeFiles files that were read are cached.
+func NewSimpleCsr(fetcher storage.Fetcher) (*Csr, error) {
+	return NewSimpleCsrWithCache(fetcher, CacheConfig{})
+}
+
+// NewSimpleCsrWithCache is NewSimpleCsr with the policy and the number of
+// files of the cache of the config.
+func NewSimpleCsrWithCache(fetcher storage.Fetcher, cacheConfig CacheConfig) (*Csr, error) {
+	lru, err := newCache[string, csrRepr](cacheConfig, caches.PolicyLRU, LruSizeFiles, csrReprBytes)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := loadManifest(fetcher, false)
+	if err != nil {
+		return nil, err
+	}
+	nodePaths := make([]nodeRangePath, len(manifest.Entries))
+	for i, e := range manifest.Entries {
+		//Truncated ranges could overlap those of other files.
+		if e.Header.IDWidth != 4 || e.Header.End > math.MaxUint32 {
+			return nil, fmt.Errorf("%s stores nodes %d to %d with %d byte IDs, the simple Csr only reads 4 byte node IDs",
+				e.ObjectName, e.Header.Start, e.Header.End, e.Header.IDWidth)
+		}
+		nodePaths[i] = nodeRangePath{start: uint32(e.Header.Start), end: uint32(e.Header.End), objectName: e.ObjectName}
+	}
 	log.Println("Initialized simple Csr")
 	slices.SortFunc(nodePaths, nodeCmp)
 	return &Csr{
 		nodePaths: nodePaths,
-		lru:       caches.NewLRU[string, csrRepr](LruSizeFiles),
+		lru:       lru,
+		lruBytes:  cacheConfig.Bytes > 0,
+		inFlight:  caches.NewInFlight[string, csrRepr](),
 		fetcher:   fetcher,
+	}, nil
+}
+
+func (scsr *Csr) GetNeighbours(ctx context.Context, req Request) ([]uint32, error) {
+	objectName, err := scsr.getObjectWithNode(req.Node)
+	if err != nil {
+		return nil, err
 	}
+	csrRepr, err := scsr.getRepr(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+	return csrRepr.getEdges(req), nil
 }
 
-func (scsr *Csr) GetNeighbours(req Request) []uint32 {
-	objectName := scsr.getObjectWithNode(req.Node)
-	csrRepr, found := scsr.lru.Get(objectName)
-	if !found {
-		scsr.stats.S3Fetches.Add(1)
-		csrRepr = scsr.fetch(objectName)
-		scsr.lru.Put(objectName, csrRepr)
-	} else {
+// GetNeighboursBatch groups the requests by the object that stores them
+// so that every object is fetched only once for the whole batch. The
+// objects themselves are fetched concurrently, at most MaxBatchFetches
+// at a time.
+func (scsr *Csr) GetNeighboursBatch(ctx context.Context, requests []Request) []BatchResult {
+	results := make([]BatchResult, len(requests))
+	byObject := make(map[string][]int)
+	var objects []string
+	for i, req := range requests {
+		objectName, err := scsr.getObjectWithNode(req.Node)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if _, ok := byObject[objectName]; !ok {
+			objects = append(objects, objectName)
+		}
+		byObject[objectName] = append(byObject[objectName], i)
+	}
+	forEachLimited(len(objects), func(j int) {
+		csrRepr, err := scsr.getRepr(ctx, objects[j])
+		for _, idx := range byObject[objects[j]] {
+			if err != nil {
+				results[idx].Err = err
+			} else {
+				results[idx].Neighbours = csrRepr.getEdges(requests[idx])
+			}
+		}
+	})
+	return results
+}
+
+func (scsr *Csr) getRepr(ctx context.Context, objectName string) (csrRepr, error) {
+	repr, found := scsr.lru.Get(objectName)
+	if found {
 		scsr.stats.CacheHits.Add(1)
+		return repr, nil
+	}
+	repr, shared, err := scsr.inFlight.Do(ctx, objectName, func(ctx context.Context) (csrRepr, error) {
+		scsr.stats.S3Fetches.Add(1)
+		fetched, err := scsr.fetch(ctx, objectName)
+		if err == nil {
+			scsr.lru.Put(objectName, fetched)
+		}
+		return fetched, err
+	})
+	if shared {
+		scsr.stats.Coalesced.Add(1)
 	}
-	return csrRepr.getEdges(req)
+	return repr, err
+}
+
+// GetNeighbours64 only reads files with 4 byte node IDs.
+func (scsr *Csr) GetNeighbours64(ctx context.Context, req Request64) ([]uint64, error) {
+	return getNeighbours64(ctx, req, scsr.GetNeighbours)
 }
 
 func (scsr *Csr) GetStats() string {
-	return scsr.stats.convertToString()
+	return statsWithFetcher(scsr.statsMap(), scsr.fetcher)
+}
+
+func (scsr *Csr) statsMap() map[string]uint64 {
+	stats := scsr.stats.toMap()
+	stats["cachedFiles"] = uint64(scsr.lru.Len())
+	if scsr.lruBytes {
+		stats["cacheBytes"] = uint64(scsr.lru.Weight())
+	}
+	return stats
 }
 
-func (scsr *Csr) fetch(objectName string) csrRepr {
+func (scsr *Csr) fetch(ctx context.Context, objectName string) (csrRepr, error) {
 	log.Printf("Fetching %s\n", objectName)
-	fileBytes := scsr.fetcher.Fetch(objectName, storage.BRangeStart(0))
-	start := bin_util.ByteToUint(fileBytes[:4])
-	end := bin_util.ByteToUint(fileBytes[4:8])
-	numValues := end - start + 1
-	sizeOfIndices := 2 * SizeIntBytes * numValues
-	nodeIndices := bin_util.ByteArrayToPairArray(fileBytes[8 : 8+sizeOfIndices])
-	pairs := bin_util.ByteArrayToPairArray(fileBytes[8+sizeOfIndices:])
+	fileBytes, err := scsr.fetcher.FetchContext(ctx, objectName, storage.BRangeStart(0))
+	if err != nil {
+		return csrRepr{}, err
+	}
+	h, offsets, edges, err := splitSimpleFile(fileBytes)
+	if err != nil {
+		return csrRepr{}, fmt.Errorf("Unable to read %s: %w", objectName, err)
+	}
+	nodeIndices := bin_util.PairArrayView(offsets)
+	pairs := bin_util.PairArrayView(edges)
 	//The memory layout of pair is same as edge so, it is safe to
 	//do a direct typecast.
 	pairPtr := unsafe.Pointer(&pairs)
 	nodeIndexPtr := unsafe.Pointer(&nodeIndices)
 	return csrRepr{
-		startNodeId: start,
+		startNodeId: uint32(h.Start),
 		indices:     *(*[]nodeIndex)(nodeIndexPtr),
 		edges:       *(*[]edge)(pairPtr),
+	}, nil
+}
+
+// splitSimpleFile validates the file and returns its header, offset
+// table and edges. Once the file is valid every index in the offset table
+// is within the edges.
+func splitSimpleFile(fileBytes []byte) (csrfile.Header, []byte, []byte, error) {
+	h, err := csrfile.ParseHeader(fileBytes)
+	if err != nil {
+		return h, nil, nil, err
+	}
+	if uint64(len(fileBytes)) < h.EdgesStart() {
+		return h, nil, nil, fmt.Errorf("%w: file is only %d bytes long but its edges start at %d",
+			csrfile.ErrCorrupt, len(fileBytes), h.EdgesStart())
+	}
+	offsets := fileBytes[h.OffsetTableStart():h.EdgesStart()]
+	edges := fileBytes[h.EdgesStart():]
+	if err = h.InferLayout(offsets); err != nil {
+		return h, nil, nil, err
+	}
+	if h.Layout() != csrfile.LayoutIndices {
+		return h, nil, nil, errWrongLayout(h.Layout())
+	}
+	if err = h.ValidateEdges(edges); err != nil {
+		return h, nil, nil, err
+	}
+	if err = h.ValidateOffsets(offsets); err != nil {
+		return h, nil, nil, err
 	}
+	return h, offsets, edges, nil
 }
 
 func (repr *csrRepr) getEdges(req Request) []uint32 {
@@ -157,20 +278,20 @@ func (repr *csrRepr) getEndEdgeIndex(nodeId uint32, incoming bool) uint32 {
 	return repr.indices[index].incoming
 }
 
-func (scsr *Csr) getObjectWithNode(src uint32) string {
+func (scsr *Csr) getObjectWithNode(src uint32) (string, error) {
 	start := 0
 	end := len(scsr.nodePaths) - 1
 	for start <= end {
 		mid := (start + end) / 2
 		if scsr.nodePaths[mid].contains(src) {
-			return scsr.nodePaths[mid].objectName
+			return scsr.nodePaths[mid].objectName, nil
 		} else if scsr.nodePaths[mid].start > src {
 			end = mid - 1
 		} else {
 			start = mid + 1
 		}
 	}
-	panic(fmt.Errorf("%d not found in nodeRanges", src))
+	return "", fmt.Errorf("%d not found in nodeRanges", src)
 }
 
 func nodeCmp(one, two nodeRangePath) int {
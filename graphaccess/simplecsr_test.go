@@ -1,7 +1,13 @@
 package graphaccess
 
 import (
+	"context"
+	"encoding/binary"
+	"errors"
 	"testing"
+
+	"github.com/adityachandla/graph_access_service/caches"
+	"github.com/adityachandla/graph_access_service/csrfile"
 )
 
 func TestGraphReprEdges(t *testing.T) {
@@ -25,3 +31,113 @@ func assertLength[K any](t *testing.T, slice []K, size int) {
 		t.Fatalf("Expected size %d, got %d", size, len(slice))
 	}
 }
+
+func TestSimpleCsrMatchesOffsetCsr(t *testing.T) {
+	for _, encode := range []func(uint32, []nodeEdges, bool) []byte{encodeCsr, encodeVersionedCsr} {
+		compareAccessors(t, testGraphWith(encode, false), testGraphWith(encode, true))
+	}
+}
+
+func compareAccessors(t *testing.T, simpleGraph, offsetGraph *memFetcher) {
+	simple, err := NewSimpleCsr(simpleGraph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, err := NewOffsetCsr(offsetGraph, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for node := uint32(0); node < 4; node++ {
+		for _, dir := range []Direction{INCOMING, OUTGOING, BOTH} {
+			req := Request{Node: node, Label: 1, Direction: dir}
+			expected, _ := offset.GetNeighbours(context.Background(), req)
+			actual, err := simple.GetNeighbours(context.Background(), req)
+			if err != nil || !arrayEqual(expected, actual) {
+				t.Fatalf("Mismatch for %v: %v %v", req, expected, actual)
+			}
+		}
+	}
+}
+
+func TestSimpleCsrCachePolicies(t *testing.T) {
+	offset, err := NewOffsetCsr(testGraphWith(encodeVersionedCsr, true), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, policy := range []caches.Policy{caches.PolicyLRU, caches.PolicyLRFU, caches.PolicyARC, caches.PolicyTinyLFU} {
+		simple, err := NewSimpleCsrWithCache(testGraphWith(encodeVersionedCsr, false), CacheConfig{Policy: policy, Size: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for node := uint32(0); node < 4; node++ {
+			req := Request{Node: node, Label: 1, Direction: BOTH}
+			expected, _ := offset.GetNeighbours(context.Background(), req)
+			actual, err := simple.GetNeighbours(context.Background(), req)
+			if err != nil || !arrayEqual(expected, actual) {
+				t.Fatalf("Mismatch for %v with %s: %v %v", req, policy, expected, actual)
+			}
+		}
+	}
+	//A budget smaller than a file caches nothing.
+	for _, budget := range []int64{1, 1 << 20} {
+		simple, err := NewSimpleCsrWithCache(testGraphWith(encodeVersionedCsr, false), CacheConfig{Bytes: budget})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = simple.GetNeighbours(context.Background(), Request{Node: 0, Label: 1, Direction: OUTGOING})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats := simple.statsMap()
+		if (stats["cacheBytes"] > 0) != (budget > 1) || stats["cacheBytes"] > uint64(budget) {
+			t.Fatalf("Unexpected cache bytes %d for a budget of %d", stats["cacheBytes"], budget)
+		}
+	}
+	_, err = NewSimpleCsrWithCache(testGraph(false), CacheConfig{Policy: "fifo"})
+	if err == nil {
+		t.Fatal("Expected an error for an unknown policy")
+	}
+}
+
+func TestCorruptFiles(t *testing.T) {
+	graph := testGraphWith(encodeVersionedCsr, false)
+	graph.objects["b"] = graph.objects["b"][:len(graph.objects["b"])-3]
+	simple, err := NewSimpleCsr(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = simple.GetNeighbours(context.Background(), Request{Node: 3, Label: 1, Direction: INCOMING})
+	if !errors.Is(err, csrfile.ErrCorrupt) {
+		t.Fatalf("Expected a corrupt file error, got %v", err)
+	}
+
+	//Offsets past the edges of a legacy file.
+	graph = testGraph(false)
+	binary.LittleEndian.PutUint32(graph.objects["a"][16:], 9)
+	simple, err = NewSimpleCsr(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = simple.GetNeighbours(context.Background(), Request{Node: 0, Label: 1, Direction: OUTGOING})
+	if !errors.Is(err, csrfile.ErrCorrupt) {
+		t.Fatalf("Expected a corrupt file error, got %v", err)
+	}
+
+	graph = testGraphWith(encodeVersionedCsr, true)
+	graph.objects["a"][csrfile.HeaderSize] ^= 1
+	if _, err = NewOffsetCsr(graph, 0); !errors.Is(err, csrfile.ErrCorrupt) {
+		t.Fatalf("Expected a corrupt file error, got %v", err)
+	}
+
+	//Files in the layout of the other accessor.
+	if _, err = NewOffsetCsr(testGraph(false), 0); err == nil {
+		t.Fatal("Offset accessor read a file with edge indices")
+	}
+	simple, err = NewSimpleCsr(testGraphWith(encodeVersionedCsr, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = simple.GetNeighbours(context.Background(), Request{Node: 0, Label: 1, Direction: OUTGOING}); err == nil {
+		t.Fatal("Simple accessor read a file with byte offsets")
+	}
+}
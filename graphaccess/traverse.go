@@ -0,0 +1,66 @@
+package graphaccess
+
+import "context"
+
+// Visit is a node reached by Traverse. Parent is the node through which
+// Node was first reached, seeds are their own parents.
+type Visit struct {
+	Node, Depth, Parent uint32
+}
+
+// Traverse runs a breadth first search from the seeds following edges
+// with any of the labels in the given direction, up to maxDepth hops.
+// Every node is visited once, in the order in which it is first reached.
+// The traversal stops when visit returns an error or ctx is done. Every
+// level is read with a single batch, accessors like PrefetchCsr hand the
+// neighbours of the batch to their prefetcher, so the next level is
+// fetched while this one is being visited.
+func Traverse(ctx context.Context, ga GraphAccess, seeds []uint32, maxDepth uint32,
+	labels []uint32, direction Direction, visit func(Visit) error) error {
+	visited := make(map[uint32]struct{}, len(seeds))
+	frontier := make([]uint32, 0, len(seeds))
+	for _, seed := range seeds {
+		if _, ok := visited[seed]; ok {
+			continue
+		}
+		visited[seed] = struct{}{}
+		frontier = append(frontier, seed)
+		if err := visit(Visit{Node: seed, Depth: 0, Parent: seed}); err != nil {
+			return err
+		}
+	}
+	for depth := uint32(1); depth <= maxDepth && len(frontier) > 0; depth++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		requests := make([]Request, 0, len(frontier)*len(labels))
+		for _, node := range frontier {
+			for _, label := range labels {
+				requests = append(requests, Request{Node: node, Label: label, Direction: direction})
+			}
+		}
+		results := ga.GetNeighboursBatch(ctx, requests)
+		next := make([]uint32, 0)
+		parents := make([]uint32, 0)
+		for i, res := range results {
+			if res.Err != nil {
+				return res.Err
+			}
+			for _, n := range res.Neighbours {
+				if _, ok := visited[n]; ok {
+					continue
+				}
+				visited[n] = struct{}{}
+				next = append(next, n)
+				parents = append(parents, requests[i].Node)
+			}
+		}
+		for i, n := range next {
+			if err := visit(Visit{Node: n, Depth: depth, Parent: parents[i]}); err != nil {
+				return err
+			}
+		}
+		frontier = next
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package graphaccess
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraverseLevels(t *testing.T) {
+	graph := mapAccess{1: {2, 3}, 2: {3, 4}, 3: {1, 5}, 4: {6}, 6: {7}}
+	var visits []Visit
+	err := Traverse(context.Background(), graph, []uint32{1}, 3, []uint32{1}, OUTGOING,
+		func(v Visit) error {
+			visits = append(visits, v)
+			return nil
+		})
+	assert.Nil(t, err)
+	expected := []Visit{{1, 0, 1}, {2, 1, 1}, {3, 1, 1}, {4, 2, 2}, {5, 2, 3}, {6, 3, 4}}
+	assert.Equal(t, expected, visits)
+}
+
+func TestTraverseStopsOnError(t *testing.T) {
+	graph := mapAccess{1: {2, 3}, 2: {4}, 3: {5}}
+	stop := errors.New("stop")
+	count := 0
+	err := Traverse(context.Background(), graph, []uint32{1}, 5, []uint32{1}, OUTGOING,
+		func(v Visit) error {
+			count++
+			if count == 2 {
+				return stop
+			}
+			return nil
+		})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestTraverseCancelled(t *testing.T) {
+	graph := mapAccess{1: {2}, 2: {3}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Traverse(ctx, graph, []uint32{1}, 5, []uint32{1}, OUTGOING,
+		func(v Visit) error { return nil })
+	assert.Equal(t, context.Canceled, err)
+}
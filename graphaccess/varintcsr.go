@@ -0,0 +1,118 @@
+package graphaccess
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+// VarintCsr reads files whose edges are compressed, see
+// csrfile.AppendVarintSection. Like OffsetCsr it keeps the offset tables
+// in memory and fetches only the edges of the requested node, of which
+// only the destinations with the requested label are decoded.
+type VarintCsr struct {
+	offsets fileOffsets
+	fetcher storage.Fetcher
+	planner *readPlanner
+	//Time spent decoding the fetched edges.
+	decodeNanos atomic.Uint64
+}
+
+// NewVarintCsr reads the offsets of all the files like NewOffsetCsr, the
+// files have to be compressed.
+func NewVarintCsr(fetcher storage.Fetcher, maxReadGap int) (*VarintCsr, error) {
+	offsets, _, err := loadFileOffsets(fetcher, csrfile.LayoutVarint)
+	if err != nil {
+		return nil, err
+	}
+	return &VarintCsr{offsets: offsets, fetcher: fetcher, planner: newReadPlanner(fetcher, maxReadGap)}, nil
+}
+
+func (csr *VarintCsr) GetNeighbours(ctx context.Context, req Request) ([]uint32, error) {
+	file, err := csr.offsets.find(req.Node)
+	if err != nil {
+		return nil, err
+	}
+	offset, _ := file.fetchOffset(req)
+	resultBytes, err := csr.planner.fetch(ctx, file.nodeRange.objectName, offset)
+	if err != nil {
+		return nil, err
+	}
+	return csr.decodeNeighbours(req, file, resultBytes)
+}
+
+// GetNeighboursBatch reads the nodes of all the requests through the
+// read planner like OffsetCsr.GetNeighboursBatch.
+func (csr *VarintCsr) GetNeighboursBatch(ctx context.Context, requests []Request) []BatchResult {
+	results := make([]BatchResult, len(requests))
+	reads := make([]rangeRead, 0, len(requests))
+	readIdx := make([]int, 0, len(requests))
+	files := make([]*fileOffset, 0, len(requests))
+	for i, req := range requests {
+		file, err := csr.offsets.find(req.Node)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		offset, _ := file.fetchOffset(req)
+		reads = append(reads, newRangeRead(file.nodeRange.objectName, offset))
+		readIdx = append(readIdx, i)
+		files = append(files, file)
+	}
+	data, errs := csr.planner.read(ctx, reads)
+	for j, i := range readIdx {
+		if errs[j] != nil {
+			results[i].Err = errs[j]
+			continue
+		}
+		results[i].Neighbours, results[i].Err = csr.decodeNeighbours(requests[i], files[j], data[j])
+	}
+	return results
+}
+
+// decodeNeighbours returns the destinations of the edges with the label
+// of the request. For BOTH requests the outgoing section is followed by
+// the incoming one.
+func (csr *VarintCsr) decodeNeighbours(req Request, file *fileOffset, resultBytes []byte) ([]uint32, error) {
+	start := time.Now()
+	defer func() {
+		csr.decodeNanos.Add(uint64(time.Since(start).Nanoseconds()))
+	}()
+	var res []uint32
+	var err error
+	if req.Direction != BOTH {
+		res, err = csrfile.VarintNeighbours(resultBytes, req.Label)
+	} else if outSize := file.outgoingSize(req.Node); int(outSize) > len(resultBytes) {
+		err = fmt.Errorf("%w: node %d has fewer edges than its offsets", csrfile.ErrCorrupt, req.Node)
+	} else {
+		var in []uint32
+		res, err = csrfile.VarintNeighbours(resultBytes[:outSize], req.Label)
+		if err == nil {
+			in, err = csrfile.VarintNeighbours(resultBytes[outSize:], req.Label)
+			res = append(res, in...)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read %s: %w", file.nodeRange.objectName, err)
+	}
+	return res, nil
+}
+
+// GetNeighbours64 only reads files with 4 byte node IDs.
+func (csr *VarintCsr) GetNeighbours64(ctx context.Context, req Request64) ([]uint64, error) {
+	return getNeighbours64(ctx, req, csr.GetNeighbours)
+}
+
+func (csr *VarintCsr) GetStats() string {
+	return statsWithFetcher(csr.statsMap(), csr.fetcher)
+}
+
+func (csr *VarintCsr) statsMap() map[string]uint64 {
+	stats := csr.planner.stats()
+	stats["decodeNanos"] = csr.decodeNanos.Load()
+	return stats
+}
@@ -0,0 +1,63 @@
+package graphaccess
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeVarintCsr(start uint32, nodes []nodeEdges, _ bool) []byte {
+	return mustEncode(uint64(start), toFileNodes(nodes), csrfile.EncodeOptions{Flags: csrfile.Varint})
+}
+
+func TestVarintCsrMatchesOffsetCsr(t *testing.T) {
+	offset, err := NewOffsetCsr(testGraph(true), 0)
+	assert.Nil(t, err)
+	varint, err := NewVarintCsr(testGraphWith(encodeVarintCsr, true), 0)
+	assert.Nil(t, err)
+	var requests []Request
+	for node := uint32(0); node < 4; node++ {
+		for label := uint32(0); label < 3; label++ {
+			for _, dir := range []Direction{INCOMING, OUTGOING, BOTH} {
+				requests = append(requests, Request{Node: node, Label: label, Direction: dir})
+			}
+		}
+	}
+	batch := varint.GetNeighboursBatch(context.Background(), requests)
+	for i, req := range requests {
+		expected, err := offset.GetNeighbours(context.Background(), req)
+		assert.Nil(t, err)
+		actual, err := varint.GetNeighbours(context.Background(), req)
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, expected, actual, "%v", req)
+		assert.Nil(t, batch[i].Err)
+		assert.ElementsMatch(t, expected, batch[i].Neighbours, "%v", req)
+	}
+	assert.Contains(t, varint.GetStats(), "bytesFetched")
+}
+
+func TestVarintCsrErrors(t *testing.T) {
+	if _, err := NewVarintCsr(testGraphWith(encodeVersionedCsr, true), 0); err == nil {
+		t.Fatal("Varint accessor read a file with uncompressed edges")
+	}
+	if _, err := NewOffsetCsr(testGraphWith(encodeVarintCsr, true), 0); err == nil {
+		t.Fatal("Offset accessor read a file with compressed edges")
+	}
+
+	//Corrupt edges are reported instead of being decoded.
+	graph := testGraphWith(encodeVarintCsr, true)
+	a := graph.objects["a"]
+	h, err := csrfile.ParseHeader(a)
+	assert.Nil(t, err)
+	edges := a[h.EdgesStart():]
+	for i := range edges {
+		edges[i] = 0xFF
+	}
+	varint, err := NewVarintCsr(graph, 0)
+	assert.Nil(t, err)
+	_, err = varint.GetNeighbours(context.Background(), Request{Node: 0, Label: 1, Direction: BOTH})
+	assert.True(t, errors.Is(err, csrfile.ErrCorrupt), "%v", err)
+}
@@ -0,0 +1,83 @@
+package graphaccess
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+// wideEdgeSize is the size of an edge of a file with 8 byte node IDs.
+const wideEdgeSize = SizeIntBytes + 8
+
+// wideFile is a file with 8 byte node IDs, its offset table is kept the
+// way it is stored in the file.
+type wideFile struct {
+	//Nodes from start to end, both inclusive, are stored in the file.
+	start, end uint64
+	objectName string
+	offsets    []byte
+}
+
+// newWideFile checks that the file has the layout and that its offsets
+// are all within the file.
+func newWideFile(e ManifestEntry, layout csrfile.Layout) (*wideFile, error) {
+	h := e.Header
+	if h.Layout() != layout {
+		return nil, fmt.Errorf("Unable to read %s: %w", e.ObjectName, errWrongLayout(h.Layout()))
+	}
+	if err := h.ValidateOffsets(e.Offsets); err != nil {
+		return nil, fmt.Errorf("Unable to read %s: %w", e.ObjectName, err)
+	}
+	return &wideFile{start: h.Start, end: h.End, objectName: e.ObjectName, offsets: e.Offsets}, nil
+}
+
+type wideFiles []*wideFile
+
+func (wf wideFiles) find(node uint64) (*wideFile, error) {
+	i := sort.Search(len(wf), func(i int) bool {
+		return wf[i].end >= node
+	})
+	if i == len(wf) || wf[i].start > node {
+		return nil, fmt.Errorf("Node %d not found in fileOffsets", node)
+	}
+	return wf[i], nil
+}
+
+// offset is the outgoing or incoming offset of the node at idx.
+func (f *wideFile) offset(idx uint64, incoming bool) uint64 {
+	pos := 16 * idx
+	if incoming {
+		pos += 8
+	}
+	return binary.LittleEndian.Uint64(f.offsets[pos:])
+}
+
+func (f *wideFile) fetchOffset(req Request64) storage.ByteRange {
+	idx := req.Node - f.start
+	start := f.offset(idx, req.Direction == INCOMING)
+	if req.Direction == OUTGOING {
+		return storage.BRange(start, f.offset(idx, true)-1)
+	}
+	if req.Node < f.end {
+		return storage.BRange(start, f.offset(idx+1, false)-1)
+	}
+	return storage.BRangeStart(start)
+}
+
+// decodeWideNeighbours returns the destinations of the edges with the
+// label in the order in which they are stored.
+func decodeWideNeighbours(objectName string, data []byte, label uint32) ([]uint64, error) {
+	if len(data)%wideEdgeSize != 0 {
+		return nil, fmt.Errorf("Unable to read %s: %w: edges are cut off", objectName, csrfile.ErrCorrupt)
+	}
+	res := make([]uint64, 0)
+	for i := 0; i < len(data); i += wideEdgeSize {
+		if binary.LittleEndian.Uint32(data[i:]) == label {
+			res = append(res, binary.LittleEndian.Uint64(data[i+SizeIntBytes:]))
+		}
+	}
+	return res, nil
+}
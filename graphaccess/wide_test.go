@@ -0,0 +1,89 @@
+package graphaccess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func wideEdge(label uint32, dest uint64) csrfile.Edge {
+	return csrfile.Edge{Label: label, Dest: dest}
+}
+
+// wideGraph has the files of testGraph and two files with 8 byte node
+// IDs, one of them with nodes that fit in 32 bits.
+func wideGraph() *memFetcher {
+	graph := testGraph(true)
+	wide := csrfile.EncodeOptions{Flags: csrfile.ByteOffsets, IDWidth: 8}
+	graph.objects["v"] = mustEncode(4, []csrfile.Node{
+		{Out: []csrfile.Edge{wideEdge(1, 0), wideEdge(1, 5)}, In: []csrfile.Edge{wideEdge(2, 4)}},
+		{In: []csrfile.Edge{wideEdge(1, 1<<40)}},
+	}, wide)
+	graph.objects["w"] = mustEncode(1<<33, []csrfile.Node{
+		{Out: []csrfile.Edge{wideEdge(2, 1<<40), wideEdge(1, 1<<33+1), wideEdge(1, 3)}},
+		{In: []csrfile.Edge{wideEdge(1, 1<<33)}},
+	}, wide)
+	return graph
+}
+
+func TestWideFiles(t *testing.T) {
+	csr, err := NewOffsetCsr(wideGraph(), 0)
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	res, err := csr.GetNeighbours64(ctx, Request64{Node: 1 << 33, Label: 1, Direction: OUTGOING})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []uint64{3, 1<<33 + 1}, res)
+	res, err = csr.GetNeighbours64(ctx, Request64{Node: 1<<33 + 1, Label: 1, Direction: BOTH})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{1 << 33}, res)
+	res, err = csr.GetNeighbours64(ctx, Request64{Node: 1<<33 + 1, Label: 1, Direction: OUTGOING})
+	assert.Nil(t, err)
+	assert.Empty(t, res)
+	_, err = csr.GetNeighbours64(ctx, Request64{Node: 1<<33 + 2, Label: 1, Direction: BOTH})
+	assert.NotNil(t, err)
+
+	//Files with 4 byte node IDs are read by both.
+	res, err = csr.GetNeighbours64(ctx, Request64{Node: 3, Label: 1, Direction: BOTH})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{0, 0}, res)
+
+	//Nodes of files with 8 byte IDs are read as long as their neighbours
+	//fit in 32 bits.
+	narrow, err := csr.GetNeighbours(ctx, Request{Node: 4, Label: 1, Direction: BOTH})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{0, 5}, narrow)
+	_, err = csr.GetNeighbours(ctx, Request{Node: 5, Label: 1, Direction: INCOMING})
+	assert.NotNil(t, err)
+	batch := csr.GetNeighboursBatch(ctx, []Request{{Node: 4, Label: 2, Direction: INCOMING}, {Node: 3, Label: 2, Direction: OUTGOING}})
+	assert.Nil(t, batch[0].Err)
+	assert.Equal(t, []uint32{4}, batch[0].Neighbours)
+	assert.Nil(t, batch[1].Err)
+	assert.Equal(t, []uint32{1}, batch[1].Neighbours)
+}
+
+func TestWideFilesFromManifest(t *testing.T) {
+	graph := wideGraph()
+	m, err := ProbeManifest(graph, true)
+	assert.Nil(t, err)
+	decoded, err := DecodeManifest(m.Encode())
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, m.Entries, decoded.Entries)
+	graph.objects[ManifestObject] = m.Encode()
+	csr, err := NewOffsetCsr(graph, 0)
+	assert.Nil(t, err)
+	res, err := csr.GetNeighbours64(context.Background(), Request64{Node: 1 << 33, Label: 2, Direction: OUTGOING})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{1 << 40}, res)
+
+	if _, err = NewVarintCsr(graph, 0); err == nil {
+		t.Fatal("Varint accessor read a file with 8 byte node IDs")
+	}
+}
+
+func TestSimpleCsrRejectsWideFiles(t *testing.T) {
+	_, err := NewSimpleCsr(wideGraph())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,222 @@
+package graphaccess
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/adityachandla/graph_access_service/caches"
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+// ZstdCsr reads files whose edges are stored in zstd compressed blocks,
+// see csrfile.BlockIndex. It keeps the offset tables and block indices in
+// memory and fetches only the blocks that hold the edges of the requested
+// nodes. Decompressed blocks are cached so that nodes stored in the same
+// block are read without fetching it again.
+type ZstdCsr struct {
+	offsets fileOffsets
+	fetcher storage.Fetcher
+	planner *readPlanner
+	//cache is nil if blocks are not cached.
+	cache *caches.LRU[blockKey, []byte]
+
+	blockCacheHits atomic.Uint64
+	blocksFetched  atomic.Uint64
+	//Time spent decompressing the fetched blocks.
+	decompressNanos atomic.Uint64
+}
+
+type blockKey struct {
+	objectName string
+	block      int
+}
+
+// NewZstdCsr reads the offsets of all the files like NewOffsetCsr, the
+// files have to be zstd compressed. At most cacheBytes bytes of
+// decompressed blocks are cached, blocks are not cached if it is not
+// positive.
+func NewZstdCsr(fetcher storage.Fetcher, maxReadGap int, cacheBytes int64) (*ZstdCsr, error) {
+	offsets, _, err := loadFileOffsets(fetcher, csrfile.LayoutZstd)
+	if err != nil {
+		return nil, err
+	}
+	csr := &ZstdCsr{offsets: offsets, fetcher: fetcher, planner: newReadPlanner(fetcher, maxReadGap)}
+	if cacheBytes > 0 {
+		csr.cache = caches.NewWeightedLRU[blockKey, []byte](cacheBytes, func(block []byte) int64 {
+			return int64(len(block))
+		})
+	}
+	return csr, nil
+}
+
+func (csr *ZstdCsr) GetNeighbours(ctx context.Context, req Request) ([]uint32, error) {
+	res := csr.GetNeighboursBatch(ctx, []Request{req})[0]
+	return res.Neighbours, res.Err
+}
+
+// zstdLookup is the range of the uncompressed edges that a request reads.
+type zstdLookup struct {
+	file       *fileOffset
+	start, end uint64
+	numOut     uint32
+}
+
+// GetNeighboursBatch fetches the blocks of all the requests that are not
+// cached through the read planner, so adjacent blocks are fetched with a
+// single GET. Every block is fetched at most once per batch.
+func (csr *ZstdCsr) GetNeighboursBatch(ctx context.Context, requests []Request) []BatchResult {
+	results := make([]BatchResult, len(requests))
+	lookups := make([]zstdLookup, len(requests))
+	blocks := make(map[blockKey][]byte)
+	blockErrs := make(map[blockKey]error)
+	var missing []blockKey
+	var missingFiles []*fileOffset
+	var reads []rangeRead
+	for i, req := range requests {
+		file, err := csr.offsets.find(req.Node)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		start, end, numOut := file.edgeRange(req)
+		lookups[i] = zstdLookup{file: file, start: start, end: end, numOut: numOut}
+		if start == end {
+			continue
+		}
+		first, last := file.blocks.Blocks(start, end)
+		for b := first; b <= last; b++ {
+			key := blockKey{file.nodeRange.objectName, b}
+			if _, found := blocks[key]; found {
+				continue
+			}
+			if data, found := csr.cachedBlock(key); found {
+				blocks[key] = data
+				continue
+			}
+			blocks[key] = nil
+			from, to := file.blocks.BlockRange(b)
+			missing = append(missing, key)
+			missingFiles = append(missingFiles, file)
+			reads = append(reads, newRangeRead(key.objectName, storage.BRange(uint64(from), uint64(to-1))))
+		}
+	}
+	data, errs := csr.planner.read(ctx, reads)
+	csr.blocksFetched.Add(uint64(len(reads)))
+	for j, key := range missing {
+		if errs[j] != nil {
+			blockErrs[key] = errs[j]
+			continue
+		}
+		block, err := csr.decompress(missingFiles[j], key.block, data[j])
+		if err != nil {
+			blockErrs[key] = err
+			continue
+		}
+		blocks[key] = block
+		if csr.cache != nil {
+			csr.cache.Put(key, block)
+		}
+	}
+	for i, req := range requests {
+		if results[i].Err != nil {
+			continue
+		}
+		edges, err := lookups[i].edges(blocks, blockErrs)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Neighbours, results[i].Err = decodeNeighbours(req,
+			lookups[i].file.nodeRange.objectName, edges, lookups[i].numOut)
+	}
+	return results
+}
+
+func (csr *ZstdCsr) cachedBlock(key blockKey) ([]byte, bool) {
+	if csr.cache == nil {
+		return nil, false
+	}
+	data, found := csr.cache.Get(key)
+	if found {
+		csr.blockCacheHits.Add(1)
+	}
+	return data, found
+}
+
+func (csr *ZstdCsr) decompress(file *fileOffset, block int, compressed []byte) ([]byte, error) {
+	start := time.Now()
+	defer func() {
+		csr.decompressNanos.Add(uint64(time.Since(start).Nanoseconds()))
+	}()
+	data, err := file.blocks.Decompress(block, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read %s: %w", file.nodeRange.objectName, err)
+	}
+	return data, nil
+}
+
+// edges returns the uncompressed edges of the lookup from its blocks.
+func (l *zstdLookup) edges(blocks map[blockKey][]byte, blockErrs map[blockKey]error) ([]byte, error) {
+	if l.start == l.end {
+		return nil, nil
+	}
+	blockSize := uint64(l.file.blocks.BlockSize)
+	first, last := l.file.blocks.Blocks(l.start, l.end)
+	var edges []byte
+	for b := first; b <= last; b++ {
+		key := blockKey{l.file.nodeRange.objectName, b}
+		if err := blockErrs[key]; err != nil {
+			return nil, err
+		}
+		if first == last {
+			edges = blocks[key]
+		} else {
+			edges = append(edges, blocks[key]...)
+		}
+	}
+	base := uint64(first) * blockSize
+	return edges[l.start-base : l.end-base], nil
+}
+
+// edgeRange is the range of the uncompressed edges of a zstd file that
+// the request reads, in bytes, along with the number of outgoing edges
+// for BOTH requests.
+func (offset *fileOffset) edgeRange(req Request) (uint64, uint64, uint32) {
+	const edgeSize = 2 * SizeIntBytes
+	idx := req.Node - offset.nodeRange.start
+	out, in := offset.offsetArr[idx].outgoing, offset.offsetArr[idx].incoming
+	next := offset.blocks.EdgesSize() / edgeSize
+	if int(idx) < len(offset.offsetArr)-1 {
+		next = uint64(offset.offsetArr[idx+1].outgoing)
+	}
+	switch req.Direction {
+	case OUTGOING:
+		return uint64(out) * edgeSize, uint64(in) * edgeSize, in - out
+	case INCOMING:
+		return uint64(in) * edgeSize, next * edgeSize, 0
+	}
+	return uint64(out) * edgeSize, next * edgeSize, in - out
+}
+
+// GetNeighbours64 only reads files with 4 byte node IDs.
+func (csr *ZstdCsr) GetNeighbours64(ctx context.Context, req Request64) ([]uint64, error) {
+	return getNeighbours64(ctx, req, csr.GetNeighbours)
+}
+
+func (csr *ZstdCsr) GetStats() string {
+	return statsWithFetcher(csr.statsMap(), csr.fetcher)
+}
+
+func (csr *ZstdCsr) statsMap() map[string]uint64 {
+	stats := csr.planner.stats()
+	stats["blockCacheHits"] = csr.blockCacheHits.Load()
+	stats["blocksFetched"] = csr.blocksFetched.Load()
+	stats["decompressNanos"] = csr.decompressNanos.Load()
+	if csr.cache != nil {
+		stats["blockCacheBytes"] = uint64(csr.cache.Weight())
+	}
+	return stats
+}
@@ -0,0 +1,100 @@
+package graphaccess
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/stretchr/testify/assert"
+)
+
+// encodeZstdCsr uses blocks of two edges so that nodes span blocks.
+func encodeZstdCsr(start uint32, nodes []nodeEdges, _ bool) []byte {
+	return mustEncode(uint64(start), toFileNodes(nodes), csrfile.EncodeOptions{Flags: csrfile.Zstd, BlockSize: 16})
+}
+
+func TestZstdCsrMatchesOffsetCsr(t *testing.T) {
+	offset, err := NewOffsetCsr(testGraph(true), 0)
+	assert.Nil(t, err)
+	for _, cacheBytes := range []int64{0, 1 << 20} {
+		zstd, err := NewZstdCsr(testGraphWith(encodeZstdCsr, true), 0, cacheBytes)
+		assert.Nil(t, err)
+		var requests []Request
+		for node := uint32(0); node < 4; node++ {
+			for label := uint32(0); label < 3; label++ {
+				for _, dir := range []Direction{INCOMING, OUTGOING, BOTH} {
+					requests = append(requests, Request{Node: node, Label: label, Direction: dir})
+				}
+			}
+		}
+		batch := zstd.GetNeighboursBatch(context.Background(), requests)
+		for i, req := range requests {
+			expected, err := offset.GetNeighbours(context.Background(), req)
+			assert.Nil(t, err)
+			actual, err := zstd.GetNeighbours(context.Background(), req)
+			assert.Nil(t, err)
+			assert.ElementsMatch(t, expected, actual, "%v", req)
+			assert.Nil(t, batch[i].Err)
+			assert.ElementsMatch(t, expected, batch[i].Neighbours, "%v", req)
+		}
+		stats := zstd.statsMap()
+		assert.NotZero(t, stats["bytesFetched"])
+		if cacheBytes == 0 {
+			assert.Zero(t, stats["blockCacheHits"])
+			continue
+		}
+		//All the blocks are fetched by the batch and cached, both files have
+		//40 bytes of edges.
+		assert.Equal(t, uint64(6), stats["blocksFetched"])
+		assert.Equal(t, uint64(80), stats["blockCacheBytes"])
+	}
+}
+
+func TestZstdCsrErrors(t *testing.T) {
+	if _, err := NewZstdCsr(testGraphWith(encodeVersionedCsr, true), 0, 0); err == nil {
+		t.Fatal("Zstd accessor read a file with uncompressed edges")
+	}
+
+	//Corrupt blocks are reported instead of being decoded.
+	graph := testGraphWith(encodeZstdCsr, true)
+	a := graph.objects["a"]
+	h, err := csrfile.ParseHeader(a)
+	assert.Nil(t, err)
+	size, err := h.BlockIndexSize(a[h.EdgesStart():])
+	assert.Nil(t, err)
+	blocks := a[h.EdgesStart()+size:]
+	for i := range blocks {
+		blocks[i] = 0xFF
+	}
+	zstd, err := NewZstdCsr(graph, 0, 1<<20)
+	assert.Nil(t, err)
+	_, err = zstd.GetNeighbours(context.Background(), Request{Node: 0, Label: 1, Direction: BOTH})
+	assert.True(t, errors.Is(err, csrfile.ErrCorrupt), "%v", err)
+	//Nodes in other files are still read.
+	neighbours, err := zstd.GetNeighbours(context.Background(), Request{Node: 3, Label: 1, Direction: OUTGOING})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{0}, neighbours)
+}
+
+func TestZstdCsrFromManifest(t *testing.T) {
+	fetcher := testGraphWith(encodeZstdCsr, true)
+	m, err := ProbeManifest(fetcher, true)
+	assert.Nil(t, err)
+	assert.NotNil(t, m.Entries[0].BlockIndex)
+	data := m.Encode()
+	decoded, err := DecodeManifest(data)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, m.Entries, decoded.Entries)
+	_, err = DecodeManifest(data[:len(data)-1])
+	assert.NotNil(t, err)
+	fetcher.objects[ManifestObject] = data
+
+	fetcher.fetches.Store(0)
+	zstd, err := NewZstdCsr(fetcher, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), fetcher.fetches.Load())
+	res, err := zstd.GetNeighbours(context.Background(), Request{Node: 3, Label: 1, Direction: BOTH})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{0, 0}, res)
+}
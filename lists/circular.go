@@ -28,7 +28,7 @@ func (cq *CircularQueue[T]) Read() T {
 	cq.lock.Lock()
 	defer cq.lock.Unlock()
 
-	if cq.front == cq.back && !cq.isFull {
+	for cq.front == cq.back && !cq.isFull {
 		cq.writeCond.Wait()
 	}
 	val := cq.arr[cq.front]
@@ -39,6 +39,22 @@ func (cq *CircularQueue[T]) Read() T {
 	return val
 }
 
+// TryRead is like Read but returns false instead of waiting when the
+// queue is empty.
+func (cq *CircularQueue[T]) TryRead() (T, bool) {
+	cq.lock.Lock()
+	defer cq.lock.Unlock()
+
+	if cq.front == cq.back && !cq.isFull {
+		var zero T
+		return zero, false
+	}
+	val := cq.arr[cq.front]
+	cq.front = (cq.front + 1) % len(cq.arr)
+	cq.isFull = false
+	return val, true
+}
+
 func (cq *CircularQueue[T]) Write(newElements []T) {
 	cq.lock.Lock()
 	defer cq.lock.Unlock()
@@ -19,6 +19,22 @@ func TestNormalAddition(t *testing.T) {
 	assert.Equal(t, 2, v)
 }
 
+func TestTryRead(t *testing.T) {
+	cq := lists.NewCircularQueue[int](2)
+	_, ok := cq.TryRead()
+	assert.False(t, ok)
+
+	cq.Write([]int{1, 2})
+	v, ok := cq.TryRead()
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+	v, ok = cq.TryRead()
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	_, ok = cq.TryRead()
+	assert.False(t, ok)
+}
+
 func TestOverwrite(t *testing.T) {
 	cq := lists.NewCircularQueue[int](4)
 	cq.Write([]int{4, 3, 2, 1})
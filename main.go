@@ -2,16 +2,22 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"sync"
+	"time"
 
+	"github.com/adityachandla/graph_access_service/caches"
 	pb "github.com/adityachandla/graph_access_service/generated"
 	"github.com/adityachandla/graph_access_service/graphaccess"
 	"github.com/adityachandla/graph_access_service/storage"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 //go:generate protoc --go-grpc_out=generated --go_out=generated --go_opt=paths=source_relative  --go-grpc_opt=paths=source_relative graph_access.proto
@@ -19,9 +25,36 @@ var (
 	port     = flag.Int("port", 20301, "The server port")
 	fsType   = flag.String("fstype", "s3", "Filesystem type s3/local")
 	bucket   = flag.String("bucket", "s3graphtest1", "Path to the s3 bucket")
+	useMmap  = flag.Bool("mmap", false, "Memory map the files of the local fstype instead of reading them")
 	noLog    = flag.Bool("nolog", false, "Turn off logging")
 	region   = flag.String("region", "eu-west-1", "AWS Region")
-	accessor = flag.String("accessor", "prefetch", "Possible values are: prefetch/offset/simple")
+	accessor = flag.String("accessor", "prefetch", "Possible values are: prefetch/offset/simple/varint/zstd")
+	inFlight = flag.Int("inflight", 32, "Maximum number of outstanding lookups per stream")
+	fetches  = flag.Int("batchfetches", 64, "Maximum number of concurrent fetches of a batch, an expansion hop or a traversal level")
+
+	attempts   = flag.Int("attempts", 1, "Maximum attempts per fetch, failed fetches are retried if this is more than 1")
+	backoff    = flag.Duration("backoff", 50*time.Millisecond, "Delay before the first retry, doubled after every retry")
+	maxBackoff = flag.Duration("maxbackoff", 2*time.Second, "Maximum delay between retries")
+	jitter     = flag.Float64("jitter", 0.5, "Fraction of the retry delay that is randomized")
+
+	hedgePercentile = flag.Float64("hedge", 0, "Latency percentile after which a fetch is hedged, 0 disables hedging")
+	hedgeDelay      = flag.Duration("hedgedelay", 100*time.Millisecond, "Hedge delay used till enough latencies are observed")
+
+	diskCache     = flag.String("diskcache", "", "Directory in which fetched ranges are cached across restarts, empty disables the disk cache")
+	diskCacheSize = flag.Int64("diskcachesize", 1<<30, "Maximum bytes stored in the disk cache")
+
+	readGap  = flag.Int("readgap", 4096, "Nodes in the same object less than this many bytes apart are fetched with one GET, negative disables merging")
+	merged   = flag.Int64("mergedbytes", 1<<20, "Maximum bytes of a GET that nearby node reads are merged into")
+	coalesce = flag.Bool("coalesce", true, "Share a single fetch between concurrent requests for the same byte range")
+
+	blockCache = flag.Int64("blockcache", 256<<20, "Maximum bytes of decompressed blocks cached by the zstd accessor, 0 disables the cache")
+
+	cachePolicy = flag.String("cachepolicy", "", "Cache policy of the simple and prefetch accessors: lru/lrfu/arc/tinylfu, empty keeps the accessor's default")
+	cacheBytes  = flag.Int64("cachebytes", 0, "Maximum bytes of files cached by the simple accessor or of responses and prefetched edges cached by the prefetch accessor, 0 limits the number of cached values instead, can not be set along with cachesize")
+	cacheSize   = flag.Int("cachesize", 0, "Number of values cached by the simple and prefetch accessors, 0 keeps the accessor's default")
+
+	partitioned = flag.Bool("partitioned", false, "Read a label partitioned bucket, every partition is read with the accessor")
+	permute     = flag.Bool("permute", true, "Translate node IDs with the permutation of a relabeled graph, if the bucket has one")
 )
 
 type server struct {
@@ -29,22 +62,181 @@ type server struct {
 	accessService graphaccess.GraphAccess
 }
 
-func (s *server) GetNeighbours(_ context.Context, req *pb.AccessRequest) (*pb.AccessResponse, error) {
+// GetNeighbours keeps prefetching the neighbours after it returns, they
+// are prefetched for the client's next requests. The lookups of streams
+// and traversals abandon their prefetches with the stream instead.
+func (s *server) GetNeighbours(ctx context.Context, req *pb.AccessRequest) (*pb.AccessResponse, error) {
 	log.Printf("Processing reqest %v\n", req)
-	request := graphaccess.Request{
+	response := s.lookup(graphaccess.DetachPrefetch(ctx), req)
+	if err := ctx.Err(); err != nil {
+		return nil, rpcError(err)
+	}
+	return response, nil
+}
+
+func (s *server) lookup(ctx context.Context, req *pb.AccessRequest) *pb.AccessResponse {
+	neighbours, err := s.accessService.GetNeighbours(ctx, mapRequest(req))
+	if err != nil {
+		log.Printf("Request %v failed: %s\n", req, err)
+		return &pb.AccessResponse{Status: pb.AccessResponse_SERVER_ERROR}
+	}
+	return &pb.AccessResponse{Neighbours: neighbours, Status: pb.AccessResponse_NO_ERROR}
+}
+
+func (s *server) GetNeighbours64(ctx context.Context, req *pb.AccessRequest64) (*pb.AccessResponse64, error) {
+	log.Printf("Processing request %v\n", req)
+	neighbours, err := s.accessService.GetNeighbours64(graphaccess.DetachPrefetch(ctx), graphaccess.Request64{
 		Node:      req.NodeId,
 		Label:     req.Label,
 		Direction: mapDirection(req.Direction),
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, rpcError(ctxErr)
+	}
+	if err != nil {
+		log.Printf("Request %v failed: %s\n", req, err)
+		return &pb.AccessResponse64{Status: pb.AccessResponse_SERVER_ERROR}, nil
+	}
+	return &pb.AccessResponse64{Neighbours: neighbours, Status: pb.AccessResponse_NO_ERROR}, nil
+}
+
+func (s *server) GetNeighboursBatch(ctx context.Context, req *pb.BatchAccessRequest) (*pb.BatchAccessResponse, error) {
+	log.Printf("Processing batch of %d requests\n", len(req.Requests))
+	requests := make([]graphaccess.Request, len(req.Requests))
+	for i, r := range req.Requests {
+		requests[i] = mapRequest(r)
+	}
+	results := s.accessService.GetNeighboursBatch(graphaccess.DetachPrefetch(ctx), requests)
+	if err := ctx.Err(); err != nil {
+		return nil, rpcError(err)
+	}
+	responses := make([]*pb.AccessResponse, len(results))
+	for i, res := range results {
+		if res.Err != nil {
+			log.Printf("Batch request %v failed: %s\n", req.Requests[i], res.Err)
+			responses[i] = &pb.AccessResponse{Status: pb.AccessResponse_SERVER_ERROR}
+			continue
+		}
+		responses[i] = &pb.AccessResponse{
+			Neighbours: res.Neighbours,
+			Status:     pb.AccessResponse_NO_ERROR,
+		}
+	}
+	return &pb.BatchAccessResponse{Responses: responses}, nil
+}
+
+// GetNeighboursStream answers every request on the stream as soon as its
+// lookup finishes. At most `inflight` lookups run at once for a stream,
+// after that we stop reading from the stream until one of them finishes.
+func (s *server) GetNeighboursStream(stream pb.GraphAccess_GetNeighboursStreamServer) error {
+	slots := make(chan struct{}, *inFlight)
+	var sendLock sync.Mutex
+	var wg sync.WaitGroup
+	// Responses can't be sent once we return.
+	defer wg.Wait()
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-stream.Context().Done():
+			return rpcError(stream.Context().Err())
+		}
+		wg.Add(1)
+		go func(req *pb.StreamAccessRequest) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			response := &pb.StreamAccessResponse{RequestId: req.RequestId}
+			if req.Request == nil {
+				response.Response = &pb.AccessResponse{Status: pb.AccessResponse_UNSUPPORTED}
+			} else {
+				response.Response = s.lookup(stream.Context(), req.Request)
+			}
+			sendLock.Lock()
+			defer sendLock.Unlock()
+			if err := stream.Send(response); err != nil {
+				log.Printf("Unable to send response for %d: %s\n", req.RequestId, err)
+			}
+		}(req)
+	}
+}
+
+func (s *server) Expand(ctx context.Context, req *pb.ExpandRequest) (*pb.ExpandResponse, error) {
+	log.Printf("Processing expansion from %d with %d steps\n", req.Source, len(req.Steps))
+	if len(req.Steps) == 0 {
+		return &pb.ExpandResponse{Status: pb.AccessResponse_UNSUPPORTED}, nil
+	}
+	steps := make([]graphaccess.Step, len(req.Steps))
+	for i, step := range req.Steps {
+		steps[i] = graphaccess.Step{Label: step.Label, Direction: mapDirection(step.Direction)}
+	}
+	hops, truncated, err := graphaccess.Expand(ctx, s.accessService, req.Source, steps, int(req.MaxPerHop))
+	if err != nil {
+		log.Printf("Expansion from %d failed: %s\n", req.Source, err)
+		return &pb.ExpandResponse{Status: pb.AccessResponse_SERVER_ERROR}, nil
+	}
+	response := &pb.ExpandResponse{
+		Nodes:     hops[len(hops)-1],
+		Truncated: truncated,
+		Status:    pb.AccessResponse_NO_ERROR,
+	}
+	if req.GroupByHop {
+		response.Hops = make([]*pb.ExpandResponse_Hop, len(hops))
+		for i, hop := range hops {
+			response.Hops[i] = &pb.ExpandResponse_Hop{Nodes: hop}
+		}
 	}
-	response := &pb.AccessResponse{Neighbours: s.accessService.GetNeighbours(request)}
-	response.Status = pb.AccessResponse_NO_ERROR
 	return response, nil
 }
 
+// Traverse streams the nodes reached by a breadth first search. The search
+// stops as soon as the client cancels the stream.
+func (s *server) Traverse(req *pb.TraverseRequest, stream pb.GraphAccess_TraverseServer) error {
+	log.Printf("Processing traversal from %v up to depth %d\n", req.Seeds, req.MaxDepth)
+	if len(req.Labels) == 0 {
+		return status.Error(codes.InvalidArgument, "At least one label is needed for a traversal")
+	}
+	err := graphaccess.Traverse(stream.Context(), s.accessService, req.Seeds, req.MaxDepth,
+		req.Labels, mapDirection(req.Direction), func(v graphaccess.Visit) error {
+			return stream.Send(&pb.TraverseResponse{Node: v.Node, Depth: v.Depth, Parent: v.Parent})
+		})
+	if err != nil {
+		log.Printf("Traversal from %v stopped: %s\n", req.Seeds, err)
+		return rpcError(err)
+	}
+	return nil
+}
+
 func (s *server) GetStats(_ context.Context, _ *pb.StatsRequest) (*pb.Stats, error) {
 	return &pb.Stats{Stats: s.accessService.GetStats()}, nil
 }
 
+// rpcError returns the gRPC status of a cancelled or timed out request.
+// Errors that are already a status, such as those of stream.Send, are
+// returned as they are and any other error is an internal error.
+func rpcError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func mapRequest(req *pb.AccessRequest) graphaccess.Request {
+	return graphaccess.Request{
+		Node:      req.NodeId,
+		Label:     req.Label,
+		Direction: mapDirection(req.Direction),
+	}
+}
+
 func mapDirection(dir pb.AccessRequest_Direction) graphaccess.Direction {
 	if dir == pb.AccessRequest_OUTGOING {
 		return graphaccess.OUTGOING
@@ -60,8 +252,25 @@ func main() {
 		log.SetFlags(0)
 		log.SetOutput(io.Discard)
 	}
+	if *inFlight < 1 {
+		log.Fatalf("At least one lookup per stream has to be in flight, inflight is %d", *inFlight)
+	}
+	if *fetches < 1 {
+		log.Fatalf("At least one fetch of a batch has to run at once, batchfetches is %d", *fetches)
+	}
+	if *merged < 1 {
+		log.Fatalf("Merged reads need at least one byte, mergedbytes is %d", *merged)
+	}
+	if *cacheBytes > 0 && *cacheSize > 0 {
+		log.Fatal("Only one of cachebytes and cachesize can be set")
+	}
+	graphaccess.MaxBatchFetches = *fetches
+	graphaccess.MaxMergedReadBytes = *merged
 	fetcher := getFetcher()
-	accessService := getAccessService(fetcher)
+	accessService, err := getAccessService(fetcher)
+	if err != nil {
+		log.Fatalf("Unable to initialize access service: %s", err)
+	}
 	log.Println("Initialized access service")
 	s := &server{accessService: accessService}
 	startServer(s)
@@ -82,22 +291,81 @@ func startServer(ser *server) {
 }
 
 func getFetcher() storage.Fetcher {
+	var base storage.Fetcher
 	if *fsType == "s3" {
-		return storage.InitializeS3Service(*bucket, *region)
+		base = storage.InitializeS3Service(*bucket, *region)
+	} else if *fsType == "local" && *useMmap {
+		base = storage.InitializeMmapService(*bucket)
 	} else if *fsType == "local" {
-		return storage.InitializeFsService(*bucket)
+		base = storage.InitializeFsService(*bucket)
 	} else {
 		panic("Invalid filesystem type")
 	}
+	fetcher := base
+	if *hedgePercentile > 0 {
+		fetcher = storage.NewHedgeFetcher(fetcher, storage.HedgeConfig{
+			Percentile:   *hedgePercentile,
+			InitialDelay: *hedgeDelay,
+			WindowSize:   1000,
+		})
+	}
+	if *attempts > 1 {
+		fetcher = storage.NewRetryFetcher(fetcher, storage.RetryConfig{
+			MaxAttempts: *attempts,
+			BaseDelay:   *backoff,
+			MaxDelay:    *maxBackoff,
+			Jitter:      *jitter,
+		})
+	}
+	// Ranges served from disk skip the retries and do not count towards
+	// the hedge delay.
+	if *diskCache != "" {
+		cached, err := storage.NewDiskCacheFetcher(fetcher, base.(storage.Versioner),
+			storage.DiskCacheConfig{Directory: *diskCache, MaxBytes: *diskCacheSize})
+		if err != nil {
+			log.Fatalf("Unable to open disk cache: %s", err)
+		}
+		fetcher = cached
+	}
+	if *coalesce {
+		fetcher = storage.NewCoalescingFetcher(fetcher)
+	}
+	return fetcher
+}
+
+func getAccessService(fetcher storage.Fetcher) (graphaccess.GraphAccess, error) {
+	var accessService graphaccess.GraphAccess
+	var err error
+	if *partitioned {
+		accessService, err = graphaccess.NewPartitionedCsr(fetcher, newAccessor)
+	} else {
+		accessService, err = newAccessor(fetcher)
+	}
+	if err != nil || !*permute {
+		return accessService, err
+	}
+	perm, err := graphaccess.LoadPermutation(fetcher)
+	if err != nil {
+		return nil, err
+	}
+	if perm == nil {
+		return accessService, nil
+	}
+	return graphaccess.NewPermutedCsr(accessService, perm), nil
 }
 
-func getAccessService(fetcher storage.Fetcher) graphaccess.GraphAccess {
+func newAccessor(fetcher storage.Fetcher) (graphaccess.GraphAccess, error) {
+	cacheConfig := graphaccess.CacheConfig{Policy: caches.Policy(*cachePolicy), Size: *cacheSize, Bytes: *cacheBytes}
 	if *accessor == "simple" {
-		return graphaccess.NewSimpleCsr(fetcher)
+		return graphaccess.NewSimpleCsrWithCache(fetcher, cacheConfig)
 	} else if *accessor == "offset" {
-		return graphaccess.NewOffsetCsr(fetcher)
+		return graphaccess.NewOffsetCsr(fetcher, *readGap)
 	} else if *accessor == "prefetch" {
-		return graphaccess.NewPrefetchCsr(fetcher)
+		return graphaccess.NewPrefetchCsrWithCache(fetcher, *readGap, cacheConfig)
+	} else if *accessor == "varint" {
+		return graphaccess.NewVarintCsr(fetcher, *readGap)
+	} else if *accessor == "zstd" {
+		return graphaccess.NewZstdCsr(fetcher, *readGap, *blockCache)
 	} else {
 		panic("Invalid accessor")
 	}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/adityachandla/graph_access_service/generated"
+	"github.com/adityachandla/graph_access_service/graphaccess"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// blockingAccess answers a node once its channel in release is closed,
+// nodes without a channel are answered immediately.
+type blockingAccess struct {
+	release map[uint32]chan struct{}
+	//cancelled is closed when a lookup sees its context cancelled.
+	cancelled     chan struct{}
+	cancelOnce    sync.Once
+	running, peak atomic.Int32
+}
+
+func (b *blockingAccess) GetNeighbours(ctx context.Context, req graphaccess.Request) ([]uint32, error) {
+	n := b.running.Add(1)
+	defer b.running.Add(-1)
+	for p := b.peak.Load(); n > p && !b.peak.CompareAndSwap(p, n); p = b.peak.Load() {
+	}
+	if ch, ok := b.release[req.Node]; ok {
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			b.cancelOnce.Do(func() { close(b.cancelled) })
+			return nil, ctx.Err()
+		}
+	}
+	return []uint32{req.Node * 10}, nil
+}
+
+func (b *blockingAccess) GetNeighboursBatch(ctx context.Context, requests []graphaccess.Request) []graphaccess.BatchResult {
+	return nil
+}
+
+func (b *blockingAccess) GetNeighbours64(ctx context.Context, req graphaccess.Request64) ([]uint64, error) {
+	return nil, nil
+}
+
+func (b *blockingAccess) GetStats() string {
+	return ""
+}
+
+func newBlockingAccess(blocked ...uint32) *blockingAccess {
+	b := &blockingAccess{release: make(map[uint32]chan struct{}), cancelled: make(chan struct{})}
+	for _, node := range blocked {
+		b.release[node] = make(chan struct{})
+	}
+	return b
+}
+
+// startTestServer serves the accessor over an in memory connection.
+func startTestServer(t *testing.T, access graphaccess.GraphAccess) pb.GraphAccessClient {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	pb.RegisterGraphAccessServer(s, &server{accessService: access})
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewGraphAccessClient(conn)
+}
+
+func streamRequest(id uint64, node uint32) *pb.StreamAccessRequest {
+	return &pb.StreamAccessRequest{
+		RequestId: id,
+		Request:   &pb.AccessRequest{NodeId: node, Label: 1, Direction: pb.AccessRequest_OUTGOING},
+	}
+}
+
+func TestStreamOutOfOrder(t *testing.T) {
+	defer func(old int) { *inFlight = old }(*inFlight)
+	*inFlight = 2
+	access := newBlockingAccess(1, 2)
+	client := startTestServer(t, access)
+	stream, err := client.GetNeighboursStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, node := range []uint32{1, 2, 3} {
+		if err := stream.Send(streamRequest(uint64(id), node)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//Both slots are taken by the blocked nodes, so node 3 is only read
+	//once one of them finishes.
+	for deadline := time.Now().Add(5 * time.Second); access.running.Load() != 2; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 2 running lookups, got %d", access.running.Load())
+		}
+	}
+	close(access.release[2])
+	for _, expected := range []uint64{1, 2} {
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.RequestId != expected || res.Response.Neighbours[0] != uint32(expected+1)*10 {
+			t.Fatalf("Expected response %d, got %v", expected, res)
+		}
+	}
+	close(access.release[1])
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	res, err := stream.Recv()
+	if err != nil || res.RequestId != 0 {
+		t.Fatalf("Expected response 0, got %v %v", res, err)
+	}
+	if access.peak.Load() > 2 {
+		t.Fatalf("%d lookups ran at once with 2 slots", access.peak.Load())
+	}
+}
+
+func TestStreamCancelled(t *testing.T) {
+	access := newBlockingAccess(1)
+	client := startTestServer(t, access)
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.GetNeighboursStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(streamRequest(0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(streamRequest(1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	res, err := stream.Recv()
+	if err != nil || res.RequestId != 1 {
+		t.Fatalf("Expected response 1, got %v %v", res, err)
+	}
+	cancel()
+	select {
+	case <-access.cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The blocked lookup was not cancelled")
+	}
+	_, err = stream.Recv()
+	if status.Code(err) != codes.Canceled {
+		t.Fatalf("Expected a cancelled stream, got %v", err)
+	}
+}
+
+func TestRpcError(t *testing.T) {
+	if status.Code(rpcError(context.Canceled)) != codes.Canceled {
+		t.Fatal("Expected a cancelled status")
+	}
+	if status.Code(rpcError(fmt.Errorf("Lookup failed: %w", context.DeadlineExceeded))) != codes.DeadlineExceeded {
+		t.Fatal("Expected a deadline exceeded status")
+	}
+	if status.Code(rpcError(errors.New("Fetch failed"))) != codes.Internal {
+		t.Fatal("Expected an internal status")
+	}
+	if status.Code(rpcError(status.Error(codes.Unavailable, "Client went away"))) != codes.Unavailable {
+		t.Fatal("Expected the status of the error")
+	}
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/adityachandla/graph_access_service/caches"
+)
+
+type fetchKey struct {
+	objectName string
+	bRange     ByteRange
+}
+
+// CoalescingFetcher shares a single fetch between concurrent requests
+// for the same range of the same object.
+type CoalescingFetcher struct {
+	fetcher   Fetcher
+	inFlight  *caches.InFlight[fetchKey, []byte]
+	coalesced atomic.Uint64
+}
+
+func NewCoalescingFetcher(fetcher Fetcher) *CoalescingFetcher {
+	return &CoalescingFetcher{
+		fetcher:  fetcher,
+		inFlight: caches.NewInFlight[fetchKey, []byte](),
+	}
+}
+
+func (c *CoalescingFetcher) Fetch(objectName string, bRange ByteRange) ([]byte, error) {
+	return c.FetchContext(context.TODO(), objectName, bRange)
+}
+
+// FetchContext returns the same slice to every request that shared the
+// fetch, callers must not modify it.
+func (c *CoalescingFetcher) FetchContext(ctx context.Context, objectName string, bRange ByteRange) ([]byte, error) {
+	res, shared, err := c.inFlight.Do(ctx, fetchKey{objectName, bRange},
+		func(ctx context.Context) ([]byte, error) {
+			return c.fetcher.FetchContext(ctx, objectName, bRange)
+		})
+	if shared {
+		c.coalesced.Add(1)
+	}
+	return res, err
+}
+
+func (c *CoalescingFetcher) ListFiles() ([]string, error) {
+	return c.fetcher.ListFiles()
+}
+
+func (c *CoalescingFetcher) Stats() map[string]uint64 {
+	stats := CollectStats(c.fetcher)
+	stats["coalescedFetches"] = c.coalesced.Load()
+	return stats
+}
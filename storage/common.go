@@ -1,19 +1,84 @@
 package storage
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
 type Fetcher interface {
 	// Fetch the byte range. Start and end of byte range are inclusive.
-	Fetch(objectName string, bRange ByteRange) []byte
-	ListFiles() []string
+	Fetch(objectName string, bRange ByteRange) ([]byte, error)
+	// FetchContext is the same as Fetch but gives up as soon as ctx is done.
+	FetchContext(ctx context.Context, objectName string, bRange ByteRange) ([]byte, error)
+	ListFiles() ([]string, error)
+}
+
+// Writer is implemented by storages that objects can be written to.
+type Writer interface {
+	// Put creates or replaces the object with data.
+	Put(objectName string, data []byte) error
+}
+
+// Versioner is implemented by fetchers whose objects can change. The
+// version of an object changes whenever its contents do.
+type Versioner interface {
+	ObjectVersions() (map[string]string, error)
 }
 
+// ByteRange is a range of bytes of an object, objects can be larger than
+// 4 GiB.
 type ByteRange struct {
-	start, end uint32
+	start, end uint64
 }
 
-func BRangeStart(start uint32) ByteRange {
+func BRangeStart(start uint64) ByteRange {
 	return ByteRange{start: start, end: 0}
 }
 
-func BRange(start, end uint32) ByteRange {
+func BRange(start, end uint64) ByteRange {
 	return ByteRange{start: start, end: end}
 }
+
+// Bounds returns the start and end of the range. An end of 0 means that
+// the range extends till the end of the object.
+func (b ByteRange) Bounds() (uint64, uint64) {
+	return b.start, b.end
+}
+
+// StatsReporter is implemented by fetchers that keep counters about the
+// requests that go through them.
+type StatsReporter interface {
+	Stats() map[string]uint64
+}
+
+// CollectStats returns the counters of the fetcher. Fetchers that wrap
+// another fetcher include the counters of the wrapped one.
+func CollectStats(fetcher Fetcher) map[string]uint64 {
+	if reporter, ok := fetcher.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return make(map[string]uint64)
+}
+
+// IsNotExist tells if the error was caused by fetching an object that
+// does not exist.
+func IsNotExist(err error) bool {
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+	var apiErr interface{ ErrorCode() string }
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchKey"
+}
+
+// InitializeService returns the fetcher for the bucket of the filesystem
+// type, which is either s3 or local.
+func InitializeService(fsType, bucket, region string) (Fetcher, error) {
+	if fsType == "s3" {
+		return InitializeS3Service(bucket, region), nil
+	} else if fsType == "local" {
+		return InitializeFsService(bucket), nil
+	}
+	return nil, fmt.Errorf("Invalid filesystem type %s", fsType)
+}
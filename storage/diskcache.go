@@ -0,0 +1,351 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/adityachandla/graph_access_service/lists"
+)
+
+const (
+	diskEntryMagic  = "GDC2"
+	diskEntrySuffix = ".blk"
+	diskTempPrefix  = ".tmp-"
+)
+
+type DiskCacheConfig struct {
+	Directory string
+	// MaxBytes is the budget for all the entries on disk.
+	MaxBytes int64
+}
+
+// diskEntry is a fetched range stored in a file of the cache directory.
+type diskEntry struct {
+	path string
+	size int64
+}
+
+// DiskCacheFetcher keeps the ranges fetched from the wrapped fetcher in
+// a local directory so they survive restarts. Every entry records the
+// version of its object, entries of objects that changed since they
+// were stored are dropped when the cache is opened.
+type DiskCacheFetcher struct {
+	fetcher   Fetcher
+	directory string
+	maxBytes  int64
+	versions  map[string]string
+
+	lock         sync.Mutex
+	mapping      map[fetchKey]*lists.ListNode[fetchKey, diskEntry]
+	recencyQueue *lists.LinkedList[fetchKey, diskEntry]
+	usedBytes    int64
+
+	hits, misses, evictions atomic.Uint64
+}
+
+// NewDiskCacheFetcher opens the cache directory and rebuilds the index
+// of the entries in it. The versions of the objects are listed once
+// through versioner, which is usually the fetcher that is wrapped by
+// the decorators in fetcher.
+func NewDiskCacheFetcher(fetcher Fetcher, versioner Versioner, config DiskCacheConfig) (*DiskCacheFetcher, error) {
+	if config.MaxBytes <= 0 {
+		return nil, fmt.Errorf("Disk cache budget should be positive, got %d", config.MaxBytes)
+	}
+	if err := os.MkdirAll(config.Directory, 0o755); err != nil {
+		return nil, fmt.Errorf("Unable to create cache directory %s: %w", config.Directory, err)
+	}
+	versions, err := versioner.ObjectVersions()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch object versions: %w", err)
+	}
+	d := &DiskCacheFetcher{
+		fetcher:      fetcher,
+		directory:    config.Directory,
+		maxBytes:     config.MaxBytes,
+		versions:     versions,
+		mapping:      make(map[fetchKey]*lists.ListNode[fetchKey, diskEntry]),
+		recencyQueue: lists.NewLinkedList[fetchKey, diskEntry](),
+	}
+	if err = d.loadIndex(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// loadIndex adds the valid entries in the directory to the recency queue
+// in the order of their last use and removes everything else.
+func (d *DiskCacheFetcher) loadIndex() error {
+	dirEntries, err := os.ReadDir(d.directory)
+	if err != nil {
+		return fmt.Errorf("Unable to list cache directory %s: %w", d.directory, err)
+	}
+	type loaded struct {
+		key     fetchKey
+		entry   diskEntry
+		lastUse time.Time
+	}
+	entries := make([]loaded, 0, len(dirEntries))
+	stale := 0
+	for _, e := range dirEntries {
+		name := e.Name()
+		path := filepath.Join(d.directory, name)
+		if strings.HasPrefix(name, diskTempPrefix) {
+			//Left over from a write that did not finish.
+			os.Remove(path)
+			continue
+		}
+		if e.IsDir() || !strings.HasSuffix(name, diskEntrySuffix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		key, version, err := readEntryHeader(path)
+		if err != nil || d.versions[key.objectName] != version || path != d.entryPath(key) {
+			stale++
+			os.Remove(path)
+			continue
+		}
+		entries = append(entries, loaded{key, diskEntry{path, info.Size()}, info.ModTime()})
+	}
+	slices.SortFunc(entries, func(a, b loaded) int {
+		return a.lastUse.Compare(b.lastUse)
+	})
+	for _, e := range entries {
+		d.mapping[e.key] = d.recencyQueue.AddToFront(e.key, e.entry)
+		d.usedBytes += e.entry.size
+	}
+	d.evictOverBudget()
+	log.Printf("Loaded %d cached ranges (%d bytes) from %s, dropped %d stale ones\n",
+		d.recencyQueue.Len(), d.usedBytes, d.directory, stale)
+	return nil
+}
+
+func (d *DiskCacheFetcher) Fetch(objectName string, bRange ByteRange) ([]byte, error) {
+	return d.FetchContext(context.TODO(), objectName, bRange)
+}
+
+func (d *DiskCacheFetcher) FetchContext(ctx context.Context, objectName string, bRange ByteRange) ([]byte, error) {
+	version, known := d.versions[objectName]
+	if !known {
+		//The object was created after the cache was opened, there is no
+		//version to check the entries against.
+		return d.fetcher.FetchContext(ctx, objectName, bRange)
+	}
+	key := fetchKey{objectName, bRange}
+	if data, ok := d.get(key, version); ok {
+		d.hits.Add(1)
+		return data, nil
+	}
+	d.misses.Add(1)
+	data, err := d.fetcher.FetchContext(ctx, objectName, bRange)
+	if err != nil {
+		return nil, err
+	}
+	if err = d.put(key, version, data); err != nil {
+		log.Printf("Unable to cache %s: %s\n", objectName, err)
+	}
+	return data, nil
+}
+
+func (d *DiskCacheFetcher) ListFiles() ([]string, error) {
+	return d.fetcher.ListFiles()
+}
+
+func (d *DiskCacheFetcher) Stats() map[string]uint64 {
+	stats := CollectStats(d.fetcher)
+	stats["diskHits"] = d.hits.Load()
+	stats["diskMisses"] = d.misses.Load()
+	stats["diskEvictions"] = d.evictions.Load()
+	d.lock.Lock()
+	stats["diskBytes"] = uint64(d.usedBytes)
+	d.lock.Unlock()
+	return stats
+}
+
+func (d *DiskCacheFetcher) get(key fetchKey, version string) ([]byte, bool) {
+	d.lock.Lock()
+	ref, ok := d.mapping[key]
+	if ok {
+		d.recencyQueue.MoveToFront(ref)
+	}
+	d.lock.Unlock()
+	if !ok {
+		return nil, false
+	}
+	contents, err := os.ReadFile(ref.Value.path)
+	if err == nil {
+		var data []byte
+		if data, err = decodeEntry(contents, key, version); err == nil {
+			//The modification time orders the entries when the index is
+			//rebuilt.
+			now := time.Now()
+			os.Chtimes(ref.Value.path, now, now)
+			return data, true
+		}
+	}
+	//The entry was evicted while it was being read or it is corrupt.
+	d.lock.Lock()
+	if d.mapping[key] == ref {
+		d.remove(ref)
+	}
+	d.lock.Unlock()
+	return nil, false
+}
+
+func (d *DiskCacheFetcher) put(key fetchKey, version string, data []byte) error {
+	contents := encodeEntry(key, version, data)
+	size := int64(len(contents))
+	if size > d.maxBytes {
+		return nil
+	}
+	tmp, err := os.CreateTemp(d.directory, diskTempPrefix)
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(contents)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	path := d.entryPath(key)
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	//Concurrent misses on the same range write the same file.
+	if ref, ok := d.mapping[key]; ok {
+		d.usedBytes += size - ref.Value.size
+		ref.Value.size = size
+		d.recencyQueue.MoveToFront(ref)
+	} else {
+		d.mapping[key] = d.recencyQueue.AddToFront(key, diskEntry{path, size})
+		d.usedBytes += size
+	}
+	d.evictOverBudget()
+	return nil
+}
+
+// evictOverBudget removes the least recently used entries till the
+// entries fit in the budget. The lock should be held.
+func (d *DiskCacheFetcher) evictOverBudget() {
+	for d.usedBytes > d.maxBytes {
+		ref, err := d.recencyQueue.PopBack()
+		if err != nil {
+			panic(err)
+		}
+		delete(d.mapping, ref.Key)
+		d.usedBytes -= ref.Value.size
+		os.Remove(ref.Value.path)
+		d.evictions.Add(1)
+	}
+}
+
+// remove drops the entry from the index and the disk. The lock should
+// be held.
+func (d *DiskCacheFetcher) remove(ref *lists.ListNode[fetchKey, diskEntry]) {
+	d.recencyQueue.Remove(ref)
+	delete(d.mapping, ref.Key)
+	d.usedBytes -= ref.Value.size
+	os.Remove(ref.Value.path)
+}
+
+// entryPath names the entry after a hash of its key, object names can
+// contain characters that are not allowed in file names.
+func (d *DiskCacheFetcher) entryPath(key fetchKey) string {
+	h := sha256.New()
+	h.Write([]byte(key.objectName))
+	h.Write(binary.LittleEndian.AppendUint64(nil, key.bRange.start))
+	h.Write(binary.LittleEndian.AppendUint64(nil, key.bRange.end))
+	return filepath.Join(d.directory, hex.EncodeToString(h.Sum(nil))+diskEntrySuffix)
+}
+
+// An entry starts with the magic, the start and end of the range, the
+// lengths of the object name and version followed by the name, the
+// version and the data of the range.
+const diskEntryFixedSize = len(diskEntryMagic) + 8 + 8 + 2 + 2
+
+var errCorruptEntry = errors.New("Corrupt disk cache entry")
+
+func encodeEntry(key fetchKey, version string, data []byte) []byte {
+	res := make([]byte, 0, diskEntryFixedSize+len(key.objectName)+len(version)+len(data))
+	res = append(res, diskEntryMagic...)
+	res = binary.LittleEndian.AppendUint64(res, key.bRange.start)
+	res = binary.LittleEndian.AppendUint64(res, key.bRange.end)
+	res = binary.LittleEndian.AppendUint16(res, uint16(len(key.objectName)))
+	res = binary.LittleEndian.AppendUint16(res, uint16(len(version)))
+	res = append(res, key.objectName...)
+	res = append(res, version...)
+	return append(res, data...)
+}
+
+// parseEntryHeader returns the key and version of the entry along with
+// the size of the header.
+func parseEntryHeader(contents []byte) (fetchKey, string, int, error) {
+	if len(contents) < diskEntryFixedSize || string(contents[:4]) != diskEntryMagic {
+		return fetchKey{}, "", 0, errCorruptEntry
+	}
+	start := binary.LittleEndian.Uint64(contents[4:12])
+	end := binary.LittleEndian.Uint64(contents[12:20])
+	nameLen := int(binary.LittleEndian.Uint16(contents[20:22]))
+	versionLen := int(binary.LittleEndian.Uint16(contents[22:24]))
+	headerSize := diskEntryFixedSize + nameLen + versionLen
+	if len(contents) < headerSize {
+		return fetchKey{}, "", 0, errCorruptEntry
+	}
+	name := string(contents[diskEntryFixedSize : diskEntryFixedSize+nameLen])
+	version := string(contents[diskEntryFixedSize+nameLen : headerSize])
+	return fetchKey{name, BRange(start, end)}, version, headerSize, nil
+}
+
+func decodeEntry(contents []byte, key fetchKey, version string) ([]byte, error) {
+	storedKey, storedVersion, headerSize, err := parseEntryHeader(contents)
+	if err != nil {
+		return nil, err
+	}
+	if storedKey != key || storedVersion != version {
+		return nil, errCorruptEntry
+	}
+	return contents[headerSize:], nil
+}
+
+// readEntryHeader reads only as much of the entry as is needed for its
+// header.
+func readEntryHeader(path string) (fetchKey, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return fetchKey{}, "", err
+	}
+	defer f.Close()
+	header := make([]byte, diskEntryFixedSize)
+	if _, err = io.ReadFull(f, header); err != nil {
+		return fetchKey{}, "", err
+	}
+	nameLen := int(binary.LittleEndian.Uint16(header[20:22]))
+	versionLen := int(binary.LittleEndian.Uint16(header[22:24]))
+	header = append(header, make([]byte, nameLen+versionLen)...)
+	if _, err = io.ReadFull(f, header[diskEntryFixedSize:]); err != nil {
+		return fetchKey{}, "", err
+	}
+	key, version, _, err := parseEntryHeader(header)
+	return key, version, err
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// versionedFetcher serves the bytes of objects and counts the fetches.
+type versionedFetcher struct {
+	objects  map[string][]byte
+	versions map[string]string
+	calls    int
+}
+
+func (v *versionedFetcher) Fetch(objectName string, bRange ByteRange) ([]byte, error) {
+	return v.FetchContext(context.TODO(), objectName, bRange)
+}
+
+func (v *versionedFetcher) FetchContext(_ context.Context, objectName string, bRange ByteRange) ([]byte, error) {
+	v.calls++
+	obj := v.objects[objectName]
+	if bRange.end == 0 {
+		return obj[bRange.start:], nil
+	}
+	return obj[bRange.start : bRange.end+1], nil
+}
+
+func (v *versionedFetcher) ListFiles() ([]string, error) {
+	return nil, nil
+}
+
+func (v *versionedFetcher) ObjectVersions() (map[string]string, error) {
+	return v.versions, nil
+}
+
+func newVersionedFetcher() *versionedFetcher {
+	return &versionedFetcher{
+		objects:  map[string][]byte{"a/0": {0, 1, 2, 3, 4, 5, 6, 7}, "b": {9, 8, 7}},
+		versions: map[string]string{"a/0": "v1", "b": "v1"},
+	}
+}
+
+func TestDiskCacheSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	inner := newVersionedFetcher()
+	config := DiskCacheConfig{Directory: dir, MaxBytes: 1 << 20}
+	d, err := NewDiskCacheFetcher(inner, inner, config)
+	assert.Nil(t, err)
+	res, err := d.Fetch("a/0", BRange(2, 4))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{2, 3, 4}, res)
+	res, err = d.Fetch("a/0", BRange(2, 4))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{2, 3, 4}, res)
+	_, err = d.Fetch("b", BRangeStart(1))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, inner.calls)
+	assert.Equal(t, uint64(1), d.Stats()["diskHits"])
+
+	//Only the entries of the object that did not change are kept.
+	inner.versions["b"] = "v2"
+	d, err = NewDiskCacheFetcher(inner, inner, config)
+	assert.Nil(t, err)
+	res, err = d.Fetch("a/0", BRange(2, 4))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{2, 3, 4}, res)
+	res, err = d.Fetch("b", BRangeStart(1))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{8, 7}, res)
+	assert.Equal(t, 3, inner.calls)
+}
+
+func TestDiskCacheEvicts(t *testing.T) {
+	dir := t.TempDir()
+	inner := newVersionedFetcher()
+	entrySize := int64(len(encodeEntry(fetchKey{"a/0", BRange(0, 1)}, "v1", []byte{0, 1})))
+	d, err := NewDiskCacheFetcher(inner, inner, DiskCacheConfig{Directory: dir, MaxBytes: 2 * entrySize})
+	assert.Nil(t, err)
+	for _, start := range []uint64{0, 2, 0, 4} {
+		_, err = d.Fetch("a/0", BRange(start, start+1))
+		assert.Nil(t, err)
+	}
+	//The range starting at 2 was the least recently used.
+	assert.Equal(t, uint64(1), d.Stats()["diskEvictions"])
+	assert.Equal(t, uint64(2*entrySize), d.Stats()["diskBytes"])
+	files, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+	_, err = d.Fetch("a/0", BRange(0, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, inner.calls)
+}
+
+func TestDiskCacheDropsCorruptEntries(t *testing.T) {
+	dir := t.TempDir()
+	inner := newVersionedFetcher()
+	config := DiskCacheConfig{Directory: dir, MaxBytes: 1 << 20}
+	d, err := NewDiskCacheFetcher(inner, inner, config)
+	assert.Nil(t, err)
+	_, err = d.Fetch("b", BRange(0, 1))
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(d.entryPath(fetchKey{"b", BRange(0, 1)}), []byte("junk"), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, diskTempPrefix+"1"), []byte("partial"), 0o644))
+
+	d, err = NewDiskCacheFetcher(inner, inner, config)
+	assert.Nil(t, err)
+	files, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Empty(t, files)
+	res, err := d.Fetch("b", BRange(0, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{9, 8}, res)
+}
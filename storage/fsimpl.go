@@ -1,17 +1,26 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	"io"
+	iofs "io/fs"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
+// FsImpl serves the files of a local directory, the names of the files
+// are relative to the directory.
 type FsImpl struct {
 	directory string
 }
 
 func InitializeFsService(directory string) Fetcher {
+	return newFsImpl(directory)
+}
+
+func newFsImpl(directory string) *FsImpl {
 	f, err := os.Open(directory)
 	if err != nil {
 		panic("Unable to open the directory")
@@ -30,43 +39,106 @@ func InitializeFsService(directory string) Fetcher {
 	return &FsImpl{directory}
 }
 
-func (fs *FsImpl) ListFiles() []string {
-	//Error checked while initialization
-	f, _ := os.Open(fs.directory)
-	defer f.Close()
-	entries, err := f.ReadDir(0)
+// ListFiles returns the files in the directory and its subdirectories,
+// like S3 lists the keys under every prefix. The names of the files in
+// subdirectories are their paths relative to the directory.
+func (fs *FsImpl) ListFiles() ([]string, error) {
+	res := make([]string, 0)
+	err := filepath.WalkDir(fs.directory, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name, err := filepath.Rel(fs.directory, path)
+		if err != nil {
+			return err
+		}
+		res = append(res, filepath.ToSlash(name))
+		return nil
+	})
 	if err != nil {
-		panic("Unable to list files")
+		return nil, fmt.Errorf("Unable to list files: %w", err)
 	}
-	res := make([]string, len(entries))
-	for i, e := range entries {
-		res[i] = fs.directory + e.Name()
+	return res, nil
+}
+
+// ObjectVersions combines the size and the modification time of every
+// file in the directory into its version.
+func (fs *FsImpl) ObjectVersions() (map[string]string, error) {
+	files, err := fs.ListFiles()
+	if err != nil {
+		return nil, err
 	}
-	return res
+	versions := make(map[string]string, len(files))
+	for _, name := range files {
+		stat, err := os.Stat(fs.directory + name)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to fetch file status of %s: %w", name, err)
+		}
+		versions[name] = fmt.Sprintf("%d/%d", stat.ModTime().UnixNano(), stat.Size())
+	}
+	return versions, nil
+}
+
+func (fs *FsImpl) Fetch(name string, brange ByteRange) ([]byte, error) {
+	return fs.FetchContext(context.TODO(), name, brange)
 }
 
-func (fs *FsImpl) Fetch(path string, brange ByteRange) []byte {
+// FetchContext only checks ctx before reading, local reads are
+// short enough to not be worth interrupting.
+func (fs *FsImpl) FetchContext(ctx context.Context, name string, brange ByteRange) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	path := fs.directory + name
 	f, err := os.Open(path)
 	if err != nil {
-		panic(fmt.Errorf("Unable to open %s %s", path, err))
+		return nil, fmt.Errorf("Unable to open %s: %w", path, err)
 	}
 	defer f.Close()
-	f.Seek(int64(brange.start), 0)
+	if _, err = f.Seek(int64(brange.start), io.SeekStart); err != nil {
+		return nil, fmt.Errorf("Unable to seek in %s: %w", path, err)
+	}
 	if brange.end == 0 {
 		res, err := io.ReadAll(f)
 		if err != nil {
-			panic("Error while reading bytes")
+			return nil, fmt.Errorf("Error while reading %s: %w", path, err)
 		}
-		return res
+		return res, nil
 	}
 	numBytes := brange.end - brange.start + 1
-	res := make([]byte, 0, numBytes)
-	for len(res) < cap(res) {
-		n, err := f.Read(res[len(res):cap(res)])
-		res = res[:len(res)+n]
-		if err != nil {
-			panic("Error while reading bytes")
-		}
+	res := make([]byte, numBytes)
+	if _, err = io.ReadFull(f, res); err != nil {
+		return nil, fmt.Errorf("Error while reading %s: %w", path, err)
+	}
+	return res, nil
+}
+
+// Put writes the file through a temporary file so readers never see a
+// partially written file.
+func (fs *FsImpl) Put(name string, data []byte) error {
+	tmp, err := os.CreateTemp(fs.directory, ".tmp-")
+	if err != nil {
+		return fmt.Errorf("Unable to create %s: %w", name, err)
+	}
+	//Temporary files are only readable by the owner.
+	if err = tmp.Chmod(0o644); err == nil {
+		_, err = tmp.Write(data)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(fs.directory+name), 0o755)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), fs.directory+name)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("Unable to write %s: %w", name, err)
 	}
-	return res
+	return nil
 }
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type HedgeConfig struct {
+	// A duplicate request is sent once a request has taken longer than
+	// this percentile (between 0 and 1) of the observed latencies.
+	Percentile float64
+	// Delay that is used till enough latencies have been observed.
+	InitialDelay time.Duration
+	// Number of recent latencies from which the delay is computed.
+	WindowSize int
+}
+
+// HedgeFetcher sends a second request for the same range when the first
+// one is slower than usual. The first response wins and the other request
+// is cancelled.
+type HedgeFetcher struct {
+	fetcher   Fetcher
+	config    HedgeConfig
+	latencies *latencyWindow
+	hedges    atomic.Uint64
+	hedgeWins atomic.Uint64
+}
+
+func NewHedgeFetcher(fetcher Fetcher, config HedgeConfig) *HedgeFetcher {
+	if config.Percentile <= 0 || config.Percentile >= 1 {
+		panic("Percentile should be between 0 and 1")
+	}
+	return &HedgeFetcher{
+		fetcher:   fetcher,
+		config:    config,
+		latencies: newLatencyWindow(config.WindowSize, config.InitialDelay),
+	}
+}
+
+type hedgeResult struct {
+	res    []byte
+	err    error
+	hedged bool
+}
+
+func (h *HedgeFetcher) Fetch(objectName string, bRange ByteRange) ([]byte, error) {
+	return h.FetchContext(context.TODO(), objectName, bRange)
+}
+
+func (h *HedgeFetcher) FetchContext(ctx context.Context, objectName string, bRange ByteRange) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	// Cancels whichever request lost.
+	defer cancel()
+	results := make(chan hedgeResult, 2)
+	send := func(hedged bool) {
+		start := time.Now()
+		res, err := h.fetcher.FetchContext(ctx, objectName, bRange)
+		if err == nil {
+			h.latencies.record(time.Since(start))
+		}
+		results <- hedgeResult{res, err, hedged}
+	}
+	go send(false)
+
+	timer := time.NewTimer(h.latencies.percentile(h.config.Percentile))
+	defer timer.Stop()
+	pending := 1
+	var lastErr error
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			h.hedges.Add(1)
+			pending++
+			go send(true)
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				if result.hedged {
+					h.hedgeWins.Add(1)
+				}
+				return result.res, nil
+			}
+			// The other request can still succeed. If there is none,
+			// a request that failed quickly isn't worth hedging.
+			lastErr = result.err
+		}
+	}
+	return nil, lastErr
+}
+
+func (h *HedgeFetcher) ListFiles() ([]string, error) {
+	return h.fetcher.ListFiles()
+}
+
+func (h *HedgeFetcher) Stats() map[string]uint64 {
+	stats := CollectStats(h.fetcher)
+	hedges, wins := h.hedges.Load(), h.hedgeWins.Load()
+	stats["hedgedRequests"] = hedges
+	stats["hedgeWins"] = wins
+	if hedges > 0 {
+		stats["hedgeWinPercent"] = 100 * wins / hedges
+	}
+	stats["hedgeDelayMicros"] = uint64(h.latencies.percentile(h.config.Percentile).Microseconds())
+	return stats
+}
+
+// latencyWindow keeps the most recent latencies in a ring buffer.
+// Percentiles are recomputed after every recomputeEvery new latencies
+// instead of on every request.
+type latencyWindow struct {
+	lock        sync.Mutex
+	samples     []time.Duration
+	next        int
+	full        bool
+	sinceSorted int
+	sorted      []time.Duration
+	initial     time.Duration
+}
+
+const recomputeEvery = 64
+
+func newLatencyWindow(size int, initial time.Duration) *latencyWindow {
+	if size < 1 {
+		panic("Window size should be >= 1")
+	}
+	return &latencyWindow{samples: make([]time.Duration, size), initial: initial}
+}
+
+func (w *latencyWindow) record(latency time.Duration) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.samples[w.next] = latency
+	w.next = (w.next + 1) % len(w.samples)
+	if w.next == 0 {
+		w.full = true
+	}
+	w.sinceSorted++
+}
+
+func (w *latencyWindow) percentile(p float64) time.Duration {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	count := w.next
+	if w.full {
+		count = len(w.samples)
+	}
+	// Until we have a few samples the initial delay is a better
+	// estimate than a percentile of almost nothing.
+	if count < min(recomputeEvery, len(w.samples)) {
+		return w.initial
+	}
+	if w.sorted == nil || w.sinceSorted >= recomputeEvery {
+		w.sorted = slices.Clone(w.samples[:count])
+		slices.Sort(w.sorted)
+		w.sinceSorted = 0
+	}
+	return w.sorted[int(p*float64(len(w.sorted)-1))]
+}
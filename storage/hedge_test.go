@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slowFetcher takes delays[i] for the i'th request.
+type slowFetcher struct {
+	delays []time.Duration
+	calls  chan int
+}
+
+func (s *slowFetcher) Fetch(objectName string, bRange ByteRange) ([]byte, error) {
+	return s.FetchContext(context.TODO(), objectName, bRange)
+}
+
+func (s *slowFetcher) FetchContext(ctx context.Context, _ string, _ ByteRange) ([]byte, error) {
+	call := <-s.calls
+	s.calls <- call + 1
+	select {
+	case <-time.After(s.delays[call]):
+		return []byte{byte(call)}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *slowFetcher) ListFiles() ([]string, error) {
+	return nil, nil
+}
+
+func newSlowFetcher(delays ...time.Duration) *slowFetcher {
+	s := &slowFetcher{delays: delays, calls: make(chan int, 1)}
+	s.calls <- 0
+	return s
+}
+
+func TestHedgeWins(t *testing.T) {
+	inner := newSlowFetcher(time.Second, time.Millisecond)
+	h := NewHedgeFetcher(inner, HedgeConfig{Percentile: 0.9, InitialDelay: 5 * time.Millisecond, WindowSize: 10})
+	res, err := h.Fetch("a", BRange(0, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{1}, res)
+	stats := h.Stats()
+	assert.Equal(t, uint64(1), stats["hedgedRequests"])
+	assert.Equal(t, uint64(1), stats["hedgeWins"])
+}
+
+func TestNoHedgeWhenFast(t *testing.T) {
+	inner := newSlowFetcher(time.Millisecond)
+	h := NewHedgeFetcher(inner, HedgeConfig{Percentile: 0.9, InitialDelay: time.Second, WindowSize: 10})
+	res, err := h.Fetch("a", BRange(0, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0}, res)
+	assert.Equal(t, uint64(0), h.Stats()["hedgedRequests"])
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	w := newLatencyWindow(100, time.Second)
+	assert.Equal(t, time.Second, w.percentile(0.5))
+	for i := 1; i <= 100; i++ {
+		w.record(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 90*time.Millisecond, w.percentile(0.9))
+}
@@ -0,0 +1,109 @@
+//go:build unix
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// MmapImpl serves the files of a local directory from memory mappings.
+// Every file is mapped once, the first time that it is fetched, and the
+// fetched bytes point into the mapping without being copied.
+type MmapImpl struct {
+	fs       *FsImpl
+	lock     sync.Mutex
+	mappings map[string][]byte
+}
+
+func InitializeMmapService(directory string) Fetcher {
+	return &MmapImpl{
+		fs:       newFsImpl(directory),
+		mappings: make(map[string][]byte),
+	}
+}
+
+func (m *MmapImpl) ListFiles() ([]string, error) {
+	return m.fs.ListFiles()
+}
+
+func (m *MmapImpl) ObjectVersions() (map[string]string, error) {
+	return m.fs.ObjectVersions()
+}
+
+func (m *MmapImpl) Fetch(name string, brange ByteRange) ([]byte, error) {
+	return m.FetchContext(context.TODO(), name, brange)
+}
+
+// FetchContext returns a slice of the read only mapping of the file,
+// callers must not modify it.
+func (m *MmapImpl) FetchContext(ctx context.Context, name string, brange ByteRange) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	data, err := m.mapping(name)
+	if err != nil {
+		return nil, err
+	}
+	if int(brange.start) > len(data) {
+		return nil, fmt.Errorf("Range start %d is past the end of %s", brange.start, name)
+	}
+	if brange.end == 0 {
+		return data[brange.start:len(data):len(data)], nil
+	}
+	if int(brange.end) >= len(data) {
+		return nil, fmt.Errorf("Range end %d is past the end of %s", brange.end, name)
+	}
+	//Limit the capacity so appending to the result can not write into
+	//the mapping.
+	return data[brange.start : brange.end+1 : brange.end+1], nil
+}
+
+func (m *MmapImpl) mapping(name string) ([]byte, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if data, ok := m.mappings[name]; ok {
+		return data, nil
+	}
+	path := m.fs.directory + name
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open %s: %w", path, err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch file status of %s: %w", path, err)
+	}
+	var data []byte
+	//Empty files can not be mapped.
+	if stat.Size() > 0 {
+		data, err = syscall.Mmap(int(f.Fd()), 0, int(stat.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to map %s: %w", path, err)
+		}
+	}
+	m.mappings[name] = data
+	return data, nil
+}
+
+// Close unmaps all the files. Slices returned by earlier fetches must
+// not be used after that.
+func (m *MmapImpl) Close() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var errs []error
+	for path, data := range m.mappings {
+		if data != nil {
+			if err := syscall.Munmap(data); err != nil {
+				errs = append(errs, fmt.Errorf("Unable to unmap %s: %w", path, err))
+			}
+		}
+		delete(m.mappings, path)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package storage
+
+import "log"
+
+// InitializeMmapService falls back to reading the files on platforms
+// without mmap.
+func InitializeMmapService(directory string) Fetcher {
+	log.Println("Memory mapping is not supported, reading files instead")
+	return InitializeFsService(directory)
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMmapMatchesFs(t *testing.T) {
+	dir := t.TempDir()
+	contents := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "a"), contents, 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "empty"), nil, 0o644))
+	fs := InitializeFsService(dir)
+	m := InitializeMmapService(dir)
+
+	files, err := m.ListFiles()
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+	path := "a"
+	for _, bRange := range []ByteRange{BRange(0, 7), BRange(3, 3), BRangeStart(4), BRangeStart(10)} {
+		expected, err := fs.Fetch(path, bRange)
+		assert.Nil(t, err)
+		res, err := m.Fetch(path, bRange)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, res)
+	}
+	_, err = m.Fetch(path, BRange(8, 10))
+	assert.NotNil(t, err)
+	_, err = m.Fetch("missing", BRange(0, 1))
+	assert.NotNil(t, err)
+	res, err := m.Fetch("empty", BRangeStart(0))
+	assert.Nil(t, err)
+	assert.Empty(t, res)
+}
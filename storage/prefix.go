@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// PrefixFetcher serves the objects whose names start with a prefix as if
+// the prefix was the root of the bucket.
+type PrefixFetcher struct {
+	fetcher Fetcher
+	prefix  string
+}
+
+func NewPrefixFetcher(fetcher Fetcher, prefix string) *PrefixFetcher {
+	return &PrefixFetcher{fetcher: fetcher, prefix: prefix}
+}
+
+func (p *PrefixFetcher) Fetch(objectName string, bRange ByteRange) ([]byte, error) {
+	return p.fetcher.Fetch(p.prefix+objectName, bRange)
+}
+
+func (p *PrefixFetcher) FetchContext(ctx context.Context, objectName string, bRange ByteRange) ([]byte, error) {
+	return p.fetcher.FetchContext(ctx, p.prefix+objectName, bRange)
+}
+
+// ListFiles returns the names of the objects under the prefix without
+// the prefix.
+func (p *PrefixFetcher) ListFiles() ([]string, error) {
+	files, err := p.fetcher.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(files))
+	for _, f := range files {
+		if name, ok := strings.CutPrefix(f, p.prefix); ok {
+			res = append(res, name)
+		}
+	}
+	return res, nil
+}
+
+func (p *PrefixFetcher) Put(objectName string, data []byte) error {
+	writer, ok := p.fetcher.(Writer)
+	if !ok {
+		return errors.New("Unable to write objects through a fetcher that is not a writer")
+	}
+	return writer.Put(p.prefix+objectName, data)
+}
@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixFetcher(t *testing.T) {
+	fs := newFsImpl(t.TempDir())
+	assert.Nil(t, fs.Put("root", []byte("r")))
+	partition := NewPrefixFetcher(fs, "labels-1/")
+	assert.Nil(t, partition.Put("a", []byte("abc")))
+	assert.Nil(t, partition.Put("_manifest", []byte("m")))
+
+	files, err := fs.ListFiles()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"root", "labels-1/a", "labels-1/_manifest"}, files)
+	files, err = partition.ListFiles()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"a", "_manifest"}, files)
+
+	data, err := partition.Fetch("a", BRange(1, 2))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("bc"), data)
+	_, err = partition.Fetch("root", BRangeStart(0))
+	assert.True(t, IsNotExist(err))
+}
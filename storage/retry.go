@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+type RetryConfig struct {
+	// Maximum number of attempts for a request, including the first one.
+	MaxAttempts int
+	// Delay before the first retry, it is doubled after every attempt
+	// up to MaxDelay.
+	BaseDelay, MaxDelay time.Duration
+	// Fraction of the delay that is randomized. With a jitter of 0.5
+	// the delay is anywhere between half and all of the backoff.
+	Jitter float64
+}
+
+// RetryFetcher retries the requests of the wrapped fetcher that fail
+// with a retryable error, see isRetryable.
+type RetryFetcher struct {
+	fetcher Fetcher
+	config  RetryConfig
+	retries atomic.Uint64
+	giveUps atomic.Uint64
+}
+
+func NewRetryFetcher(fetcher Fetcher, config RetryConfig) *RetryFetcher {
+	if config.MaxAttempts < 1 {
+		panic("MaxAttempts should be >= 1")
+	}
+	return &RetryFetcher{fetcher: fetcher, config: config}
+}
+
+func (r *RetryFetcher) Fetch(objectName string, bRange ByteRange) ([]byte, error) {
+	return r.FetchContext(context.TODO(), objectName, bRange)
+}
+
+func (r *RetryFetcher) FetchContext(ctx context.Context, objectName string, bRange ByteRange) ([]byte, error) {
+	var res []byte
+	err := r.retry(ctx, func() (err error) {
+		res, err = r.fetcher.FetchContext(ctx, objectName, bRange)
+		return err
+	})
+	return res, err
+}
+
+func (r *RetryFetcher) ListFiles() ([]string, error) {
+	var res []string
+	err := r.retry(context.TODO(), func() (err error) {
+		res, err = r.fetcher.ListFiles()
+		return err
+	})
+	return res, err
+}
+
+func (r *RetryFetcher) Stats() map[string]uint64 {
+	stats := CollectStats(r.fetcher)
+	stats["retries"] = r.retries.Load()
+	stats["retryGiveUps"] = r.giveUps.Load()
+	return stats
+}
+
+func (r *RetryFetcher) retry(ctx context.Context, request func() error) error {
+	delay := r.config.BaseDelay
+	for attempt := 1; ; attempt++ {
+		err := request()
+		if err == nil || !isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		if attempt == r.config.MaxAttempts {
+			r.giveUps.Add(1)
+			return err
+		}
+		r.retries.Add(1)
+		log.Printf("Attempt %d failed, retrying: %s\n", attempt, err)
+		timer := time.NewTimer(r.withJitter(delay))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		delay = min(2*delay, r.config.MaxDelay)
+	}
+}
+
+func (r *RetryFetcher) withJitter(delay time.Duration) time.Duration {
+	fixed := float64(delay) * (1 - r.config.Jitter)
+	return time.Duration(fixed + rand.Float64()*float64(delay)*r.config.Jitter)
+}
+
+// isRetryable tells apart the errors that can go away on their own, like
+// throttling, server errors, timeouts or a reset connection, from the
+// ones that won't. Errors that we don't recognise, such as the short read
+// of a corrupt file, are not retried.
+func isRetryable(err error) bool {
+	//A done context is a timeout as well.
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var httpErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &httpErr) {
+		code := httpErr.HTTPStatusCode()
+		return code == 429 || code >= 500
+	}
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "SlowDown", "Throttling", "ThrottlingException", "RequestTimeout", "InternalError", "ServiceUnavailable":
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE)
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyFetcher fails with err for the first failures requests.
+type flakyFetcher struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyFetcher) Fetch(objectName string, bRange ByteRange) ([]byte, error) {
+	return f.FetchContext(context.TODO(), objectName, bRange)
+}
+
+func (f *flakyFetcher) FetchContext(_ context.Context, _ string, _ ByteRange) ([]byte, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	return []byte{1}, nil
+}
+
+func (f *flakyFetcher) ListFiles() ([]string, error) {
+	return nil, nil
+}
+
+type statusErr int
+
+func (s statusErr) Error() string       { return fmt.Sprintf("status %d", int(s)) }
+func (s statusErr) HTTPStatusCode() int { return int(s) }
+
+var testConfig = RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Jitter: 0.5}
+
+func TestRetrySucceeds(t *testing.T) {
+	inner := &flakyFetcher{failures: 2, err: statusErr(503)}
+	r := NewRetryFetcher(inner, testConfig)
+	res, err := r.Fetch("a", BRange(0, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{1}, res)
+	assert.Equal(t, uint64(2), r.Stats()["retries"])
+	assert.Equal(t, uint64(0), r.Stats()["retryGiveUps"])
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	inner := &flakyFetcher{failures: 5, err: fmt.Errorf("read: %w", syscall.ECONNRESET)}
+	r := NewRetryFetcher(inner, testConfig)
+	_, err := r.Fetch("a", BRange(0, 1))
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, inner.calls)
+	assert.Equal(t, uint64(1), r.Stats()["retryGiveUps"])
+}
+
+func TestFatalNotRetried(t *testing.T) {
+	fatals := []error{statusErr(404), fmt.Errorf("open: %w", fs.ErrNotExist), context.Canceled,
+		io.ErrUnexpectedEOF, errors.New("Unable to decode")}
+	for _, fatal := range fatals {
+		inner := &flakyFetcher{failures: 1, err: fatal}
+		r := NewRetryFetcher(inner, testConfig)
+		_, err := r.Fetch("a", BRange(0, 1))
+		assert.Equal(t, fatal, err)
+		assert.Equal(t, 1, inner.calls)
+	}
+}
+
+func TestTimeoutRetried(t *testing.T) {
+	inner := &flakyFetcher{failures: 1, err: fmt.Errorf("read: %w", os.ErrDeadlineExceeded)}
+	r := NewRetryFetcher(inner, testConfig)
+	_, err := r.Fetch("a", BRange(0, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, inner.calls)
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 type S3Impl struct {
@@ -24,40 +26,74 @@ func InitializeS3Service(bucketName, region string) Fetcher {
 	return &S3Impl{s3.NewFromConfig(cfg), bucketName}
 }
 
-func (service *S3Impl) ListFiles() []string {
+func (service *S3Impl) ListFiles() ([]string, error) {
 	log.Printf("Fetching files in bucket %s\n", service.bucket)
+	keys := make([]string, 0)
+	err := service.listObjects(func(obj types.Object) {
+		keys = append(keys, aws.ToString(obj.Key))
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Fetched %d objects", len(keys))
+	return keys, nil
+}
+
+// ObjectVersions combines the ETag and the size of every object in the
+// bucket into its version.
+func (service *S3Impl) ObjectVersions() (map[string]string, error) {
+	versions := make(map[string]string)
+	err := service.listObjects(func(obj types.Object) {
+		versions[aws.ToString(obj.Key)] = fmt.Sprintf("%s/%d",
+			aws.ToString(obj.ETag), aws.ToInt64(obj.Size))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (service *S3Impl) listObjects(visit func(types.Object)) error {
 	listRequest := &s3.ListObjectsV2Input{
 		Bucket: aws.String(service.bucket),
 	}
-	response := service.getListResponse(listRequest)
-	keys := make([]string, 0, len(response.Contents))
+	response, err := service.getListResponse(listRequest)
+	if err != nil {
+		return err
+	}
 	for _, obj := range response.Contents {
-		keys = append(keys, aws.ToString(obj.Key))
+		visit(obj)
 	}
 	// It is possible that we weren't able to fetch all the files
 	// in the first request so we paginate if the result was
 	// truncated.
-	for *response.IsTruncated {
+	for aws.ToBool(response.IsTruncated) {
 		listRequest.ContinuationToken = response.NextContinuationToken
-		response = service.getListResponse(listRequest)
+		response, err = service.getListResponse(listRequest)
+		if err != nil {
+			return err
+		}
 		for _, obj := range response.Contents {
-			keys = append(keys, aws.ToString(obj.Key))
+			visit(obj)
 		}
 	}
-	log.Printf("Fetched %d objects", len(keys))
-	return keys
+	return nil
 }
 
 func (service *S3Impl) getListResponse(
-	req *s3.ListObjectsV2Input) *s3.ListObjectsV2Output {
+	req *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
 	response, err := service.client.ListObjectsV2(context.TODO(), req)
 	if err != nil {
-		panic(fmt.Errorf("Unable to list objects: %s", err))
+		return nil, fmt.Errorf("Unable to list objects: %w", err)
 	}
-	return response
+	return response, nil
 }
 
-func (service *S3Impl) Fetch(objectName string, bRange ByteRange) []byte {
+func (service *S3Impl) Fetch(objectName string, bRange ByteRange) ([]byte, error) {
+	return service.FetchContext(context.TODO(), objectName, bRange)
+}
+
+func (service *S3Impl) FetchContext(ctx context.Context, objectName string, bRange ByteRange) ([]byte, error) {
 	var rangeField string
 	if bRange.end == 0 {
 		rangeField = fmt.Sprintf("bytes=%d-", bRange.start)
@@ -69,14 +105,26 @@ func (service *S3Impl) Fetch(objectName string, bRange ByteRange) []byte {
 		Key:    aws.String(objectName),
 		Range:  aws.String(rangeField),
 	}
-	res, err := service.client.GetObject(context.TODO(), req)
+	res, err := service.client.GetObject(ctx, req)
 	if err != nil {
-		panic(fmt.Errorf("GetObject request failed: %s", err))
+		return nil, fmt.Errorf("GetObject request for %s failed: %w", objectName, err)
 	}
 	defer res.Body.Close()
 	resBytes, err := io.ReadAll(res.Body)
 	if err != nil {
-		panic(fmt.Errorf("Unable to read response body: %s", err))
+		return nil, fmt.Errorf("Unable to read response body for %s: %w", objectName, err)
+	}
+	return resBytes, nil
+}
+
+func (service *S3Impl) Put(objectName string, data []byte) error {
+	req := &s3.PutObjectInput{
+		Bucket: aws.String(service.bucket),
+		Key:    aws.String(objectName),
+		Body:   bytes.NewReader(data),
+	}
+	if _, err := service.client.PutObject(context.TODO(), req); err != nil {
+		return fmt.Errorf("PutObject request for %s failed: %w", objectName, err)
 	}
-	return resBytes
+	return nil
 }
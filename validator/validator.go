@@ -0,0 +1,451 @@
+// Package validator checks that the files of a graph can be read by the
+// accessors, so that broken converter output is found before a benchmark
+// reads it.
+package validator
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/graphaccess"
+	"github.com/adityachandla/graph_access_service/storage"
+)
+
+// Kinds of issues.
+const (
+	// KindCorrupt is a file that can not be fetched or decoded.
+	KindCorrupt = "corrupt"
+	// KindOverlap is a node that is stored in more than one file.
+	KindOverlap = "overlap"
+	// KindGap is a range of nodes between two files that no file stores.
+	KindGap = "gap"
+	// KindOffsets is an offset table that csrfile.Header.ValidateOffsets
+	// rejects, such as an offset that is smaller than the one before it.
+	KindOffsets = "offsets"
+	// KindUnsorted is a node whose edges are not sorted by label.
+	KindUnsorted = "unsorted"
+	// KindDangling is an edge to a node that no file stores.
+	KindDangling = "dangling"
+	// KindUnmatched is an outgoing edge without the incoming edge at its
+	// destination, or the other way around.
+	KindUnmatched = "unmatched"
+)
+
+type Issue struct {
+	Kind    string `json:"kind"`
+	Object  string `json:"object,omitempty"`
+	Message string `json:"message"`
+}
+
+// Report is the result of a check. Only the first MaxIssues issues are
+// listed, Counts has the number of issues of every kind.
+type Report struct {
+	OK        bool           `json:"ok"`
+	Files     int            `json:"files"`
+	Nodes     uint64         `json:"nodes"`
+	Edges     uint64         `json:"edges"`
+	Start     uint64         `json:"start"`
+	End       uint64         `json:"end"`
+	Issues    []Issue        `json:"issues"`
+	Counts    map[string]int `json:"counts"`
+	maxIssues int
+}
+
+func (r *Report) add(kind, object, format string, args ...any) {
+	r.Counts[kind]++
+	if r.maxIssues > 0 && len(r.Issues) >= r.maxIssues {
+		return
+	}
+	r.Issues = append(r.Issues, Issue{Kind: kind, Object: object, Message: fmt.Sprintf(format, args...)})
+}
+
+type Options struct {
+	// MaxIssues is the number of issues that are listed, 0 lists all.
+	MaxIssues int
+	// Parallelism is the number of files that are read at once,
+	// graphaccess.ProbeParallelism is used if it is 0.
+	Parallelism int
+}
+
+// triple is an edge of the graph, incoming edges are stored with their
+// source and destination swapped so that they match their outgoing edge.
+type triple struct {
+	src, dst uint64
+	label    uint32
+}
+
+func compareTriples(a, b triple) int {
+	if c := cmp.Compare(a.src, b.src); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.label, b.label); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.dst, b.dst)
+}
+
+// hash starts from the label and folds in the source and then the
+// destination, each with the hash_combine step of boost followed by the
+// finalizer of splitmix64.
+func (t triple) hash() uint64 {
+	h := uint64(t.label)
+	for _, v := range []uint64{t.src, t.dst} {
+		h ^= v + 0x9e3779b97f4a7c15 + h<<6 + h>>2
+		h = (h ^ h>>30) * 0xbf58476d1ce4e5b9
+		h = (h ^ h>>27) * 0x94d049bb133111eb
+		h ^= h >> 31
+	}
+	return h
+}
+
+// balance adds up the hashes of the outgoing edges and subtracts the
+// hashes of the incoming edges, grouped by the file that their source
+// falls in. The sum of a group is zero when its outgoing and incoming
+// edges match, in whatever order they are read, so the edges do not have
+// to be held in memory.
+type balance struct {
+	//Sorted first nodes of the files, the group of a node is the number
+	//of files that start at or before it.
+	starts []uint64
+	sums   []atomic.Uint64
+	counts []atomic.Int64
+}
+
+func newBalance(files []*fileResult) *balance {
+	b := &balance{
+		starts: make([]uint64, len(files)),
+		sums:   make([]atomic.Uint64, len(files)+1),
+		counts: make([]atomic.Int64, len(files)+1),
+	}
+	for i, f := range files {
+		b.starts[i] = f.header.Start
+	}
+	return b
+}
+
+func (b *balance) group(node uint64) int {
+	return sort.Search(len(b.starts), func(i int) bool { return b.starts[i] > node })
+}
+
+func (b *balance) add(t triple, outgoing bool) {
+	g := b.group(t.src)
+	if outgoing {
+		b.sums[g].Add(t.hash())
+		b.counts[g].Add(1)
+	} else {
+		b.sums[g].Add(-t.hash())
+		b.counts[g].Add(-1)
+	}
+}
+
+// unmatched returns the groups whose edges do not match.
+func (b *balance) unmatched() []bool {
+	groups := make([]bool, len(b.sums))
+	for g := range groups {
+		groups[g] = b.sums[g].Load() != 0 || b.counts[g].Load() != 0
+	}
+	return groups
+}
+
+type fileResult struct {
+	name string
+	// header is only set if hasHeader is.
+	header    csrfile.Header
+	hasHeader bool
+	// decoded is set if the edges of the file could be read.
+	decoded bool
+	edges   uint64
+	issues  []Issue
+}
+
+func (f *fileResult) add(kind, format string, args ...any) {
+	f.issues = append(f.issues, Issue{Kind: kind, Object: f.name, Message: fmt.Sprintf(format, args...)})
+}
+
+// Check reads every data object of the fetcher and checks that the files
+// cover a single range of nodes without overlaps, that their offset
+// tables are valid, that the edges of every node are sorted by label, that every
+// edge points to a stored node and that every outgoing edge has its
+// incoming edge. The headers are read first, then the files are read one
+// at a time per slot and only the hashes of their edges are kept. The
+// files whose edges do not match are read once more to list the
+// unmatched edges. Only failing to list the objects is returned as an
+// error, everything else is an issue of the report.
+func Check(fetcher storage.Fetcher, opts Options) (*Report, error) {
+	objects, err := fetcher.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+	objects = slices.DeleteFunc(objects, func(name string) bool {
+		return !graphaccess.IsDataObject(name)
+	})
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = graphaccess.ProbeParallelism
+	}
+	results := make([]fileResult, len(objects))
+	for i, name := range objects {
+		results[i].name = name
+	}
+	forEachFile(len(results), parallelism, func(i int) {
+		readHeader(fetcher, &results[i])
+	})
+
+	report := &Report{
+		Files:     len(objects),
+		Issues:    []Issue{},
+		Counts:    make(map[string]int),
+		maxIssues: opts.MaxIssues,
+	}
+	var files []*fileResult
+	for i := range results {
+		if results[i].hasHeader {
+			files = append(files, &results[i])
+		}
+	}
+	slices.SortFunc(files, func(a, b *fileResult) int {
+		return cmp.Compare(a.header.Start, b.header.Start)
+	})
+	covered := checkCoverage(report, files)
+	bal := newBalance(files)
+	forEachFile(len(results), parallelism, func(i int) {
+		if results[i].hasHeader {
+			checkFile(fetcher, &results[i], covered, bal)
+		}
+	})
+	for i := range results {
+		for _, issue := range results[i].issues {
+			report.add(issue.Kind, issue.Object, "%s", issue.Message)
+		}
+		report.Edges += results[i].edges
+	}
+	if unmatched := bal.unmatched(); slices.Contains(unmatched, true) {
+		checkMatching(report, fetcher, results, parallelism, func(t triple) bool {
+			return unmatched[bal.group(t.src)]
+		})
+	}
+	report.OK = len(report.Counts) == 0
+	return report, nil
+}
+
+// forEachFile calls fn for the indices of n files, at most parallelism at
+// once.
+func forEachFile(n, parallelism int, fn func(i int)) {
+	slots := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func readHeader(fetcher storage.Fetcher, res *fileResult) {
+	prefix, err := fetcher.Fetch(res.name, storage.BRange(0, csrfile.PrefixSize-1))
+	if err == nil && csrfile.IsVersioned(prefix) {
+		prefix, err = fetcher.Fetch(res.name, storage.BRange(0, csrfile.HeaderSize-1))
+	}
+	if err != nil {
+		res.add(KindCorrupt, "Unable to fetch the header: %s", err)
+		return
+	}
+	h, err := csrfile.ParseHeader(prefix)
+	if err != nil {
+		res.add(KindCorrupt, "%s", err)
+		return
+	}
+	res.header, res.hasHeader = h, true
+}
+
+// decodeFile fetches and decodes the file, the issues are added to res.
+func decodeFile(fetcher storage.Fetcher, res *fileResult) (csrfile.Header, []csrfile.Node, bool) {
+	data, err := fetcher.Fetch(res.name, storage.BRangeStart(0))
+	if err != nil {
+		res.add(KindCorrupt, "Unable to fetch: %s", err)
+		return csrfile.Header{}, nil, false
+	}
+	h, err := csrfile.ParseHeader(data)
+	if err != nil {
+		res.add(KindCorrupt, "%s", err)
+		return h, nil, false
+	}
+	if uint64(len(data)) < h.EdgesStart() {
+		res.add(KindCorrupt, "File is only %d bytes long but its edges start at %d", len(data), h.EdgesStart())
+		return h, nil, false
+	}
+	offsets := data[h.OffsetTableStart():h.EdgesStart()]
+	if err = h.InferLayout(offsets); err != nil {
+		res.add(KindCorrupt, "%s", err)
+		return h, nil, false
+	}
+	if h.IsLegacy() {
+		//The offsets of legacy files are bounded by their number of edges,
+		//which is only known from their size.
+		if err = h.ValidateEdges(data[h.EdgesStart():]); err != nil {
+			res.add(KindCorrupt, "%s", err)
+			return h, nil, false
+		}
+	}
+	if err = h.ValidateOffsets(offsets); err != nil {
+		res.add(KindOffsets, "%s", err)
+		return h, nil, false
+	}
+	h, nodes, err := csrfile.Decode(data)
+	if err != nil {
+		res.add(KindCorrupt, "%s", err)
+		return h, nil, false
+	}
+	return h, nodes, true
+}
+
+// checkFile checks the offsets and the edges of the file and adds the
+// edges to the balance.
+func checkFile(fetcher storage.Fetcher, res *fileResult, covered [][2]uint64, bal *balance) {
+	h, nodes, ok := decodeFile(fetcher, res)
+	if !ok {
+		return
+	}
+	res.decoded = true
+	byLabel := func(a, b csrfile.Edge) int {
+		return cmp.Compare(a.Label, b.Label)
+	}
+	for i, n := range nodes {
+		node := h.Start + uint64(i)
+		if !slices.IsSortedFunc(n.Out, byLabel) {
+			res.add(KindUnsorted, "Outgoing edges of node %d are not sorted by label", node)
+		}
+		if !slices.IsSortedFunc(n.In, byLabel) {
+			res.add(KindUnsorted, "Incoming edges of node %d are not sorted by label", node)
+		}
+		for _, e := range n.Out {
+			t := triple{src: node, dst: e.Dest, label: e.Label}
+			if !isStored(covered, e.Dest) {
+				res.add(KindDangling, "Outgoing edge %d -[%d]-> %d points to node %d that is not stored",
+					t.src, t.label, t.dst, e.Dest)
+			}
+			bal.add(t, true)
+		}
+		for _, e := range n.In {
+			t := triple{src: e.Dest, dst: node, label: e.Label}
+			if !isStored(covered, e.Dest) {
+				res.add(KindDangling, "Incoming edge %d -[%d]-> %d points to node %d that is not stored",
+					t.src, t.label, t.dst, e.Dest)
+			}
+			bal.add(t, false)
+		}
+		res.edges += uint64(len(n.Out) + len(n.In))
+	}
+}
+
+// checkCoverage reports the overlaps and gaps between the files, which are
+// sorted by their first node, and returns the ranges of nodes that are
+// stored, sorted and merged.
+func checkCoverage(report *Report, files []*fileResult) [][2]uint64 {
+	var covered [][2]uint64
+	for i, f := range files {
+		h := &f.header
+		report.Nodes += h.NumNodes()
+		if i == 0 {
+			report.Start, report.End = h.Start, h.End
+			covered = append(covered, [2]uint64{h.Start, h.End})
+			continue
+		}
+		last := &covered[len(covered)-1]
+		switch {
+		case h.Start <= last[1]:
+			report.add(KindOverlap, f.name, "Nodes %d to %d are also stored in another file",
+				h.Start, min(h.End, last[1]))
+			last[1] = max(last[1], h.End)
+		case h.Start > last[1]+1:
+			report.add(KindGap, f.name, "Nodes %d to %d before the file are not stored in any file",
+				last[1]+1, h.Start-1)
+			covered = append(covered, [2]uint64{h.Start, h.End})
+		default:
+			last[1] = h.End
+		}
+		report.End = max(report.End, h.End)
+	}
+	return covered
+}
+
+func isStored(covered [][2]uint64, node uint64) bool {
+	i, _ := slices.BinarySearchFunc(covered, node, func(r [2]uint64, node uint64) int {
+		return cmp.Compare(r[1], node)
+	})
+	return i < len(covered) && covered[i][0] <= node
+}
+
+// checkMatching reads the decoded files again and keeps the edges that
+// selected is true for, which are those of the groups that do not match.
+// Both lists of edges are sorted and the edges that are only in one of
+// them are reported.
+func checkMatching(report *Report, fetcher storage.Fetcher, results []fileResult, parallelism int, selected func(triple) bool) {
+	outs := make([][]triple, len(results))
+	ins := make([][]triple, len(results))
+	forEachFile(len(results), parallelism, func(i int) {
+		if !results[i].decoded {
+			return
+		}
+		//The issues of the file were reported by the first read.
+		h, nodes, ok := decodeFile(fetcher, &fileResult{name: results[i].name})
+		if !ok {
+			return
+		}
+		for n, node := range nodes {
+			for _, e := range node.Out {
+				if t := (triple{src: h.Start + uint64(n), dst: e.Dest, label: e.Label}); selected(t) {
+					outs[i] = append(outs[i], t)
+				}
+			}
+			for _, e := range node.In {
+				if t := (triple{src: e.Dest, dst: h.Start + uint64(n), label: e.Label}); selected(t) {
+					ins[i] = append(ins[i], t)
+				}
+			}
+		}
+	})
+	var out, in []triple
+	for i := range outs {
+		out = append(out, outs[i]...)
+		in = append(in, ins[i]...)
+	}
+	slices.SortFunc(out, compareTriples)
+	slices.SortFunc(in, compareTriples)
+	i, j := 0, 0
+	for i < len(out) || j < len(in) {
+		c := 0
+		switch {
+		case i == len(out):
+			c = 1
+		case j == len(in):
+			c = -1
+		default:
+			c = compareTriples(out[i], in[j])
+		}
+		switch {
+		case c < 0:
+			report.add(KindUnmatched, "", "Outgoing edge %d -[%d]-> %d has no incoming edge",
+				out[i].src, out[i].label, out[i].dst)
+			i++
+		case c > 0:
+			report.add(KindUnmatched, "", "Incoming edge %d -[%d]-> %d has no outgoing edge",
+				in[j].src, in[j].label, in[j].dst)
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+}
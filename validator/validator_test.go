@@ -0,0 +1,118 @@
+package validator
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adityachandla/graph_access_service/builder"
+	"github.com/adityachandla/graph_access_service/csrfile"
+	"github.com/adityachandla/graph_access_service/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+const edgeList = `0,1,2
+0,2,1
+3,1,0
+2,1,3
+1,1,1
+`
+
+// testGraph writes the graph of edgeList with a node per file.
+func testGraph(t *testing.T) (string, storage.Fetcher) {
+	b := builder.New()
+	assert.Nil(t, b.ReadEdgeList(strings.NewReader(edgeList)))
+	files, err := b.Files(builder.Options{NodesPerFile: 1, Encode: csrfile.EncodeOptions{Flags: csrfile.ByteOffsets}})
+	assert.Nil(t, err)
+	dir := t.TempDir()
+	fetcher := storage.InitializeFsService(dir)
+	writer := fetcher.(storage.Writer)
+	assert.Nil(t, builder.Write(writer, "", files))
+	assert.Nil(t, writer.Put("_manifest", []byte("not a CSR file")))
+	return dir, fetcher
+}
+
+func indicesFile(start uint64, offsets []uint32, edges []csrfile.Edge) []byte {
+	var offsetTable, edgeData []byte
+	for _, o := range offsets {
+		offsetTable = binary.LittleEndian.AppendUint32(offsetTable, o)
+	}
+	for _, e := range edges {
+		edgeData = binary.LittleEndian.AppendUint32(edgeData, e.Label)
+		edgeData = binary.LittleEndian.AppendUint32(edgeData, uint32(e.Dest))
+	}
+	h := csrfile.Header{IDWidth: 4, Start: start, End: start + uint64(len(offsets))/2 - 1}
+	return csrfile.Assemble(h, offsetTable, edgeData)
+}
+
+func TestValidGraph(t *testing.T) {
+	_, fetcher := testGraph(t)
+	report, err := Check(fetcher, Options{})
+	assert.Nil(t, err)
+	assert.True(t, report.OK)
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, 4, report.Files)
+	assert.Equal(t, uint64(4), report.Nodes)
+	assert.Equal(t, uint64(10), report.Edges)
+	assert.Equal(t, uint64(3), report.End)
+}
+
+func TestMissingAndOverlappingFiles(t *testing.T) {
+	dir, fetcher := testGraph(t)
+	assert.Nil(t, os.Remove(filepath.Join(dir, "nodes-1-1")))
+	data, err := fetcher.Fetch("nodes-3-3", storage.BRangeStart(0))
+	assert.Nil(t, err)
+	assert.Nil(t, fetcher.(storage.Writer).Put("copy", data))
+
+	report, err := Check(fetcher, Options{MaxIssues: 2})
+	assert.Nil(t, err)
+	assert.False(t, report.OK)
+	assert.Len(t, report.Issues, 2)
+	assert.Equal(t, 1, report.Counts[KindOverlap])
+	assert.Equal(t, 1, report.Counts[KindGap])
+	//The edge from node 0 to node 1 is only stored at node 0 and both
+	//edges of node 3 are stored twice.
+	assert.Equal(t, 1, report.Counts[KindDangling])
+	assert.Equal(t, 3, report.Counts[KindUnmatched])
+	assert.Equal(t, uint64(4), report.Nodes)
+}
+
+func TestCorruptFiles(t *testing.T) {
+	fetcher := storage.InitializeFsService(t.TempDir())
+	writer := fetcher.(storage.Writer)
+	edges := []csrfile.Edge{{Label: 2, Dest: 0}, {Label: 1, Dest: 0}}
+	assert.Nil(t, writer.Put("a", indicesFile(0, []uint32{0, 2}, append(edges, edges...))))
+	assert.Nil(t, writer.Put("b", indicesFile(1, []uint32{0, 1, 0, 1}, nil)))
+	assert.Nil(t, writer.Put("c", []byte("GCSR")))
+
+	report, err := Check(fetcher, Options{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, report.Counts[KindUnsorted])
+	assert.Equal(t, 1, report.Counts[KindOffsets])
+	assert.Equal(t, 1, report.Counts[KindCorrupt])
+	assert.Equal(t, "c", report.Issues[len(report.Issues)-1].Object)
+	assert.Zero(t, report.Counts[KindUnmatched])
+	assert.Zero(t, report.Counts[KindGap])
+}
+
+func TestBalance(t *testing.T) {
+	files := []*fileResult{{header: csrfile.Header{Start: 0}}, {header: csrfile.Header{Start: 10}}}
+	bal := newBalance(files)
+	edges := []triple{{src: 1, dst: 12, label: 1}, {src: 12, dst: 1, label: 1}, {src: 1, dst: 12, label: 2}}
+	for _, e := range edges {
+		bal.add(e, true)
+	}
+	for i := len(edges) - 1; i >= 0; i-- {
+		bal.add(edges[i], false)
+	}
+	assert.Equal(t, []bool{false, false, false}, bal.unmatched())
+
+	//A duplicate edge does not cancel out, nor does the reversed edge.
+	bal.add(edges[0], true)
+	bal.add(edges[0], true)
+	bal.add(edges[1], false)
+	bal.add(edges[1], false)
+	assert.Equal(t, []bool{false, true, true}, bal.unmatched())
+}